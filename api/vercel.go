@@ -3,18 +3,63 @@ package api
 import (
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 
 	"csz.net/tgstate/conf"
 	"csz.net/tgstate/control"
 )
 
+// envOr 读取环境变量，未设置时使用默认值
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
 func Vercel(w http.ResponseWriter, r *http.Request) {
 	conf.BotToken = os.Getenv("token")
 	conf.ChannelName = os.Getenv("target")
 	conf.Pass = os.Getenv("pass")
 	conf.Mode = os.Getenv("mode")
 	conf.BaseUrl = os.Getenv("url")
+	conf.DSN = os.Getenv("dsn")
+	if conf.DSN == "" {
+		// Vercel 只有 /tmp 可写，且实例之间不共享，持久化需要配置外部数据库
+		conf.DSN = "sqlite:///tmp/files.db"
+	}
+	conf.DBBusyTimeout = 5000
+	// Vercel 不解析命令行参数，与 main.go 中参数的默认值保持一致
+	conf.ApiPass = os.Getenv("apiPass")
+	conf.AllowedExts = os.Getenv("exts")
+	conf.ProxyUrl = os.Getenv("proxyUrl")
+	conf.Compress = os.Getenv("compress")
+	conf.CacheDir = envOr("cacheDir", "/tmp/cache")
+	// Vercel 的 /tmp 只有 512MB
+	conf.CacheMaxSize, _ = strconv.ParseInt(envOr("cacheMaxSize", "268435456"), 10, 64)
+	conf.StripMetadata = os.Getenv("stripMeta") != "false"
+	conf.Convert = os.Getenv("convert")
+	conf.ConvertQuality, _ = strconv.Atoi(os.Getenv("convertQuality"))
+	conf.UploadAsPhoto = os.Getenv("asPhoto") == "true"
+	conf.NameLookup = os.Getenv("nameLookup") == "true"
+	conf.AnonymousUpload = os.Getenv("anonymousUpload") != "false"
+	conf.Registration = os.Getenv("registration") != "false"
+	conf.Admins = os.Getenv("admins")
+	conf.DefaultRole = envOr("defaultRole", "uploader")
+	conf.QuotaDailyBytes, _ = strconv.ParseInt(os.Getenv("quotaDailyBytes"), 10, 64)
+	conf.QuotaDailyFiles, _ = strconv.ParseInt(os.Getenv("quotaDailyFiles"), 10, 64)
+	conf.QuotaTotalBytes, _ = strconv.ParseInt(os.Getenv("quotaTotalBytes"), 10, 64)
+	conf.TrustedProxies = envOr("trustedProxies", "127.0.0.1,::1")
+	conf.ClientIPHeader = envOr("clientIPHeader", "X-Forwarded-For")
+	if conf.Mode != "p" && conf.Mode != "m" {
+		conf.Mode = "p"
+	}
+	if _, err := control.InitDB(); err != nil {
+		http.Error(w, "Failed to open database", http.StatusInternalServerError)
+		return
+	}
+	control.CaptureChannelFiles()
 	// 获取请求路径
 	path := r.URL.Path
 	// 如果请求路径以 "/img/" 开头
@@ -22,6 +67,10 @@ func Vercel(w http.ResponseWriter, r *http.Request) {
 		control.D(w, r)
 		return // 结束处理，确保不执行默认处理
 	}
+	if strings.HasPrefix(path, "/thumb/") {
+		control.Thumb(w, r)
+		return
+	}
 	switch path {
 	case "/api":
 		// 调用 control 包中的 UploadAPI 处理函数
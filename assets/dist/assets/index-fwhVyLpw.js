@@ -1,4 +1,4 @@
-import{a as Jt,d as we,u as $t,c as Kt,b as V,o as P,m as Qt,r as St,n as ye,e as Ee,f as w,g as qt,h as D,w as nt,i as X,t as N,j as K,k as q,l as ae,F as Xe,p as Ye,q as Ce,s as er,v as tr,x as rr,y as ot,z as nr}from"./vendor-D_MnsSZ4.js";(function(){const t=document.createElement("link").relList;if(t&&t.supports&&t.supports("modulepreload"))return;for(const a of document.querySelectorAll('link[rel="modulepreload"]'))n(a);new MutationObserver(a=>{for(const o of a)if(o.type==="childList")for(const i of o.addedNodes)i.tagName==="LINK"&&i.rel==="modulepreload"&&n(i)}).observe(document,{childList:!0,subtree:!0});function r(a){const o={};return a.integrity&&(o.integrity=a.integrity),a.referrerPolicy&&(o.referrerPolicy=a.referrerPolicy),a.crossOrigin==="use-credentials"?o.credentials="include":a.crossOrigin==="anonymous"?o.credentials="omit":o.credentials="same-origin",o}function n(a){if(a.ep)return;a.ep=!0;const o=r(a);fetch(a.href,o)}})();const Me=Jt.create({baseURL:"/",timeout:3e4}),or=async(e,t,r,n)=>{const a=new FormData;return a.append("file",e),t&&a.append("userFingerprint",t),r!==void 0&&a.append("shared",r.toString()),(await Me.post("/api",a,{headers:{"Content-Type":"multipart/form-data"},onUploadProgress:i=>{if(i.total&&n){const s=i.loaded/i.total*100;n(s)}}})).data},ar=async(e,t,r,n,a,o)=>{const i=new FormData;return i.append("file",e,`${n}.chunk.${t}`),i.append("chunkIndex",t.toString()),i.append("uploadId",r),i.append("fileName",n),a&&i.append("userFingerprint",a),(await Me.post("/api/chunk",i,{headers:{"Content-Type":"multipart/form-data"},onUploadProgress:l=>{if(l.total&&o){const c=l.loaded/l.total*100;o(c)}}})).data},ir=async e=>(await Me.post("/api/merge",e,{headers:{"Content-Type":"application/json"}})).data,sr=async(e,t=1,r=20)=>(await Me.get(`/api/history?fingerprint=${encodeURIComponent(e)}&page=${t}&pageSize=${r}`)).data,lr=async(e=1,t=20)=>(await Me.get(`/api/plaza?page=${e}&pageSize=${t}`)).data;function Ct(e){var t,r,n="";if(typeof e=="string"||typeof e=="number")n+=e;else if(typeof e=="object")if(Array.isArray(e)){var a=e.length;for(t=0;t<a;t++)e[t]&&(r=Ct(e[t]))&&(n&&(n+=" "),n+=r)}else for(r in e)e[r]&&(n&&(n+=" "),n+=r);return n}function Lt(){for(var e,t,r=0,n="",a=arguments.length;r<a;r++)(e=arguments[r])&&(t=Ct(e))&&(n&&(n+=" "),n+=t);return n}const Qe="-",cr=e=>{const t=dr(e),{conflictingClassGroups:r,conflictingClassGroupModifiers:n}=e;return{getClassGroupId:i=>{const s=i.split(Qe);return s[0]===""&&s.length!==1&&s.shift(),Mt(s,t)||ur(i)},getConflictingClassGroupIds:(i,s)=>{const l=r[i]||[];return s&&n[i]?[...l,...n[i]]:l}}},Mt=(e,t)=>{var i;if(e.length===0)return t.classGroupId;const r=e[0],n=t.nextPart.get(r),a=n?Mt(e.slice(1),n):void 0;if(a)return a;if(t.validators.length===0)return;const o=e.join(Qe);return(i=t.validators.find(({validator:s})=>s(o)))==null?void 0:i.classGroupId},at=/^\[(.+)\]$/,ur=e=>{if(at.test(e)){const t=at.exec(e)[1],r=t==null?void 0:t.substring(0,t.indexOf(":"));if(r)return"arbitrary.."+r}},dr=e=>{const{theme:t,prefix:r}=e,n={nextPart:new Map,validators:[]};return pr(Object.entries(e.classGroups),r).forEach(([o,i])=>{Ue(i,n,o,t)}),n},Ue=(e,t,r,n)=>{e.forEach(a=>{if(typeof a=="string"){const o=a===""?t:it(t,a);o.classGroupId=r;return}if(typeof a=="function"){if(fr(a)){Ue(a(n),t,r,n);return}t.validators.push({validator:a,classGroupId:r});return}Object.entries(a).forEach(([o,i])=>{Ue(i,it(t,o),r,n)})})},it=(e,t)=>{let r=e;return t.split(Qe).forEach(n=>{r.nextPart.has(n)||r.nextPart.set(n,{nextPart:new Map,validators:[]}),r=r.nextPart.get(n)}),r},fr=e=>e.isThemeGetter,pr=(e,t)=>t?e.map(([r,n])=>{const a=n.map(o=>typeof o=="string"?t+o:typeof o=="object"?Object.fromEntries(Object.entries(o).map(([i,s])=>[t+i,s])):o);return[r,a]}):e,mr=e=>{if(e<1)return{get:()=>{},set:()=>{}};let t=0,r=new Map,n=new Map;const a=(o,i)=>{r.set(o,i),t++,t>e&&(t=0,n=r,r=new Map)};return{get(o){let i=r.get(o);if(i!==void 0)return i;if((i=n.get(o))!==void 0)return a(o,i),i},set(o,i){r.has(o)?r.set(o,i):a(o,i)}}},Ft="!",vr=e=>{const{separator:t,experimentalParseClassName:r}=e,n=t.length===1,a=t[0],o=t.length,i=s=>{const l=[];let c=0,d=0,u;for(let p=0;p<s.length;p++){let h=s[p];if(c===0){if(h===a&&(n||s.slice(p,p+o)===t)){l.push(s.slice(d,p)),d=p+o;continue}if(h==="/"){u=p;continue}}h==="["?c++:h==="]"&&c--}const g=l.length===0?s:s.substring(d),y=g.startsWith(Ft),S=y?g.substring(1):g,b=u&&u>d?u-d:void 0;return{modifiers:l,hasImportantModifier:y,baseClassName:S,maybePostfixModifierPosition:b}};return r?s=>r({className:s,parseClassName:i}):i},gr=e=>{if(e.length<=1)return e;const t=[];let r=[];return e.forEach(n=>{n[0]==="["?(t.push(...r.sort(),n),r=[]):r.push(n)}),t.push(...r.sort()),t},hr=e=>({cache:mr(e.cacheSize),parseClassName:vr(e),...cr(e)}),br=/\s+/,yr=(e,t)=>{const{parseClassName:r,getClassGroupId:n,getConflictingClassGroupIds:a}=t,o=[],i=e.trim().split(br);let s="";for(let l=i.length-1;l>=0;l-=1){const c=i[l],{modifiers:d,hasImportantModifier:u,baseClassName:g,maybePostfixModifierPosition:y}=r(c);let S=!!y,b=n(S?g.substring(0,y):g);if(!b){if(!S){s=c+(s.length>0?" "+s:s);continue}if(b=n(g),!b){s=c+(s.length>0?" "+s:s);continue}S=!1}const p=gr(d).join(":"),h=u?p+Ft:p,k=h+b;if(o.includes(k))continue;o.push(k);const A=a(b,S);for(let L=0;L<A.length;++L){const G=A[L];o.push(h+G)}s=c+(s.length>0?" "+s:s)}return s};function wr(){let e=0,t,r,n="";for(;e<arguments.length;)(t=arguments[e++])&&(r=Pt(t))&&(n&&(n+=" "),n+=r);return n}const Pt=e=>{if(typeof e=="string")return e;let t,r="";for(let n=0;n<e.length;n++)e[n]&&(t=Pt(e[n]))&&(r&&(r+=" "),r+=t);return r};function xr(e,...t){let r,n,a,o=i;function i(l){const c=t.reduce((d,u)=>u(d),e());return r=hr(c),n=r.cache.get,a=r.cache.set,o=s,s(l)}function s(l){const c=n(l);if(c)return c;const d=yr(l,r);return a(l,d),d}return function(){return o(wr.apply(null,arguments))}}const z=e=>{const t=r=>r[e]||[];return t.isThemeGetter=!0,t},It=/^\[(?:([a-z-]+):)?(.+)\]$/i,kr=/^\d+\/\d+$/,Sr=new Set(["px","full","screen"]),Cr=/^(\d+(\.\d+)?)?(xs|sm|md|lg|xl)$/,Lr=/\d+(%|px|r?em|[sdl]?v([hwib]|min|max)|pt|pc|in|cm|mm|cap|ch|ex|r?lh|cq(w|h|i|b|min|max))|\b(calc|min|max|clamp)\(.+\)|^0$/,Mr=/^(rgba?|hsla?|hwb|(ok)?(lab|lch))\(.+\)$/,Fr=/^(inset_)?-?((\d+)?\.?(\d+)[a-z]+|0)_-?((\d+)?\.?(\d+)[a-z]+|0)/,Pr=/^(url|image|image-set|cross-fade|element|(repeating-)?(linear|radial|conic)-gradient)\(.+\)$/,ne=e=>be(e)||Sr.has(e)||kr.test(e),ce=e=>xe(e,"length",Wr),be=e=>!!e&&!Number.isNaN(Number(e)),Ze=e=>xe(e,"number",be),ke=e=>!!e&&Number.isInteger(Number(e)),Ir=e=>e.endsWith("%")&&be(e.slice(0,-1)),x=e=>It.test(e),ue=e=>Cr.test(e),Vr=new Set(["length","size","percentage"]),Ar=e=>xe(e,Vr,Vt),zr=e=>xe(e,"position",Vt),Rr=new Set(["image","url"]),Tr=e=>xe(e,Rr,Nr),Gr=e=>xe(e,"",Er),Se=()=>!0,xe=(e,t,r)=>{const n=It.exec(e);return n?n[1]?typeof t=="string"?n[1]===t:t.has(n[1]):r(n[2]):!1},Wr=e=>Lr.test(e)&&!Mr.test(e),Vt=()=>!1,Er=e=>Fr.test(e),Nr=e=>Pr.test(e),_r=()=>{const e=z("colors"),t=z("spacing"),r=z("blur"),n=z("brightness"),a=z("borderColor"),o=z("borderRadius"),i=z("borderSpacing"),s=z("borderWidth"),l=z("contrast"),c=z("grayscale"),d=z("hueRotate"),u=z("invert"),g=z("gap"),y=z("gradientColorStops"),S=z("gradientColorStopPositions"),b=z("inset"),p=z("margin"),h=z("opacity"),k=z("padding"),A=z("saturate"),L=z("scale"),G=z("sepia"),_=z("skew"),j=z("space"),C=z("translate"),se=()=>["auto","contain","none"],le=()=>["auto","hidden","clip","visible","scroll"],re=()=>["auto",x,t],f=()=>[x,t],v=()=>["",ne,ce],m=()=>["auto",be,x],W=()=>["bottom","center","left","left-bottom","left-top","right","right-bottom","right-top","top"],M=()=>["solid","dashed","dotted","double","none"],I=()=>["normal","multiply","screen","overlay","darken","lighten","color-dodge","color-burn","hard-light","soft-light","difference","exclusion","hue","saturation","color","luminosity"],R=()=>["start","end","center","between","around","evenly","stretch"],H=()=>["","0",x],J=()=>["auto","avoid","all","avoid-page","page","left","right","column"],E=()=>[be,x];return{cacheSize:500,separator:":",theme:{colors:[Se],spacing:[ne,ce],blur:["none","",ue,x],brightness:E(),borderColor:[e],borderRadius:["none","","full",ue,x],borderSpacing:f(),borderWidth:v(),contrast:E(),grayscale:H(),hueRotate:E(),invert:H(),gap:f(),gradientColorStops:[e],gradientColorStopPositions:[Ir,ce],inset:re(),margin:re(),opacity:E(),padding:f(),saturate:E(),scale:E(),sepia:H(),skew:E(),space:f(),translate:f()},classGroups:{aspect:[{aspect:["auto","square","video",x]}],container:["container"],columns:[{columns:[ue]}],"break-after":[{"break-after":J()}],"break-before":[{"break-before":J()}],"break-inside":[{"break-inside":["auto","avoid","avoid-page","avoid-column"]}],"box-decoration":[{"box-decoration":["slice","clone"]}],box:[{box:["border","content"]}],display:["block","inline-block","inline","flex","inline-flex","table","inline-table","table-caption","table-cell","table-column","table-column-group","table-footer-group","table-header-group","table-row-group","table-row","flow-root","grid","inline-grid","contents","list-item","hidden"],float:[{float:["right","left","none","start","end"]}],clear:[{clear:["left","right","both","none","start","end"]}],isolation:["isolate","isolation-auto"],"object-fit":[{object:["contain","cover","fill","none","scale-down"]}],"object-position":[{object:[...W(),x]}],overflow:[{overflow:le()}],"overflow-x":[{"overflow-x":le()}],"overflow-y":[{"overflow-y":le()}],overscroll:[{overscroll:se()}],"overscroll-x":[{"overscroll-x":se()}],"overscroll-y":[{"overscroll-y":se()}],position:["static","fixed","absolute","relative","sticky"],inset:[{inset:[b]}],"inset-x":[{"inset-x":[b]}],"inset-y":[{"inset-y":[b]}],start:[{start:[b]}],end:[{end:[b]}],top:[{top:[b]}],right:[{right:[b]}],bottom:[{bottom:[b]}],left:[{left:[b]}],visibility:["visible","invisible","collapse"],z:[{z:["auto",ke,x]}],basis:[{basis:re()}],"flex-direction":[{flex:["row","row-reverse","col","col-reverse"]}],"flex-wrap":[{flex:["wrap","wrap-reverse","nowrap"]}],flex:[{flex:["1","auto","initial","none",x]}],grow:[{grow:H()}],shrink:[{shrink:H()}],order:[{order:["first","last","none",ke,x]}],"grid-cols":[{"grid-cols":[Se]}],"col-start-end":[{col:["auto",{span:["full",ke,x]},x]}],"col-start":[{"col-start":m()}],"col-end":[{"col-end":m()}],"grid-rows":[{"grid-rows":[Se]}],"row-start-end":[{row:["auto",{span:[ke,x]},x]}],"row-start":[{"row-start":m()}],"row-end":[{"row-end":m()}],"grid-flow":[{"grid-flow":["row","col","dense","row-dense","col-dense"]}],"auto-cols":[{"auto-cols":["auto","min","max","fr",x]}],"auto-rows":[{"auto-rows":["auto","min","max","fr",x]}],gap:[{gap:[g]}],"gap-x":[{"gap-x":[g]}],"gap-y":[{"gap-y":[g]}],"justify-content":[{justify:["normal",...R()]}],"justify-items":[{"justify-items":["start","end","center","stretch"]}],"justify-self":[{"justify-self":["auto","start","end","center","stretch"]}],"align-content":[{content:["normal",...R(),"baseline"]}],"align-items":[{items:["start","end","center","baseline","stretch"]}],"align-self":[{self:["auto","start","end","center","stretch","baseline"]}],"place-content":[{"place-content":[...R(),"baseline"]}],"place-items":[{"place-items":["start","end","center","baseline","stretch"]}],"place-self":[{"place-self":["auto","start","end","center","stretch"]}],p:[{p:[k]}],px:[{px:[k]}],py:[{py:[k]}],ps:[{ps:[k]}],pe:[{pe:[k]}],pt:[{pt:[k]}],pr:[{pr:[k]}],pb:[{pb:[k]}],pl:[{pl:[k]}],m:[{m:[p]}],mx:[{mx:[p]}],my:[{my:[p]}],ms:[{ms:[p]}],me:[{me:[p]}],mt:[{mt:[p]}],mr:[{mr:[p]}],mb:[{mb:[p]}],ml:[{ml:[p]}],"space-x":[{"space-x":[j]}],"space-x-reverse":["space-x-reverse"],"space-y":[{"space-y":[j]}],"space-y-reverse":["space-y-reverse"],w:[{w:["auto","min","max","fit","svw","lvw","dvw",x,t]}],"min-w":[{"min-w":[x,t,"min","max","fit"]}],"max-w":[{"max-w":[x,t,"none","full","min","max","fit","prose",{screen:[ue]},ue]}],h:[{h:[x,t,"auto","min","max","fit","svh","lvh","dvh"]}],"min-h":[{"min-h":[x,t,"min","max","fit","svh","lvh","dvh"]}],"max-h":[{"max-h":[x,t,"min","max","fit","svh","lvh","dvh"]}],size:[{size:[x,t,"auto","min","max","fit"]}],"font-size":[{text:["base",ue,ce]}],"font-smoothing":["antialiased","subpixel-antialiased"],"font-style":["italic","not-italic"],"font-weight":[{font:["thin","extralight","light","normal","medium","semibold","bold","extrabold","black",Ze]}],"font-family":[{font:[Se]}],"fvn-normal":["normal-nums"],"fvn-ordinal":["ordinal"],"fvn-slashed-zero":["slashed-zero"],"fvn-figure":["lining-nums","oldstyle-nums"],"fvn-spacing":["proportional-nums","tabular-nums"],"fvn-fraction":["diagonal-fractions","stacked-fractions"],tracking:[{tracking:["tighter","tight","normal","wide","wider","widest",x]}],"line-clamp":[{"line-clamp":["none",be,Ze]}],leading:[{leading:["none","tight","snug","normal","relaxed","loose",ne,x]}],"list-image":[{"list-image":["none",x]}],"list-style-type":[{list:["none","disc","decimal",x]}],"list-style-position":[{list:["inside","outside"]}],"placeholder-color":[{placeholder:[e]}],"placeholder-opacity":[{"placeholder-opacity":[h]}],"text-alignment":[{text:["left","center","right","justify","start","end"]}],"text-color":[{text:[e]}],"text-opacity":[{"text-opacity":[h]}],"text-decoration":["underline","overline","line-through","no-underline"],"text-decoration-style":[{decoration:[...M(),"wavy"]}],"text-decoration-thickness":[{decoration:["auto","from-font",ne,ce]}],"underline-offset":[{"underline-offset":["auto",ne,x]}],"text-decoration-color":[{decoration:[e]}],"text-transform":["uppercase","lowercase","capitalize","normal-case"],"text-overflow":["truncate","text-ellipsis","text-clip"],"text-wrap":[{text:["wrap","nowrap","balance","pretty"]}],indent:[{indent:f()}],"vertical-align":[{align:["baseline","top","middle","bottom","text-top","text-bottom","sub","super",x]}],whitespace:[{whitespace:["normal","nowrap","pre","pre-line","pre-wrap","break-spaces"]}],break:[{break:["normal","words","all","keep"]}],hyphens:[{hyphens:["none","manual","auto"]}],content:[{content:["none",x]}],"bg-attachment":[{bg:["fixed","local","scroll"]}],"bg-clip":[{"bg-clip":["border","padding","content","text"]}],"bg-opacity":[{"bg-opacity":[h]}],"bg-origin":[{"bg-origin":["border","padding","content"]}],"bg-position":[{bg:[...W(),zr]}],"bg-repeat":[{bg:["no-repeat",{repeat:["","x","y","round","space"]}]}],"bg-size":[{bg:["auto","cover","contain",Ar]}],"bg-image":[{bg:["none",{"gradient-to":["t","tr","r","br","b","bl","l","tl"]},Tr]}],"bg-color":[{bg:[e]}],"gradient-from-pos":[{from:[S]}],"gradient-via-pos":[{via:[S]}],"gradient-to-pos":[{to:[S]}],"gradient-from":[{from:[y]}],"gradient-via":[{via:[y]}],"gradient-to":[{to:[y]}],rounded:[{rounded:[o]}],"rounded-s":[{"rounded-s":[o]}],"rounded-e":[{"rounded-e":[o]}],"rounded-t":[{"rounded-t":[o]}],"rounded-r":[{"rounded-r":[o]}],"rounded-b":[{"rounded-b":[o]}],"rounded-l":[{"rounded-l":[o]}],"rounded-ss":[{"rounded-ss":[o]}],"rounded-se":[{"rounded-se":[o]}],"rounded-ee":[{"rounded-ee":[o]}],"rounded-es":[{"rounded-es":[o]}],"rounded-tl":[{"rounded-tl":[o]}],"rounded-tr":[{"rounded-tr":[o]}],"rounded-br":[{"rounded-br":[o]}],"rounded-bl":[{"rounded-bl":[o]}],"border-w":[{border:[s]}],"border-w-x":[{"border-x":[s]}],"border-w-y":[{"border-y":[s]}],"border-w-s":[{"border-s":[s]}],"border-w-e":[{"border-e":[s]}],"border-w-t":[{"border-t":[s]}],"border-w-r":[{"border-r":[s]}],"border-w-b":[{"border-b":[s]}],"border-w-l":[{"border-l":[s]}],"border-opacity":[{"border-opacity":[h]}],"border-style":[{border:[...M(),"hidden"]}],"divide-x":[{"divide-x":[s]}],"divide-x-reverse":["divide-x-reverse"],"divide-y":[{"divide-y":[s]}],"divide-y-reverse":["divide-y-reverse"],"divide-opacity":[{"divide-opacity":[h]}],"divide-style":[{divide:M()}],"border-color":[{border:[a]}],"border-color-x":[{"border-x":[a]}],"border-color-y":[{"border-y":[a]}],"border-color-s":[{"border-s":[a]}],"border-color-e":[{"border-e":[a]}],"border-color-t":[{"border-t":[a]}],"border-color-r":[{"border-r":[a]}],"border-color-b":[{"border-b":[a]}],"border-color-l":[{"border-l":[a]}],"divide-color":[{divide:[a]}],"outline-style":[{outline:["",...M()]}],"outline-offset":[{"outline-offset":[ne,x]}],"outline-w":[{outline:[ne,ce]}],"outline-color":[{outline:[e]}],"ring-w":[{ring:v()}],"ring-w-inset":["ring-inset"],"ring-color":[{ring:[e]}],"ring-opacity":[{"ring-opacity":[h]}],"ring-offset-w":[{"ring-offset":[ne,ce]}],"ring-offset-color":[{"ring-offset":[e]}],shadow:[{shadow:["","inner","none",ue,Gr]}],"shadow-color":[{shadow:[Se]}],opacity:[{opacity:[h]}],"mix-blend":[{"mix-blend":[...I(),"plus-lighter","plus-darker"]}],"bg-blend":[{"bg-blend":I()}],filter:[{filter:["","none"]}],blur:[{blur:[r]}],brightness:[{brightness:[n]}],contrast:[{contrast:[l]}],"drop-shadow":[{"drop-shadow":["","none",ue,x]}],grayscale:[{grayscale:[c]}],"hue-rotate":[{"hue-rotate":[d]}],invert:[{invert:[u]}],saturate:[{saturate:[A]}],sepia:[{sepia:[G]}],"backdrop-filter":[{"backdrop-filter":["","none"]}],"backdrop-blur":[{"backdrop-blur":[r]}],"backdrop-brightness":[{"backdrop-brightness":[n]}],"backdrop-contrast":[{"backdrop-contrast":[l]}],"backdrop-grayscale":[{"backdrop-grayscale":[c]}],"backdrop-hue-rotate":[{"backdrop-hue-rotate":[d]}],"backdrop-invert":[{"backdrop-invert":[u]}],"backdrop-opacity":[{"backdrop-opacity":[h]}],"backdrop-saturate":[{"backdrop-saturate":[A]}],"backdrop-sepia":[{"backdrop-sepia":[G]}],"border-collapse":[{border:["collapse","separate"]}],"border-spacing":[{"border-spacing":[i]}],"border-spacing-x":[{"border-spacing-x":[i]}],"border-spacing-y":[{"border-spacing-y":[i]}],"table-layout":[{table:["auto","fixed"]}],caption:[{caption:["top","bottom"]}],transition:[{transition:["none","all","","colors","opacity","shadow","transform",x]}],duration:[{duration:E()}],ease:[{ease:["linear","in","out","in-out",x]}],delay:[{delay:E()}],animate:[{animate:["none","spin","ping","pulse","bounce",x]}],transform:[{transform:["","gpu","none"]}],scale:[{scale:[L]}],"scale-x":[{"scale-x":[L]}],"scale-y":[{"scale-y":[L]}],rotate:[{rotate:[ke,x]}],"translate-x":[{"translate-x":[C]}],"translate-y":[{"translate-y":[C]}],"skew-x":[{"skew-x":[_]}],"skew-y":[{"skew-y":[_]}],"transform-origin":[{origin:["center","top","top-right","right","bottom-right","bottom","bottom-left","left","top-left",x]}],accent:[{accent:["auto",e]}],appearance:[{appearance:["none","auto"]}],cursor:[{cursor:["auto","default","pointer","wait","text","move","help","not-allowed","none","context-menu","progress","cell","crosshair","vertical-text","alias","copy","no-drop","grab","grabbing","all-scroll","col-resize","row-resize","n-resize","e-resize","s-resize","w-resize","ne-resize","nw-resize","se-resize","sw-resize","ew-resize","ns-resize","nesw-resize","nwse-resize","zoom-in","zoom-out",x]}],"caret-color":[{caret:[e]}],"pointer-events":[{"pointer-events":["none","auto"]}],resize:[{resize:["none","y","x",""]}],"scroll-behavior":[{scroll:["auto","smooth"]}],"scroll-m":[{"scroll-m":f()}],"scroll-mx":[{"scroll-mx":f()}],"scroll-my":[{"scroll-my":f()}],"scroll-ms":[{"scroll-ms":f()}],"scroll-me":[{"scroll-me":f()}],"scroll-mt":[{"scroll-mt":f()}],"scroll-mr":[{"scroll-mr":f()}],"scroll-mb":[{"scroll-mb":f()}],"scroll-ml":[{"scroll-ml":f()}],"scroll-p":[{"scroll-p":f()}],"scroll-px":[{"scroll-px":f()}],"scroll-py":[{"scroll-py":f()}],"scroll-ps":[{"scroll-ps":f()}],"scroll-pe":[{"scroll-pe":f()}],"scroll-pt":[{"scroll-pt":f()}],"scroll-pr":[{"scroll-pr":f()}],"scroll-pb":[{"scroll-pb":f()}],"scroll-pl":[{"scroll-pl":f()}],"snap-align":[{snap:["start","end","center","align-none"]}],"snap-stop":[{snap:["normal","always"]}],"snap-type":[{snap:["none","x","y","both"]}],"snap-strictness":[{snap:["mandatory","proximity"]}],touch:[{touch:["auto","none","manipulation"]}],"touch-x":[{"touch-pan":["x","left","right"]}],"touch-y":[{"touch-pan":["y","up","down"]}],"touch-pz":["touch-pinch-zoom"],select:[{select:["none","text","all","auto"]}],"will-change":[{"will-change":["auto","scroll","contents","transform",x]}],fill:[{fill:[e,"none"]}],"stroke-w":[{stroke:[ne,ce,Ze]}],stroke:[{stroke:[e,"none"]}],sr:["sr-only","not-sr-only"],"forced-color-adjust":[{"forced-color-adjust":["auto","none"]}]},conflictingClassGroups:{overflow:["overflow-x","overflow-y"],overscroll:["overscroll-x","overscroll-y"],inset:["inset-x","inset-y","start","end","top","right","bottom","left"],"inset-x":["right","left"],"inset-y":["top","bottom"],flex:["basis","grow","shrink"],gap:["gap-x","gap-y"],p:["px","py","ps","pe","pt","pr","pb","pl"],px:["pr","pl"],py:["pt","pb"],m:["mx","my","ms","me","mt","mr","mb","ml"],mx:["mr","ml"],my:["mt","mb"],size:["w","h"],"font-size":["leading"],"fvn-normal":["fvn-ordinal","fvn-slashed-zero","fvn-figure","fvn-spacing","fvn-fraction"],"fvn-ordinal":["fvn-normal"],"fvn-slashed-zero":["fvn-normal"],"fvn-figure":["fvn-normal"],"fvn-spacing":["fvn-normal"],"fvn-fraction":["fvn-normal"],"line-clamp":["display","overflow"],rounded:["rounded-s","rounded-e","rounded-t","rounded-r","rounded-b","rounded-l","rounded-ss","rounded-se","rounded-ee","rounded-es","rounded-tl","rounded-tr","rounded-br","rounded-bl"],"rounded-s":["rounded-ss","rounded-es"],"rounded-e":["rounded-se","rounded-ee"],"rounded-t":["rounded-tl","rounded-tr"],"rounded-r":["rounded-tr","rounded-br"],"rounded-b":["rounded-br","rounded-bl"],"rounded-l":["rounded-tl","rounded-bl"],"border-spacing":["border-spacing-x","border-spacing-y"],"border-w":["border-w-s","border-w-e","border-w-t","border-w-r","border-w-b","border-w-l"],"border-w-x":["border-w-r","border-w-l"],"border-w-y":["border-w-t","border-w-b"],"border-color":["border-color-s","border-color-e","border-color-t","border-color-r","border-color-b","border-color-l"],"border-color-x":["border-color-r","border-color-l"],"border-color-y":["border-color-t","border-color-b"],"scroll-m":["scroll-mx","scroll-my","scroll-ms","scroll-me","scroll-mt","scroll-mr","scroll-mb","scroll-ml"],"scroll-mx":["scroll-mr","scroll-ml"],"scroll-my":["scroll-mt","scroll-mb"],"scroll-p":["scroll-px","scroll-py","scroll-ps","scroll-pe","scroll-pt","scroll-pr","scroll-pb","scroll-pl"],"scroll-px":["scroll-pr","scroll-pl"],"scroll-py":["scroll-pt","scroll-pb"],touch:["touch-x","touch-y","touch-pz"],"touch-x":["touch"],"touch-y":["touch"],"touch-pz":["touch"]},conflictingClassGroupModifiers:{"font-size":["leading"]}}},jr=xr(_r);function qe(...e){return jr(Lt(e))}function Zr(e){if(e===0)return"0 Bytes";const t=1024,r=["Bytes","KB","MB","GB","TB"],n=Math.floor(Math.log(e)/Math.log(t));return parseFloat((e/Math.pow(t,n)).toFixed(2))+" "+r[n]}function Dr(e){return Zr(e)+"/s"}function Or(e){if(e<60)return`${Math.round(e)}s`;if(e<3600){const t=Math.floor(e/60),r=Math.round(e%60);return`${t}m ${r}s`}else{const t=Math.floor(e/3600),r=Math.floor(e%3600/60);return`${t}h ${r}m`}}function me(){return Date.now().toString(36)+Math.random().toString(36).substr(2)}async function At(e){try{if(navigator.clipboard&&window.isSecureContext)return await navigator.clipboard.writeText(e),!0;{const t=document.createElement("textarea");t.value=e,t.style.position="fixed",t.style.left="-999999px",t.style.top="-999999px",document.body.appendChild(t),t.focus(),t.select();const r=document.execCommand("copy");return t.remove(),r}}catch(t){return console.error("Failed to copy text: ",t),!1}}var Je=function(){return Je=Object.assign||function(t){for(var r,n=1,a=arguments.length;n<a;n++){r=arguments[n];for(var o in r)Object.prototype.hasOwnProperty.call(r,o)&&(t[o]=r[o])}return t},Je.apply(this,arguments)};function ee(e,t,r,n){function a(o){return o instanceof r?o:new r(function(i){i(o)})}return new(r||(r=Promise))(function(o,i){function s(d){try{c(n.next(d))}catch(u){i(u)}}function l(d){try{c(n.throw(d))}catch(u){i(u)}}function c(d){d.done?o(d.value):a(d.value).then(s,l)}c((n=n.apply(e,t||[])).next())})}function te(e,t){var r={label:0,sent:function(){if(o[0]&1)throw o[1];return o[1]},trys:[],ops:[]},n,a,o,i=Object.create((typeof Iterator=="function"?Iterator:Object).prototype);return i.next=s(0),i.throw=s(1),i.return=s(2),typeof Symbol=="function"&&(i[Symbol.iterator]=function(){return this}),i;function s(c){return function(d){return l([c,d])}}function l(c){if(n)throw new TypeError("Generator is already executing.");for(;i&&(i=0,c[0]&&(r=0)),r;)try{if(n=1,a&&(o=c[0]&2?a.return:c[0]?a.throw||((o=a.return)&&o.call(a),0):a.next)&&!(o=o.call(a,c[1])).done)return o;switch(a=0,o&&(c=[c[0]&2,o.value]),c[0]){case 0:case 1:o=c;break;case 4:return r.label++,{value:c[1],done:!1};case 5:r.label++,a=c[1],c=[0];continue;case 7:c=r.ops.pop(),r.trys.pop();continue;default:if(o=r.trys,!(o=o.length>0&&o[o.length-1])&&(c[0]===6||c[0]===2)){r=0;continue}if(c[0]===3&&(!o||c[1]>o[0]&&c[1]<o[3])){r.label=c[1];break}if(c[0]===6&&r.label<o[1]){r.label=o[1],o=c;break}if(o&&r.label<o[2]){r.label=o[2],r.ops.push(c);break}o[2]&&r.ops.pop(),r.trys.pop();continue}c=t.call(e,r)}catch(d){c=[6,d],a=0}finally{n=o=0}if(c[0]&5)throw c[1];return{value:c[0]?c[1]:void 0,done:!0}}}function zt(e,t,r){if(r||arguments.length===2)for(var n=0,a=t.length,o;n<a;n++)(o||!(n in t))&&(o||(o=Array.prototype.slice.call(t,0,n)),o[n]=t[n]);return e.concat(o||Array.prototype.slice.call(t))}var Rt="4.6.2";function Ne(e,t){return new Promise(function(r){return setTimeout(r,e,t)})}function Hr(){return new Promise(function(e){var t=new MessageChannel;t.port1.onmessage=function(){return e()},t.port2.postMessage(null)})}function Br(e,t){t===void 0&&(t=1/0);var r=window.requestIdleCallback;return r?new Promise(function(n){return r.call(window,function(){return n()},{timeout:t})}):Ne(Math.min(e,t))}function Tt(e){return!!e&&typeof e.then=="function"}function st(e,t){try{var r=e();Tt(r)?r.then(function(n){return t(!0,n)},function(n){return t(!1,n)}):t(!0,r)}catch(n){t(!1,n)}}function lt(e,t,r){return r===void 0&&(r=16),ee(this,void 0,void 0,function(){var n,a,o,i;return te(this,function(s){switch(s.label){case 0:n=Array(e.length),a=Date.now(),o=0,s.label=1;case 1:return o<e.length?(n[o]=t(e[o],o),i=Date.now(),i>=a+r?(a=i,[4,Hr()]):[3,3]):[3,4];case 2:s.sent(),s.label=3;case 3:return++o,[3,1];case 4:return[2,n]}})})}function Le(e){return e.then(void 0,function(){}),e}function Xr(e,t){for(var r=0,n=e.length;r<n;++r)if(e[r]===t)return!0;return!1}function Yr(e,t){return!Xr(e,t)}function et(e){return parseInt(e)}function $(e){return parseFloat(e)}function oe(e,t){return typeof e=="number"&&isNaN(e)?t:e}function O(e){return e.reduce(function(t,r){return t+(r?1:0)},0)}function Gt(e,t){if(t===void 0&&(t=1),Math.abs(t)>=1)return Math.round(e/t)*t;var r=1/t;return Math.round(e*r)/r}function Ur(e){for(var t,r,n="Unexpected syntax '".concat(e,"'"),a=/^\s*([a-z-]*)(.*)$/i.exec(e),o=a[1]||void 0,i={},s=/([.:#][\w-]+|\[.+?\])/gi,l=function(g,y){i[g]=i[g]||[],i[g].push(y)};;){var c=s.exec(a[2]);if(!c)break;var d=c[0];switch(d[0]){case".":l("class",d.slice(1));break;case"#":l("id",d.slice(1));break;case"[":{var u=/^\[([\w-]+)([~|^$*]?=("(.*?)"|([\w-]+)))?(\s+[is])?\]$/.exec(d);if(u)l(u[1],(r=(t=u[4])!==null&&t!==void 0?t:u[5])!==null&&r!==void 0?r:"");else throw new Error(n);break}default:throw new Error(n)}}return[o,i]}function Jr(e){for(var t=new Uint8Array(e.length),r=0;r<e.length;r++){var n=e.charCodeAt(r);if(n>127)return new TextEncoder().encode(e);t[r]=n}return t}function de(e,t){var r=e[0]>>>16,n=e[0]&65535,a=e[1]>>>16,o=e[1]&65535,i=t[0]>>>16,s=t[0]&65535,l=t[1]>>>16,c=t[1]&65535,d=0,u=0,g=0,y=0;y+=o+c,g+=y>>>16,y&=65535,g+=a+l,u+=g>>>16,g&=65535,u+=n+s,d+=u>>>16,u&=65535,d+=r+i,d&=65535,e[0]=d<<16|u,e[1]=g<<16|y}function U(e,t){var r=e[0]>>>16,n=e[0]&65535,a=e[1]>>>16,o=e[1]&65535,i=t[0]>>>16,s=t[0]&65535,l=t[1]>>>16,c=t[1]&65535,d=0,u=0,g=0,y=0;y+=o*c,g+=y>>>16,y&=65535,g+=a*c,u+=g>>>16,g&=65535,g+=o*l,u+=g>>>16,g&=65535,u+=n*c,d+=u>>>16,u&=65535,u+=a*l,d+=u>>>16,u&=65535,u+=o*s,d+=u>>>16,u&=65535,d+=r*c+n*l+a*s+o*i,d&=65535,e[0]=d<<16|u,e[1]=g<<16|y}function ve(e,t){var r=e[0];t%=64,t===32?(e[0]=e[1],e[1]=r):t<32?(e[0]=r<<t|e[1]>>>32-t,e[1]=e[1]<<t|r>>>32-t):(t-=32,e[0]=e[1]<<t|r>>>32-t,e[1]=r<<t|e[1]>>>32-t)}function B(e,t){t%=64,t!==0&&(t<32?(e[0]=e[1]>>>32-t,e[1]=e[1]<<t):(e[0]=e[1]<<t-32,e[1]=0))}function T(e,t){e[0]^=t[0],e[1]^=t[1]}var $r=[4283543511,3981806797],Kr=[3301882366,444984403];function ct(e){var t=[0,e[0]>>>1];T(e,t),U(e,$r),t[1]=e[0]>>>1,T(e,t),U(e,Kr),t[1]=e[0]>>>1,T(e,t)}var Re=[2277735313,289559509],Te=[1291169091,658871167],ut=[0,5],Qr=[0,1390208809],qr=[0,944331445];function en(e,t){var r=Jr(e);t=t||0;var n=[0,r.length],a=n[1]%16,o=n[1]-a,i=[0,t],s=[0,t],l=[0,0],c=[0,0],d;for(d=0;d<o;d=d+16)l[0]=r[d+4]|r[d+5]<<8|r[d+6]<<16|r[d+7]<<24,l[1]=r[d]|r[d+1]<<8|r[d+2]<<16|r[d+3]<<24,c[0]=r[d+12]|r[d+13]<<8|r[d+14]<<16|r[d+15]<<24,c[1]=r[d+8]|r[d+9]<<8|r[d+10]<<16|r[d+11]<<24,U(l,Re),ve(l,31),U(l,Te),T(i,l),ve(i,27),de(i,s),U(i,ut),de(i,Qr),U(c,Te),ve(c,33),U(c,Re),T(s,c),ve(s,31),de(s,i),U(s,ut),de(s,qr);l[0]=0,l[1]=0,c[0]=0,c[1]=0;var u=[0,0];switch(a){case 15:u[1]=r[d+14],B(u,48),T(c,u);case 14:u[1]=r[d+13],B(u,40),T(c,u);case 13:u[1]=r[d+12],B(u,32),T(c,u);case 12:u[1]=r[d+11],B(u,24),T(c,u);case 11:u[1]=r[d+10],B(u,16),T(c,u);case 10:u[1]=r[d+9],B(u,8),T(c,u);case 9:u[1]=r[d+8],T(c,u),U(c,Te),ve(c,33),U(c,Re),T(s,c);case 8:u[1]=r[d+7],B(u,56),T(l,u);case 7:u[1]=r[d+6],B(u,48),T(l,u);case 6:u[1]=r[d+5],B(u,40),T(l,u);case 5:u[1]=r[d+4],B(u,32),T(l,u);case 4:u[1]=r[d+3],B(u,24),T(l,u);case 3:u[1]=r[d+2],B(u,16),T(l,u);case 2:u[1]=r[d+1],B(u,8),T(l,u);case 1:u[1]=r[d],T(l,u),U(l,Re),ve(l,31),U(l,Te),T(i,l)}return T(i,n),T(s,n),de(i,s),de(s,i),ct(i),ct(s),de(i,s),de(s,i),("00000000"+(i[0]>>>0).toString(16)).slice(-8)+("00000000"+(i[1]>>>0).toString(16)).slice(-8)+("00000000"+(s[0]>>>0).toString(16)).slice(-8)+("00000000"+(s[1]>>>0).toString(16)).slice(-8)}function tn(e){var t;return Je({name:e.name,message:e.message,stack:(t=e.stack)===null||t===void 0?void 0:t.split(`
+import{a as Jt,d as we,u as $t,c as Kt,b as V,o as P,m as Qt,r as St,n as ye,e as Ee,f as w,g as qt,h as D,w as nt,i as X,t as N,j as K,k as q,l as ae,F as Xe,p as Ye,q as Ce,s as er,v as tr,x as rr,y as ot,z as nr}from"./vendor-D_MnsSZ4.js";(function(){const t=document.createElement("link").relList;if(t&&t.supports&&t.supports("modulepreload"))return;for(const a of document.querySelectorAll('link[rel="modulepreload"]'))n(a);new MutationObserver(a=>{for(const o of a)if(o.type==="childList")for(const i of o.addedNodes)i.tagName==="LINK"&&i.rel==="modulepreload"&&n(i)}).observe(document,{childList:!0,subtree:!0});function r(a){const o={};return a.integrity&&(o.integrity=a.integrity),a.referrerPolicy&&(o.referrerPolicy=a.referrerPolicy),a.crossOrigin==="use-credentials"?o.credentials="include":a.crossOrigin==="anonymous"?o.credentials="omit":o.credentials="same-origin",o}function n(a){if(a.ep)return;a.ep=!0;const o=r(a);fetch(a.href,o)}})();let Qc;const Me=Jt.create({baseURL:"/",timeout:3e4}),Qd=()=>(Qc||(Qc=Jt.get("/api/me").then(e=>{var t,r;return(r=(t=e.data)==null?void 0:t.data)==null?void 0:r.csrfToken}).catch(()=>{}),Qc.then(e=>{e||(Qc=void 0)})),Qc),Qe_=Me.interceptors.request.use(async e=>{var t;if(((t=e.method)==null?void 0:t.toLowerCase())==="post"){const r=await Qd();r&&(e.headers["X-CSRF-Token"]=r)}return e}),or=async(e,t,r,n)=>{const a=new FormData;return a.append("file",e),t&&a.append("userFingerprint",t),r!==void 0&&a.append("shared",r.toString()),(await Me.post("/api",a,{headers:{"Content-Type":"multipart/form-data"},onUploadProgress:i=>{if(i.total&&n){const s=i.loaded/i.total*100;n(s)}}})).data},ar=async(e,t,r,n,a,o)=>{const i=new FormData;return i.append("file",e,`${n}.chunk.${t}`),i.append("chunkIndex",t.toString()),i.append("uploadId",r),i.append("fileName",n),a&&i.append("userFingerprint",a),(await Me.post("/api/chunk",i,{headers:{"Content-Type":"multipart/form-data"},onUploadProgress:l=>{if(l.total&&o){const c=l.loaded/l.total*100;o(c)}}})).data},ir=async e=>(await Me.post("/api/merge",e,{headers:{"Content-Type":"application/json"}})).data,sr=async(e,t=1,r=20)=>(await Me.get(`/api/history?fingerprint=${encodeURIComponent(e)}&page=${t}&pageSize=${r}`)).data,lr=async(e=1,t=20)=>(await Me.get(`/api/plaza?page=${e}&pageSize=${t}`)).data;function Ct(e){var t,r,n="";if(typeof e=="string"||typeof e=="number")n+=e;else if(typeof e=="object")if(Array.isArray(e)){var a=e.length;for(t=0;t<a;t++)e[t]&&(r=Ct(e[t]))&&(n&&(n+=" "),n+=r)}else for(r in e)e[r]&&(n&&(n+=" "),n+=r);return n}function Lt(){for(var e,t,r=0,n="",a=arguments.length;r<a;r++)(e=arguments[r])&&(t=Ct(e))&&(n&&(n+=" "),n+=t);return n}const Qe="-",cr=e=>{const t=dr(e),{conflictingClassGroups:r,conflictingClassGroupModifiers:n}=e;return{getClassGroupId:i=>{const s=i.split(Qe);return s[0]===""&&s.length!==1&&s.shift(),Mt(s,t)||ur(i)},getConflictingClassGroupIds:(i,s)=>{const l=r[i]||[];return s&&n[i]?[...l,...n[i]]:l}}},Mt=(e,t)=>{var i;if(e.length===0)return t.classGroupId;const r=e[0],n=t.nextPart.get(r),a=n?Mt(e.slice(1),n):void 0;if(a)return a;if(t.validators.length===0)return;const o=e.join(Qe);return(i=t.validators.find(({validator:s})=>s(o)))==null?void 0:i.classGroupId},at=/^\[(.+)\]$/,ur=e=>{if(at.test(e)){const t=at.exec(e)[1],r=t==null?void 0:t.substring(0,t.indexOf(":"));if(r)return"arbitrary.."+r}},dr=e=>{const{theme:t,prefix:r}=e,n={nextPart:new Map,validators:[]};return pr(Object.entries(e.classGroups),r).forEach(([o,i])=>{Ue(i,n,o,t)}),n},Ue=(e,t,r,n)=>{e.forEach(a=>{if(typeof a=="string"){const o=a===""?t:it(t,a);o.classGroupId=r;return}if(typeof a=="function"){if(fr(a)){Ue(a(n),t,r,n);return}t.validators.push({validator:a,classGroupId:r});return}Object.entries(a).forEach(([o,i])=>{Ue(i,it(t,o),r,n)})})},it=(e,t)=>{let r=e;return t.split(Qe).forEach(n=>{r.nextPart.has(n)||r.nextPart.set(n,{nextPart:new Map,validators:[]}),r=r.nextPart.get(n)}),r},fr=e=>e.isThemeGetter,pr=(e,t)=>t?e.map(([r,n])=>{const a=n.map(o=>typeof o=="string"?t+o:typeof o=="object"?Object.fromEntries(Object.entries(o).map(([i,s])=>[t+i,s])):o);return[r,a]}):e,mr=e=>{if(e<1)return{get:()=>{},set:()=>{}};let t=0,r=new Map,n=new Map;const a=(o,i)=>{r.set(o,i),t++,t>e&&(t=0,n=r,r=new Map)};return{get(o){let i=r.get(o);if(i!==void 0)return i;if((i=n.get(o))!==void 0)return a(o,i),i},set(o,i){r.has(o)?r.set(o,i):a(o,i)}}},Ft="!",vr=e=>{const{separator:t,experimentalParseClassName:r}=e,n=t.length===1,a=t[0],o=t.length,i=s=>{const l=[];let c=0,d=0,u;for(let p=0;p<s.length;p++){let h=s[p];if(c===0){if(h===a&&(n||s.slice(p,p+o)===t)){l.push(s.slice(d,p)),d=p+o;continue}if(h==="/"){u=p;continue}}h==="["?c++:h==="]"&&c--}const g=l.length===0?s:s.substring(d),y=g.startsWith(Ft),S=y?g.substring(1):g,b=u&&u>d?u-d:void 0;return{modifiers:l,hasImportantModifier:y,baseClassName:S,maybePostfixModifierPosition:b}};return r?s=>r({className:s,parseClassName:i}):i},gr=e=>{if(e.length<=1)return e;const t=[];let r=[];return e.forEach(n=>{n[0]==="["?(t.push(...r.sort(),n),r=[]):r.push(n)}),t.push(...r.sort()),t},hr=e=>({cache:mr(e.cacheSize),parseClassName:vr(e),...cr(e)}),br=/\s+/,yr=(e,t)=>{const{parseClassName:r,getClassGroupId:n,getConflictingClassGroupIds:a}=t,o=[],i=e.trim().split(br);let s="";for(let l=i.length-1;l>=0;l-=1){const c=i[l],{modifiers:d,hasImportantModifier:u,baseClassName:g,maybePostfixModifierPosition:y}=r(c);let S=!!y,b=n(S?g.substring(0,y):g);if(!b){if(!S){s=c+(s.length>0?" "+s:s);continue}if(b=n(g),!b){s=c+(s.length>0?" "+s:s);continue}S=!1}const p=gr(d).join(":"),h=u?p+Ft:p,k=h+b;if(o.includes(k))continue;o.push(k);const A=a(b,S);for(let L=0;L<A.length;++L){const G=A[L];o.push(h+G)}s=c+(s.length>0?" "+s:s)}return s};function wr(){let e=0,t,r,n="";for(;e<arguments.length;)(t=arguments[e++])&&(r=Pt(t))&&(n&&(n+=" "),n+=r);return n}const Pt=e=>{if(typeof e=="string")return e;let t,r="";for(let n=0;n<e.length;n++)e[n]&&(t=Pt(e[n]))&&(r&&(r+=" "),r+=t);return r};function xr(e,...t){let r,n,a,o=i;function i(l){const c=t.reduce((d,u)=>u(d),e());return r=hr(c),n=r.cache.get,a=r.cache.set,o=s,s(l)}function s(l){const c=n(l);if(c)return c;const d=yr(l,r);return a(l,d),d}return function(){return o(wr.apply(null,arguments))}}const z=e=>{const t=r=>r[e]||[];return t.isThemeGetter=!0,t},It=/^\[(?:([a-z-]+):)?(.+)\]$/i,kr=/^\d+\/\d+$/,Sr=new Set(["px","full","screen"]),Cr=/^(\d+(\.\d+)?)?(xs|sm|md|lg|xl)$/,Lr=/\d+(%|px|r?em|[sdl]?v([hwib]|min|max)|pt|pc|in|cm|mm|cap|ch|ex|r?lh|cq(w|h|i|b|min|max))|\b(calc|min|max|clamp)\(.+\)|^0$/,Mr=/^(rgba?|hsla?|hwb|(ok)?(lab|lch))\(.+\)$/,Fr=/^(inset_)?-?((\d+)?\.?(\d+)[a-z]+|0)_-?((\d+)?\.?(\d+)[a-z]+|0)/,Pr=/^(url|image|image-set|cross-fade|element|(repeating-)?(linear|radial|conic)-gradient)\(.+\)$/,ne=e=>be(e)||Sr.has(e)||kr.test(e),ce=e=>xe(e,"length",Wr),be=e=>!!e&&!Number.isNaN(Number(e)),Ze=e=>xe(e,"number",be),ke=e=>!!e&&Number.isInteger(Number(e)),Ir=e=>e.endsWith("%")&&be(e.slice(0,-1)),x=e=>It.test(e),ue=e=>Cr.test(e),Vr=new Set(["length","size","percentage"]),Ar=e=>xe(e,Vr,Vt),zr=e=>xe(e,"position",Vt),Rr=new Set(["image","url"]),Tr=e=>xe(e,Rr,Nr),Gr=e=>xe(e,"",Er),Se=()=>!0,xe=(e,t,r)=>{const n=It.exec(e);return n?n[1]?typeof t=="string"?n[1]===t:t.has(n[1]):r(n[2]):!1},Wr=e=>Lr.test(e)&&!Mr.test(e),Vt=()=>!1,Er=e=>Fr.test(e),Nr=e=>Pr.test(e),_r=()=>{const e=z("colors"),t=z("spacing"),r=z("blur"),n=z("brightness"),a=z("borderColor"),o=z("borderRadius"),i=z("borderSpacing"),s=z("borderWidth"),l=z("contrast"),c=z("grayscale"),d=z("hueRotate"),u=z("invert"),g=z("gap"),y=z("gradientColorStops"),S=z("gradientColorStopPositions"),b=z("inset"),p=z("margin"),h=z("opacity"),k=z("padding"),A=z("saturate"),L=z("scale"),G=z("sepia"),_=z("skew"),j=z("space"),C=z("translate"),se=()=>["auto","contain","none"],le=()=>["auto","hidden","clip","visible","scroll"],re=()=>["auto",x,t],f=()=>[x,t],v=()=>["",ne,ce],m=()=>["auto",be,x],W=()=>["bottom","center","left","left-bottom","left-top","right","right-bottom","right-top","top"],M=()=>["solid","dashed","dotted","double","none"],I=()=>["normal","multiply","screen","overlay","darken","lighten","color-dodge","color-burn","hard-light","soft-light","difference","exclusion","hue","saturation","color","luminosity"],R=()=>["start","end","center","between","around","evenly","stretch"],H=()=>["","0",x],J=()=>["auto","avoid","all","avoid-page","page","left","right","column"],E=()=>[be,x];return{cacheSize:500,separator:":",theme:{colors:[Se],spacing:[ne,ce],blur:["none","",ue,x],brightness:E(),borderColor:[e],borderRadius:["none","","full",ue,x],borderSpacing:f(),borderWidth:v(),contrast:E(),grayscale:H(),hueRotate:E(),invert:H(),gap:f(),gradientColorStops:[e],gradientColorStopPositions:[Ir,ce],inset:re(),margin:re(),opacity:E(),padding:f(),saturate:E(),scale:E(),sepia:H(),skew:E(),space:f(),translate:f()},classGroups:{aspect:[{aspect:["auto","square","video",x]}],container:["container"],columns:[{columns:[ue]}],"break-after":[{"break-after":J()}],"break-before":[{"break-before":J()}],"break-inside":[{"break-inside":["auto","avoid","avoid-page","avoid-column"]}],"box-decoration":[{"box-decoration":["slice","clone"]}],box:[{box:["border","content"]}],display:["block","inline-block","inline","flex","inline-flex","table","inline-table","table-caption","table-cell","table-column","table-column-group","table-footer-group","table-header-group","table-row-group","table-row","flow-root","grid","inline-grid","contents","list-item","hidden"],float:[{float:["right","left","none","start","end"]}],clear:[{clear:["left","right","both","none","start","end"]}],isolation:["isolate","isolation-auto"],"object-fit":[{object:["contain","cover","fill","none","scale-down"]}],"object-position":[{object:[...W(),x]}],overflow:[{overflow:le()}],"overflow-x":[{"overflow-x":le()}],"overflow-y":[{"overflow-y":le()}],overscroll:[{overscroll:se()}],"overscroll-x":[{"overscroll-x":se()}],"overscroll-y":[{"overscroll-y":se()}],position:["static","fixed","absolute","relative","sticky"],inset:[{inset:[b]}],"inset-x":[{"inset-x":[b]}],"inset-y":[{"inset-y":[b]}],start:[{start:[b]}],end:[{end:[b]}],top:[{top:[b]}],right:[{right:[b]}],bottom:[{bottom:[b]}],left:[{left:[b]}],visibility:["visible","invisible","collapse"],z:[{z:["auto",ke,x]}],basis:[{basis:re()}],"flex-direction":[{flex:["row","row-reverse","col","col-reverse"]}],"flex-wrap":[{flex:["wrap","wrap-reverse","nowrap"]}],flex:[{flex:["1","auto","initial","none",x]}],grow:[{grow:H()}],shrink:[{shrink:H()}],order:[{order:["first","last","none",ke,x]}],"grid-cols":[{"grid-cols":[Se]}],"col-start-end":[{col:["auto",{span:["full",ke,x]},x]}],"col-start":[{"col-start":m()}],"col-end":[{"col-end":m()}],"grid-rows":[{"grid-rows":[Se]}],"row-start-end":[{row:["auto",{span:[ke,x]},x]}],"row-start":[{"row-start":m()}],"row-end":[{"row-end":m()}],"grid-flow":[{"grid-flow":["row","col","dense","row-dense","col-dense"]}],"auto-cols":[{"auto-cols":["auto","min","max","fr",x]}],"auto-rows":[{"auto-rows":["auto","min","max","fr",x]}],gap:[{gap:[g]}],"gap-x":[{"gap-x":[g]}],"gap-y":[{"gap-y":[g]}],"justify-content":[{justify:["normal",...R()]}],"justify-items":[{"justify-items":["start","end","center","stretch"]}],"justify-self":[{"justify-self":["auto","start","end","center","stretch"]}],"align-content":[{content:["normal",...R(),"baseline"]}],"align-items":[{items:["start","end","center","baseline","stretch"]}],"align-self":[{self:["auto","start","end","center","stretch","baseline"]}],"place-content":[{"place-content":[...R(),"baseline"]}],"place-items":[{"place-items":["start","end","center","baseline","stretch"]}],"place-self":[{"place-self":["auto","start","end","center","stretch"]}],p:[{p:[k]}],px:[{px:[k]}],py:[{py:[k]}],ps:[{ps:[k]}],pe:[{pe:[k]}],pt:[{pt:[k]}],pr:[{pr:[k]}],pb:[{pb:[k]}],pl:[{pl:[k]}],m:[{m:[p]}],mx:[{mx:[p]}],my:[{my:[p]}],ms:[{ms:[p]}],me:[{me:[p]}],mt:[{mt:[p]}],mr:[{mr:[p]}],mb:[{mb:[p]}],ml:[{ml:[p]}],"space-x":[{"space-x":[j]}],"space-x-reverse":["space-x-reverse"],"space-y":[{"space-y":[j]}],"space-y-reverse":["space-y-reverse"],w:[{w:["auto","min","max","fit","svw","lvw","dvw",x,t]}],"min-w":[{"min-w":[x,t,"min","max","fit"]}],"max-w":[{"max-w":[x,t,"none","full","min","max","fit","prose",{screen:[ue]},ue]}],h:[{h:[x,t,"auto","min","max","fit","svh","lvh","dvh"]}],"min-h":[{"min-h":[x,t,"min","max","fit","svh","lvh","dvh"]}],"max-h":[{"max-h":[x,t,"min","max","fit","svh","lvh","dvh"]}],size:[{size:[x,t,"auto","min","max","fit"]}],"font-size":[{text:["base",ue,ce]}],"font-smoothing":["antialiased","subpixel-antialiased"],"font-style":["italic","not-italic"],"font-weight":[{font:["thin","extralight","light","normal","medium","semibold","bold","extrabold","black",Ze]}],"font-family":[{font:[Se]}],"fvn-normal":["normal-nums"],"fvn-ordinal":["ordinal"],"fvn-slashed-zero":["slashed-zero"],"fvn-figure":["lining-nums","oldstyle-nums"],"fvn-spacing":["proportional-nums","tabular-nums"],"fvn-fraction":["diagonal-fractions","stacked-fractions"],tracking:[{tracking:["tighter","tight","normal","wide","wider","widest",x]}],"line-clamp":[{"line-clamp":["none",be,Ze]}],leading:[{leading:["none","tight","snug","normal","relaxed","loose",ne,x]}],"list-image":[{"list-image":["none",x]}],"list-style-type":[{list:["none","disc","decimal",x]}],"list-style-position":[{list:["inside","outside"]}],"placeholder-color":[{placeholder:[e]}],"placeholder-opacity":[{"placeholder-opacity":[h]}],"text-alignment":[{text:["left","center","right","justify","start","end"]}],"text-color":[{text:[e]}],"text-opacity":[{"text-opacity":[h]}],"text-decoration":["underline","overline","line-through","no-underline"],"text-decoration-style":[{decoration:[...M(),"wavy"]}],"text-decoration-thickness":[{decoration:["auto","from-font",ne,ce]}],"underline-offset":[{"underline-offset":["auto",ne,x]}],"text-decoration-color":[{decoration:[e]}],"text-transform":["uppercase","lowercase","capitalize","normal-case"],"text-overflow":["truncate","text-ellipsis","text-clip"],"text-wrap":[{text:["wrap","nowrap","balance","pretty"]}],indent:[{indent:f()}],"vertical-align":[{align:["baseline","top","middle","bottom","text-top","text-bottom","sub","super",x]}],whitespace:[{whitespace:["normal","nowrap","pre","pre-line","pre-wrap","break-spaces"]}],break:[{break:["normal","words","all","keep"]}],hyphens:[{hyphens:["none","manual","auto"]}],content:[{content:["none",x]}],"bg-attachment":[{bg:["fixed","local","scroll"]}],"bg-clip":[{"bg-clip":["border","padding","content","text"]}],"bg-opacity":[{"bg-opacity":[h]}],"bg-origin":[{"bg-origin":["border","padding","content"]}],"bg-position":[{bg:[...W(),zr]}],"bg-repeat":[{bg:["no-repeat",{repeat:["","x","y","round","space"]}]}],"bg-size":[{bg:["auto","cover","contain",Ar]}],"bg-image":[{bg:["none",{"gradient-to":["t","tr","r","br","b","bl","l","tl"]},Tr]}],"bg-color":[{bg:[e]}],"gradient-from-pos":[{from:[S]}],"gradient-via-pos":[{via:[S]}],"gradient-to-pos":[{to:[S]}],"gradient-from":[{from:[y]}],"gradient-via":[{via:[y]}],"gradient-to":[{to:[y]}],rounded:[{rounded:[o]}],"rounded-s":[{"rounded-s":[o]}],"rounded-e":[{"rounded-e":[o]}],"rounded-t":[{"rounded-t":[o]}],"rounded-r":[{"rounded-r":[o]}],"rounded-b":[{"rounded-b":[o]}],"rounded-l":[{"rounded-l":[o]}],"rounded-ss":[{"rounded-ss":[o]}],"rounded-se":[{"rounded-se":[o]}],"rounded-ee":[{"rounded-ee":[o]}],"rounded-es":[{"rounded-es":[o]}],"rounded-tl":[{"rounded-tl":[o]}],"rounded-tr":[{"rounded-tr":[o]}],"rounded-br":[{"rounded-br":[o]}],"rounded-bl":[{"rounded-bl":[o]}],"border-w":[{border:[s]}],"border-w-x":[{"border-x":[s]}],"border-w-y":[{"border-y":[s]}],"border-w-s":[{"border-s":[s]}],"border-w-e":[{"border-e":[s]}],"border-w-t":[{"border-t":[s]}],"border-w-r":[{"border-r":[s]}],"border-w-b":[{"border-b":[s]}],"border-w-l":[{"border-l":[s]}],"border-opacity":[{"border-opacity":[h]}],"border-style":[{border:[...M(),"hidden"]}],"divide-x":[{"divide-x":[s]}],"divide-x-reverse":["divide-x-reverse"],"divide-y":[{"divide-y":[s]}],"divide-y-reverse":["divide-y-reverse"],"divide-opacity":[{"divide-opacity":[h]}],"divide-style":[{divide:M()}],"border-color":[{border:[a]}],"border-color-x":[{"border-x":[a]}],"border-color-y":[{"border-y":[a]}],"border-color-s":[{"border-s":[a]}],"border-color-e":[{"border-e":[a]}],"border-color-t":[{"border-t":[a]}],"border-color-r":[{"border-r":[a]}],"border-color-b":[{"border-b":[a]}],"border-color-l":[{"border-l":[a]}],"divide-color":[{divide:[a]}],"outline-style":[{outline:["",...M()]}],"outline-offset":[{"outline-offset":[ne,x]}],"outline-w":[{outline:[ne,ce]}],"outline-color":[{outline:[e]}],"ring-w":[{ring:v()}],"ring-w-inset":["ring-inset"],"ring-color":[{ring:[e]}],"ring-opacity":[{"ring-opacity":[h]}],"ring-offset-w":[{"ring-offset":[ne,ce]}],"ring-offset-color":[{"ring-offset":[e]}],shadow:[{shadow:["","inner","none",ue,Gr]}],"shadow-color":[{shadow:[Se]}],opacity:[{opacity:[h]}],"mix-blend":[{"mix-blend":[...I(),"plus-lighter","plus-darker"]}],"bg-blend":[{"bg-blend":I()}],filter:[{filter:["","none"]}],blur:[{blur:[r]}],brightness:[{brightness:[n]}],contrast:[{contrast:[l]}],"drop-shadow":[{"drop-shadow":["","none",ue,x]}],grayscale:[{grayscale:[c]}],"hue-rotate":[{"hue-rotate":[d]}],invert:[{invert:[u]}],saturate:[{saturate:[A]}],sepia:[{sepia:[G]}],"backdrop-filter":[{"backdrop-filter":["","none"]}],"backdrop-blur":[{"backdrop-blur":[r]}],"backdrop-brightness":[{"backdrop-brightness":[n]}],"backdrop-contrast":[{"backdrop-contrast":[l]}],"backdrop-grayscale":[{"backdrop-grayscale":[c]}],"backdrop-hue-rotate":[{"backdrop-hue-rotate":[d]}],"backdrop-invert":[{"backdrop-invert":[u]}],"backdrop-opacity":[{"backdrop-opacity":[h]}],"backdrop-saturate":[{"backdrop-saturate":[A]}],"backdrop-sepia":[{"backdrop-sepia":[G]}],"border-collapse":[{border:["collapse","separate"]}],"border-spacing":[{"border-spacing":[i]}],"border-spacing-x":[{"border-spacing-x":[i]}],"border-spacing-y":[{"border-spacing-y":[i]}],"table-layout":[{table:["auto","fixed"]}],caption:[{caption:["top","bottom"]}],transition:[{transition:["none","all","","colors","opacity","shadow","transform",x]}],duration:[{duration:E()}],ease:[{ease:["linear","in","out","in-out",x]}],delay:[{delay:E()}],animate:[{animate:["none","spin","ping","pulse","bounce",x]}],transform:[{transform:["","gpu","none"]}],scale:[{scale:[L]}],"scale-x":[{"scale-x":[L]}],"scale-y":[{"scale-y":[L]}],rotate:[{rotate:[ke,x]}],"translate-x":[{"translate-x":[C]}],"translate-y":[{"translate-y":[C]}],"skew-x":[{"skew-x":[_]}],"skew-y":[{"skew-y":[_]}],"transform-origin":[{origin:["center","top","top-right","right","bottom-right","bottom","bottom-left","left","top-left",x]}],accent:[{accent:["auto",e]}],appearance:[{appearance:["none","auto"]}],cursor:[{cursor:["auto","default","pointer","wait","text","move","help","not-allowed","none","context-menu","progress","cell","crosshair","vertical-text","alias","copy","no-drop","grab","grabbing","all-scroll","col-resize","row-resize","n-resize","e-resize","s-resize","w-resize","ne-resize","nw-resize","se-resize","sw-resize","ew-resize","ns-resize","nesw-resize","nwse-resize","zoom-in","zoom-out",x]}],"caret-color":[{caret:[e]}],"pointer-events":[{"pointer-events":["none","auto"]}],resize:[{resize:["none","y","x",""]}],"scroll-behavior":[{scroll:["auto","smooth"]}],"scroll-m":[{"scroll-m":f()}],"scroll-mx":[{"scroll-mx":f()}],"scroll-my":[{"scroll-my":f()}],"scroll-ms":[{"scroll-ms":f()}],"scroll-me":[{"scroll-me":f()}],"scroll-mt":[{"scroll-mt":f()}],"scroll-mr":[{"scroll-mr":f()}],"scroll-mb":[{"scroll-mb":f()}],"scroll-ml":[{"scroll-ml":f()}],"scroll-p":[{"scroll-p":f()}],"scroll-px":[{"scroll-px":f()}],"scroll-py":[{"scroll-py":f()}],"scroll-ps":[{"scroll-ps":f()}],"scroll-pe":[{"scroll-pe":f()}],"scroll-pt":[{"scroll-pt":f()}],"scroll-pr":[{"scroll-pr":f()}],"scroll-pb":[{"scroll-pb":f()}],"scroll-pl":[{"scroll-pl":f()}],"snap-align":[{snap:["start","end","center","align-none"]}],"snap-stop":[{snap:["normal","always"]}],"snap-type":[{snap:["none","x","y","both"]}],"snap-strictness":[{snap:["mandatory","proximity"]}],touch:[{touch:["auto","none","manipulation"]}],"touch-x":[{"touch-pan":["x","left","right"]}],"touch-y":[{"touch-pan":["y","up","down"]}],"touch-pz":["touch-pinch-zoom"],select:[{select:["none","text","all","auto"]}],"will-change":[{"will-change":["auto","scroll","contents","transform",x]}],fill:[{fill:[e,"none"]}],"stroke-w":[{stroke:[ne,ce,Ze]}],stroke:[{stroke:[e,"none"]}],sr:["sr-only","not-sr-only"],"forced-color-adjust":[{"forced-color-adjust":["auto","none"]}]},conflictingClassGroups:{overflow:["overflow-x","overflow-y"],overscroll:["overscroll-x","overscroll-y"],inset:["inset-x","inset-y","start","end","top","right","bottom","left"],"inset-x":["right","left"],"inset-y":["top","bottom"],flex:["basis","grow","shrink"],gap:["gap-x","gap-y"],p:["px","py","ps","pe","pt","pr","pb","pl"],px:["pr","pl"],py:["pt","pb"],m:["mx","my","ms","me","mt","mr","mb","ml"],mx:["mr","ml"],my:["mt","mb"],size:["w","h"],"font-size":["leading"],"fvn-normal":["fvn-ordinal","fvn-slashed-zero","fvn-figure","fvn-spacing","fvn-fraction"],"fvn-ordinal":["fvn-normal"],"fvn-slashed-zero":["fvn-normal"],"fvn-figure":["fvn-normal"],"fvn-spacing":["fvn-normal"],"fvn-fraction":["fvn-normal"],"line-clamp":["display","overflow"],rounded:["rounded-s","rounded-e","rounded-t","rounded-r","rounded-b","rounded-l","rounded-ss","rounded-se","rounded-ee","rounded-es","rounded-tl","rounded-tr","rounded-br","rounded-bl"],"rounded-s":["rounded-ss","rounded-es"],"rounded-e":["rounded-se","rounded-ee"],"rounded-t":["rounded-tl","rounded-tr"],"rounded-r":["rounded-tr","rounded-br"],"rounded-b":["rounded-br","rounded-bl"],"rounded-l":["rounded-tl","rounded-bl"],"border-spacing":["border-spacing-x","border-spacing-y"],"border-w":["border-w-s","border-w-e","border-w-t","border-w-r","border-w-b","border-w-l"],"border-w-x":["border-w-r","border-w-l"],"border-w-y":["border-w-t","border-w-b"],"border-color":["border-color-s","border-color-e","border-color-t","border-color-r","border-color-b","border-color-l"],"border-color-x":["border-color-r","border-color-l"],"border-color-y":["border-color-t","border-color-b"],"scroll-m":["scroll-mx","scroll-my","scroll-ms","scroll-me","scroll-mt","scroll-mr","scroll-mb","scroll-ml"],"scroll-mx":["scroll-mr","scroll-ml"],"scroll-my":["scroll-mt","scroll-mb"],"scroll-p":["scroll-px","scroll-py","scroll-ps","scroll-pe","scroll-pt","scroll-pr","scroll-pb","scroll-pl"],"scroll-px":["scroll-pr","scroll-pl"],"scroll-py":["scroll-pt","scroll-pb"],touch:["touch-x","touch-y","touch-pz"],"touch-x":["touch"],"touch-y":["touch"],"touch-pz":["touch"]},conflictingClassGroupModifiers:{"font-size":["leading"]}}},jr=xr(_r);function qe(...e){return jr(Lt(e))}function Zr(e){if(e===0)return"0 Bytes";const t=1024,r=["Bytes","KB","MB","GB","TB"],n=Math.floor(Math.log(e)/Math.log(t));return parseFloat((e/Math.pow(t,n)).toFixed(2))+" "+r[n]}function Dr(e){return Zr(e)+"/s"}function Or(e){if(e<60)return`${Math.round(e)}s`;if(e<3600){const t=Math.floor(e/60),r=Math.round(e%60);return`${t}m ${r}s`}else{const t=Math.floor(e/3600),r=Math.floor(e%3600/60);return`${t}h ${r}m`}}function me(){return Date.now().toString(36)+Math.random().toString(36).substr(2)}async function At(e){try{if(navigator.clipboard&&window.isSecureContext)return await navigator.clipboard.writeText(e),!0;{const t=document.createElement("textarea");t.value=e,t.style.position="fixed",t.style.left="-999999px",t.style.top="-999999px",document.body.appendChild(t),t.focus(),t.select();const r=document.execCommand("copy");return t.remove(),r}}catch(t){return console.error("Failed to copy text: ",t),!1}}var Je=function(){return Je=Object.assign||function(t){for(var r,n=1,a=arguments.length;n<a;n++){r=arguments[n];for(var o in r)Object.prototype.hasOwnProperty.call(r,o)&&(t[o]=r[o])}return t},Je.apply(this,arguments)};function ee(e,t,r,n){function a(o){return o instanceof r?o:new r(function(i){i(o)})}return new(r||(r=Promise))(function(o,i){function s(d){try{c(n.next(d))}catch(u){i(u)}}function l(d){try{c(n.throw(d))}catch(u){i(u)}}function c(d){d.done?o(d.value):a(d.value).then(s,l)}c((n=n.apply(e,t||[])).next())})}function te(e,t){var r={label:0,sent:function(){if(o[0]&1)throw o[1];return o[1]},trys:[],ops:[]},n,a,o,i=Object.create((typeof Iterator=="function"?Iterator:Object).prototype);return i.next=s(0),i.throw=s(1),i.return=s(2),typeof Symbol=="function"&&(i[Symbol.iterator]=function(){return this}),i;function s(c){return function(d){return l([c,d])}}function l(c){if(n)throw new TypeError("Generator is already executing.");for(;i&&(i=0,c[0]&&(r=0)),r;)try{if(n=1,a&&(o=c[0]&2?a.return:c[0]?a.throw||((o=a.return)&&o.call(a),0):a.next)&&!(o=o.call(a,c[1])).done)return o;switch(a=0,o&&(c=[c[0]&2,o.value]),c[0]){case 0:case 1:o=c;break;case 4:return r.label++,{value:c[1],done:!1};case 5:r.label++,a=c[1],c=[0];continue;case 7:c=r.ops.pop(),r.trys.pop();continue;default:if(o=r.trys,!(o=o.length>0&&o[o.length-1])&&(c[0]===6||c[0]===2)){r=0;continue}if(c[0]===3&&(!o||c[1]>o[0]&&c[1]<o[3])){r.label=c[1];break}if(c[0]===6&&r.label<o[1]){r.label=o[1],o=c;break}if(o&&r.label<o[2]){r.label=o[2],r.ops.push(c);break}o[2]&&r.ops.pop(),r.trys.pop();continue}c=t.call(e,r)}catch(d){c=[6,d],a=0}finally{n=o=0}if(c[0]&5)throw c[1];return{value:c[0]?c[1]:void 0,done:!0}}}function zt(e,t,r){if(r||arguments.length===2)for(var n=0,a=t.length,o;n<a;n++)(o||!(n in t))&&(o||(o=Array.prototype.slice.call(t,0,n)),o[n]=t[n]);return e.concat(o||Array.prototype.slice.call(t))}var Rt="4.6.2";function Ne(e,t){return new Promise(function(r){return setTimeout(r,e,t)})}function Hr(){return new Promise(function(e){var t=new MessageChannel;t.port1.onmessage=function(){return e()},t.port2.postMessage(null)})}function Br(e,t){t===void 0&&(t=1/0);var r=window.requestIdleCallback;return r?new Promise(function(n){return r.call(window,function(){return n()},{timeout:t})}):Ne(Math.min(e,t))}function Tt(e){return!!e&&typeof e.then=="function"}function st(e,t){try{var r=e();Tt(r)?r.then(function(n){return t(!0,n)},function(n){return t(!1,n)}):t(!0,r)}catch(n){t(!1,n)}}function lt(e,t,r){return r===void 0&&(r=16),ee(this,void 0,void 0,function(){var n,a,o,i;return te(this,function(s){switch(s.label){case 0:n=Array(e.length),a=Date.now(),o=0,s.label=1;case 1:return o<e.length?(n[o]=t(e[o],o),i=Date.now(),i>=a+r?(a=i,[4,Hr()]):[3,3]):[3,4];case 2:s.sent(),s.label=3;case 3:return++o,[3,1];case 4:return[2,n]}})})}function Le(e){return e.then(void 0,function(){}),e}function Xr(e,t){for(var r=0,n=e.length;r<n;++r)if(e[r]===t)return!0;return!1}function Yr(e,t){return!Xr(e,t)}function et(e){return parseInt(e)}function $(e){return parseFloat(e)}function oe(e,t){return typeof e=="number"&&isNaN(e)?t:e}function O(e){return e.reduce(function(t,r){return t+(r?1:0)},0)}function Gt(e,t){if(t===void 0&&(t=1),Math.abs(t)>=1)return Math.round(e/t)*t;var r=1/t;return Math.round(e*r)/r}function Ur(e){for(var t,r,n="Unexpected syntax '".concat(e,"'"),a=/^\s*([a-z-]*)(.*)$/i.exec(e),o=a[1]||void 0,i={},s=/([.:#][\w-]+|\[.+?\])/gi,l=function(g,y){i[g]=i[g]||[],i[g].push(y)};;){var c=s.exec(a[2]);if(!c)break;var d=c[0];switch(d[0]){case".":l("class",d.slice(1));break;case"#":l("id",d.slice(1));break;case"[":{var u=/^\[([\w-]+)([~|^$*]?=("(.*?)"|([\w-]+)))?(\s+[is])?\]$/.exec(d);if(u)l(u[1],(r=(t=u[4])!==null&&t!==void 0?t:u[5])!==null&&r!==void 0?r:"");else throw new Error(n);break}default:throw new Error(n)}}return[o,i]}function Jr(e){for(var t=new Uint8Array(e.length),r=0;r<e.length;r++){var n=e.charCodeAt(r);if(n>127)return new TextEncoder().encode(e);t[r]=n}return t}function de(e,t){var r=e[0]>>>16,n=e[0]&65535,a=e[1]>>>16,o=e[1]&65535,i=t[0]>>>16,s=t[0]&65535,l=t[1]>>>16,c=t[1]&65535,d=0,u=0,g=0,y=0;y+=o+c,g+=y>>>16,y&=65535,g+=a+l,u+=g>>>16,g&=65535,u+=n+s,d+=u>>>16,u&=65535,d+=r+i,d&=65535,e[0]=d<<16|u,e[1]=g<<16|y}function U(e,t){var r=e[0]>>>16,n=e[0]&65535,a=e[1]>>>16,o=e[1]&65535,i=t[0]>>>16,s=t[0]&65535,l=t[1]>>>16,c=t[1]&65535,d=0,u=0,g=0,y=0;y+=o*c,g+=y>>>16,y&=65535,g+=a*c,u+=g>>>16,g&=65535,g+=o*l,u+=g>>>16,g&=65535,u+=n*c,d+=u>>>16,u&=65535,u+=a*l,d+=u>>>16,u&=65535,u+=o*s,d+=u>>>16,u&=65535,d+=r*c+n*l+a*s+o*i,d&=65535,e[0]=d<<16|u,e[1]=g<<16|y}function ve(e,t){var r=e[0];t%=64,t===32?(e[0]=e[1],e[1]=r):t<32?(e[0]=r<<t|e[1]>>>32-t,e[1]=e[1]<<t|r>>>32-t):(t-=32,e[0]=e[1]<<t|r>>>32-t,e[1]=r<<t|e[1]>>>32-t)}function B(e,t){t%=64,t!==0&&(t<32?(e[0]=e[1]>>>32-t,e[1]=e[1]<<t):(e[0]=e[1]<<t-32,e[1]=0))}function T(e,t){e[0]^=t[0],e[1]^=t[1]}var $r=[4283543511,3981806797],Kr=[3301882366,444984403];function ct(e){var t=[0,e[0]>>>1];T(e,t),U(e,$r),t[1]=e[0]>>>1,T(e,t),U(e,Kr),t[1]=e[0]>>>1,T(e,t)}var Re=[2277735313,289559509],Te=[1291169091,658871167],ut=[0,5],Qr=[0,1390208809],qr=[0,944331445];function en(e,t){var r=Jr(e);t=t||0;var n=[0,r.length],a=n[1]%16,o=n[1]-a,i=[0,t],s=[0,t],l=[0,0],c=[0,0],d;for(d=0;d<o;d=d+16)l[0]=r[d+4]|r[d+5]<<8|r[d+6]<<16|r[d+7]<<24,l[1]=r[d]|r[d+1]<<8|r[d+2]<<16|r[d+3]<<24,c[0]=r[d+12]|r[d+13]<<8|r[d+14]<<16|r[d+15]<<24,c[1]=r[d+8]|r[d+9]<<8|r[d+10]<<16|r[d+11]<<24,U(l,Re),ve(l,31),U(l,Te),T(i,l),ve(i,27),de(i,s),U(i,ut),de(i,Qr),U(c,Te),ve(c,33),U(c,Re),T(s,c),ve(s,31),de(s,i),U(s,ut),de(s,qr);l[0]=0,l[1]=0,c[0]=0,c[1]=0;var u=[0,0];switch(a){case 15:u[1]=r[d+14],B(u,48),T(c,u);case 14:u[1]=r[d+13],B(u,40),T(c,u);case 13:u[1]=r[d+12],B(u,32),T(c,u);case 12:u[1]=r[d+11],B(u,24),T(c,u);case 11:u[1]=r[d+10],B(u,16),T(c,u);case 10:u[1]=r[d+9],B(u,8),T(c,u);case 9:u[1]=r[d+8],T(c,u),U(c,Te),ve(c,33),U(c,Re),T(s,c);case 8:u[1]=r[d+7],B(u,56),T(l,u);case 7:u[1]=r[d+6],B(u,48),T(l,u);case 6:u[1]=r[d+5],B(u,40),T(l,u);case 5:u[1]=r[d+4],B(u,32),T(l,u);case 4:u[1]=r[d+3],B(u,24),T(l,u);case 3:u[1]=r[d+2],B(u,16),T(l,u);case 2:u[1]=r[d+1],B(u,8),T(l,u);case 1:u[1]=r[d],T(l,u),U(l,Re),ve(l,31),U(l,Te),T(i,l)}return T(i,n),T(s,n),de(i,s),de(s,i),ct(i),ct(s),de(i,s),de(s,i),("00000000"+(i[0]>>>0).toString(16)).slice(-8)+("00000000"+(i[1]>>>0).toString(16)).slice(-8)+("00000000"+(s[0]>>>0).toString(16)).slice(-8)+("00000000"+(s[1]>>>0).toString(16)).slice(-8)}function tn(e){var t;return Je({name:e.name,message:e.message,stack:(t=e.stack)===null||t===void 0?void 0:t.split(`
 `)},e)}function rn(e){return/^function\s.*?\{\s*\[native code]\s*}$/.test(String(e))}function nn(e){return typeof e!="function"}function on(e,t){var r=Le(new Promise(function(n){var a=Date.now();st(e.bind(null,t),function(){for(var o=[],i=0;i<arguments.length;i++)o[i]=arguments[i];var s=Date.now()-a;if(!o[0])return n(function(){return{error:o[1],duration:s}});var l=o[1];if(nn(l))return n(function(){return{value:l,duration:s}});n(function(){return new Promise(function(c){var d=Date.now();st(l,function(){for(var u=[],g=0;g<arguments.length;g++)u[g]=arguments[g];var y=s+Date.now()-d;if(!u[0])return c({error:u[1],duration:y});c({value:u[1],duration:y})})})})})}));return function(){return r.then(function(a){return a()})}}function an(e,t,r,n){var a=Object.keys(e).filter(function(i){return Yr(r,i)}),o=Le(lt(a,function(i){return on(e[i],t)},n));return function(){return ee(this,void 0,void 0,function(){var s,l,c,d,u;return te(this,function(g){switch(g.label){case 0:return[4,o];case 1:return s=g.sent(),[4,lt(s,function(y){return Le(y())},n)];case 2:return l=g.sent(),[4,Promise.all(l)];case 3:for(c=g.sent(),d={},u=0;u<a.length;++u)d[a[u]]=c[u];return[2,d]}})})}}function Wt(){var e=window,t=navigator;return O(["MSCSSMatrix"in e,"msSetImmediate"in e,"msIndexedDB"in e,"msMaxTouchPoints"in t,"msPointerEnabled"in t])>=4}function sn(){var e=window,t=navigator;return O(["msWriteProfilerMark"in e,"MSStream"in e,"msLaunchUri"in t,"msSaveBlob"in t])>=3&&!Wt()}function Fe(){var e=window,t=navigator;return O(["webkitPersistentStorage"in t,"webkitTemporaryStorage"in t,(t.vendor||"").indexOf("Google")===0,"webkitResolveLocalFileSystemURL"in e,"BatteryManager"in e,"webkitMediaStream"in e,"webkitSpeechGrammar"in e])>=5}function Q(){var e=window,t=navigator;return O(["ApplePayError"in e,"CSSPrimitiveValue"in e,"Counter"in e,t.vendor.indexOf("Apple")===0,"RGBColor"in e,"WebKitMediaKeys"in e])>=4}function tt(){var e=window,t=e.HTMLElement,r=e.Document;return O(["safari"in e,!("ongestureend"in e),!("TouchEvent"in e),!("orientation"in e),t&&!("autocapitalize"in t.prototype),r&&"pointerLockElement"in r.prototype])>=4}function Pe(){var e=window;return rn(e.print)&&String(e.browser)==="[object WebPageNamespace]"}function Et(){var e,t,r=window;return O(["buildID"in navigator,"MozAppearance"in((t=(e=document.documentElement)===null||e===void 0?void 0:e.style)!==null&&t!==void 0?t:{}),"onmozfullscreenchange"in r,"mozInnerScreenX"in r,"CSSMozDocumentRule"in r,"CanvasCaptureMediaStream"in r])>=4}function ln(){var e=window;return O([!("MediaSettingsRange"in e),"RTCEncodedAudioFrame"in e,""+e.Intl=="[object Intl]",""+e.Reflect=="[object Reflect]"])>=3}function cn(){var e=window,t=e.URLPattern;return O(["union"in Set.prototype,"Iterator"in e,t&&"hasRegExpGroups"in t.prototype,"RGB8"in WebGLRenderingContext.prototype])>=3}function un(){var e=window;return O(["DOMRectList"in e,"RTCPeerConnectionIceEvent"in e,"SVGGeometryElement"in e,"ontransitioncancel"in e])>=3}function Ie(){var e=window,t=navigator,r=e.CSS,n=e.HTMLButtonElement;return O([!("getStorageUpdates"in t),n&&"popover"in n.prototype,"CSSCounterStyleRule"in e,r.supports("font-size-adjust: ex-height 0.5"),r.supports("text-transform: full-width")])>=4}function dn(){if(navigator.platform==="iPad")return!0;var e=screen,t=e.width/e.height;return O(["MediaSource"in window,!!Element.prototype.webkitRequestFullscreen,t>.65&&t<1.53])>=2}function fn(){var e=document;return e.fullscreenElement||e.msFullscreenElement||e.mozFullScreenElement||e.webkitFullscreenElement||null}function pn(){var e=document;return(e.exitFullscreen||e.msExitFullscreen||e.mozCancelFullScreen||e.webkitExitFullscreen).call(e)}function rt(){var e=Fe(),t=Et(),r=window,n=navigator,a="connection";return e?O([!("SharedWorker"in r),n[a]&&"ontypechange"in n[a],!("sinkId"in new Audio)])>=2:t?O(["onorientationchange"in r,"orientation"in r,/android/i.test(n.appVersion)])>=2:!1}function mn(){var e=navigator,t=window,r=Audio.prototype,n=t.visualViewport;return O(["srLatency"in r,"srChannelCount"in r,"devicePosture"in e,n&&"segments"in n,"getTextInformation"in Image.prototype])>=3}function vn(){return bn()?-4:gn()}function gn(){var e=window,t=e.OfflineAudioContext||e.webkitOfflineAudioContext;if(!t)return-2;if(hn())return-1;var r=4500,n=5e3,a=new t(1,n,44100),o=a.createOscillator();o.type="triangle",o.frequency.value=1e4;var i=a.createDynamicsCompressor();i.threshold.value=-50,i.knee.value=40,i.ratio.value=12,i.attack.value=0,i.release.value=.25,o.connect(i),i.connect(a.destination),o.start(0);var s=yn(a),l=s[0],c=s[1],d=Le(l.then(function(u){return wn(u.getChannelData(0).subarray(r))},function(u){if(u.name==="timeout"||u.name==="suspended")return-3;throw u}));return function(){return c(),d}}function hn(){return Q()&&!tt()&&!un()}function bn(){return Q()&&Ie()&&Pe()||Fe()&&mn()&&cn()}function yn(e){var t=3,r=500,n=500,a=5e3,o=function(){},i=new Promise(function(s,l){var c=!1,d=0,u=0;e.oncomplete=function(S){return s(S.renderedBuffer)};var g=function(){setTimeout(function(){return l(dt("timeout"))},Math.min(n,u+a-Date.now()))},y=function(){try{var S=e.startRendering();switch(Tt(S)&&Le(S),e.state){case"running":u=Date.now(),c&&g();break;case"suspended":document.hidden||d++,c&&d>=t?l(dt("suspended")):setTimeout(y,r);break}}catch(b){l(b)}};y(),o=function(){c||(c=!0,u>0&&g())}});return[i,o]}function wn(e){for(var t=0,r=0;r<e.length;++r)t+=Math.abs(e[r]);return t}function dt(e){var t=new Error(e);return t.name=e,t}function Nt(e,t,r){var n,a,o;return r===void 0&&(r=50),ee(this,void 0,void 0,function(){var i,s;return te(this,function(l){switch(l.label){case 0:i=document,l.label=1;case 1:return i.body?[3,3]:[4,Ne(r)];case 2:return l.sent(),[3,1];case 3:s=i.createElement("iframe"),l.label=4;case 4:return l.trys.push([4,,10,11]),[4,new Promise(function(c,d){var u=!1,g=function(){u=!0,c()},y=function(p){u=!0,d(p)};s.onload=g,s.onerror=y;var S=s.style;S.setProperty("display","block","important"),S.position="absolute",S.top="0",S.left="0",S.visibility="hidden",t&&"srcdoc"in s?s.srcdoc=t:s.src="about:blank",i.body.appendChild(s);var b=function(){var p,h;u||(((h=(p=s.contentWindow)===null||p===void 0?void 0:p.document)===null||h===void 0?void 0:h.readyState)==="complete"?g():setTimeout(b,10))};b()})];case 5:l.sent(),l.label=6;case 6:return!((a=(n=s.contentWindow)===null||n===void 0?void 0:n.document)===null||a===void 0)&&a.body?[3,8]:[4,Ne(r)];case 7:return l.sent(),[3,6];case 8:return[4,e(s,s.contentWindow)];case 9:return[2,l.sent()];case 10:return(o=s.parentNode)===null||o===void 0||o.removeChild(s),[7];case 11:return[2]}})})}function xn(e){for(var t=Ur(e),r=t[0],n=t[1],a=document.createElement(r??"div"),o=0,i=Object.keys(n);o<i.length;o++){var s=i[o],l=n[s].join(" ");s==="style"?kn(a.style,l):a.setAttribute(s,l)}return a}function kn(e,t){for(var r=0,n=t.split(";");r<n.length;r++){var a=n[r],o=/^\s*([\w-]+)\s*:\s*(.+?)(\s*!([\w-]+))?\s*$/.exec(a);if(o){var i=o[1],s=o[2],l=o[4];e.setProperty(i,s,l||"")}}}function Sn(){for(var e=window;;){var t=e.parent;if(!t||t===e)return!1;try{if(t.location.origin!==e.location.origin)return!0}catch(r){if(r instanceof Error&&r.name==="SecurityError")return!0;throw r}e=t}}var Cn="mmMwWLliI0O&1",Ln="48px",ge=["monospace","sans-serif","serif"],ft=["sans-serif-thin","ARNO PRO","Agency FB","Arabic Typesetting","Arial Unicode MS","AvantGarde Bk BT","BankGothic Md BT","Batang","Bitstream Vera Sans Mono","Calibri","Century","Century Gothic","Clarendon","EUROSTILE","Franklin Gothic","Futura Bk BT","Futura Md BT","GOTHAM","Gill Sans","HELV","Haettenschweiler","Helvetica Neue","Humanst521 BT","Leelawadee","Letter Gothic","Levenim MT","Lucida Bright","Lucida Sans","Menlo","MS Mincho","MS Outlook","MS Reference Specialty","MS UI Gothic","MT Extra","MYRIAD PRO","Marlett","Meiryo UI","Microsoft Uighur","Minion Pro","Monotype Corsiva","PMingLiU","Pristina","SCRIPTINA","Segoe UI Light","Serifa","SimHei","Small Fonts","Staccato222 BT","TRAJAN PRO","Univers CE 55 Medium","Vrinda","ZWAdobeF"];function Mn(){var e=this;return Nt(function(t,r){var n=r.document;return ee(e,void 0,void 0,function(){var a,o,i,s,l,c,d,u,g,y,S,b;return te(this,function(p){for(a=n.body,a.style.fontSize=Ln,o=n.createElement("div"),o.style.setProperty("visibility","hidden","important"),i={},s={},l=function(h){var k=n.createElement("span"),A=k.style;return A.position="absolute",A.top="0",A.left="0",A.fontFamily=h,k.textContent=Cn,o.appendChild(k),k},c=function(h,k){return l("'".concat(h,"',").concat(k))},d=function(){return ge.map(l)},u=function(){for(var h={},k=function(_){h[_]=ge.map(function(j){return c(_,j)})},A=0,L=ft;A<L.length;A++){var G=L[A];k(G)}return h},g=function(h){return ge.some(function(k,A){return h[A].offsetWidth!==i[k]||h[A].offsetHeight!==s[k]})},y=d(),S=u(),a.appendChild(o),b=0;b<ge.length;b++)i[ge[b]]=y[b].offsetWidth,s[ge[b]]=y[b].offsetHeight;return[2,ft.filter(function(h){return g(S[h])})]})})})}function Fn(){var e=navigator.plugins;if(e){for(var t=[],r=0;r<e.length;++r){var n=e[r];if(n){for(var a=[],o=0;o<n.length;++o){var i=n[o];a.push({type:i.type,suffixes:i.suffixes})}t.push({name:n.name,description:n.description,mimeTypes:a})}}return t}}function Pn(){return In(Wn())}function In(e){var t,r=!1,n,a,o=Vn(),i=o[0],s=o[1];return An(i,s)?(r=zn(s),e?n=a="skipped":(t=Rn(i,s),n=t[0],a=t[1])):n=a="unsupported",{winding:r,geometry:n,text:a}}function Vn(){var e=document.createElement("canvas");return e.width=1,e.height=1,[e,e.getContext("2d")]}function An(e,t){return!!(t&&e.toDataURL)}function zn(e){return e.rect(0,0,10,10),e.rect(2,2,6,6),!e.isPointInPath(5,5,"evenodd")}function Rn(e,t){Tn(e,t);var r=De(e),n=De(e);if(r!==n)return["unstable","unstable"];Gn(e,t);var a=De(e);return[a,r]}function Tn(e,t){e.width=240,e.height=60,t.textBaseline="alphabetic",t.fillStyle="#f60",t.fillRect(100,1,62,20),t.fillStyle="#069",t.font='11pt "Times New Roman"';var r="Cwm fjordbank gly ".concat("😃");t.fillText(r,2,15),t.fillStyle="rgba(102, 204, 0, 0.2)",t.font="18pt Arial",t.fillText(r,4,45)}function Gn(e,t){e.width=122,e.height=110,t.globalCompositeOperation="multiply";for(var r=0,n=[["#f2f",40,40],["#2ff",80,40],["#ff2",60,80]];r<n.length;r++){var a=n[r],o=a[0],i=a[1],s=a[2];t.fillStyle=o,t.beginPath(),t.arc(i,s,40,0,Math.PI*2,!0),t.closePath(),t.fill()}t.fillStyle="#f9c",t.arc(60,60,60,0,Math.PI*2,!0),t.arc(60,60,20,0,Math.PI*2,!0),t.fill("evenodd")}function De(e){return e.toDataURL()}function Wn(){return Q()&&Ie()&&Pe()}function En(){var e=navigator,t=0,r;e.maxTouchPoints!==void 0?t=et(e.maxTouchPoints):e.msMaxTouchPoints!==void 0&&(t=e.msMaxTouchPoints);try{document.createEvent("TouchEvent"),r=!0}catch{r=!1}var n="ontouchstart"in window;return{maxTouchPoints:t,touchEvent:r,touchStart:n}}function Nn(){return navigator.oscpu}function _n(){var e=navigator,t=[],r=e.language||e.userLanguage||e.browserLanguage||e.systemLanguage;if(r!==void 0&&t.push([r]),Array.isArray(e.languages))Fe()&&ln()||t.push(e.languages);else if(typeof e.languages=="string"){var n=e.languages;n&&t.push(n.split(","))}return t}function jn(){return window.screen.colorDepth}function Zn(){return oe($(navigator.deviceMemory),void 0)}function Dn(){if(!(Q()&&Ie()&&Pe()))return On()}function On(){var e=screen,t=function(n){return oe(et(n),null)},r=[t(e.width),t(e.height)];return r.sort().reverse(),r}var Hn=2500,Bn=10,We,Oe;function Xn(){if(Oe===void 0){var e=function(){var t=$e();Ke(t)?Oe=setTimeout(e,Hn):(We=t,Oe=void 0)};e()}}function Yn(){var e=this;return Xn(),function(){return ee(e,void 0,void 0,function(){var t;return te(this,function(r){switch(r.label){case 0:return t=$e(),Ke(t)?We?[2,zt([],We,!0)]:fn()?[4,pn()]:[3,2]:[3,2];case 1:r.sent(),t=$e(),r.label=2;case 2:return Ke(t)||(We=t),[2,t]}})})}}function Un(){var e=this;if(Q()&&Ie()&&Pe())return function(){return Promise.resolve(void 0)};var t=Yn();return function(){return ee(e,void 0,void 0,function(){var r,n;return te(this,function(a){switch(a.label){case 0:return[4,t()];case 1:return r=a.sent(),n=function(o){return o===null?null:Gt(o,Bn)},[2,[n(r[0]),n(r[1]),n(r[2]),n(r[3])]]}})})}}function $e(){var e=screen;return[oe($(e.availTop),null),oe($(e.width)-$(e.availWidth)-oe($(e.availLeft),0),null),oe($(e.height)-$(e.availHeight)-oe($(e.availTop),0),null),oe($(e.availLeft),null)]}function Ke(e){for(var t=0;t<4;++t)if(e[t])return!1;return!0}function Jn(){return oe(et(navigator.hardwareConcurrency),void 0)}function $n(){var e,t=(e=window.Intl)===null||e===void 0?void 0:e.DateTimeFormat;if(t){var r=new t().resolvedOptions().timeZone;if(r)return r}var n=-Kn();return"UTC".concat(n>=0?"+":"").concat(n)}function Kn(){var e=new Date().getFullYear();return Math.max($(new Date(e,0,1).getTimezoneOffset()),$(new Date(e,6,1).getTimezoneOffset()))}function Qn(){try{return!!window.sessionStorage}catch{return!0}}function qn(){try{return!!window.localStorage}catch{return!0}}function eo(){if(!(Wt()||sn()))try{return!!window.indexedDB}catch{return!0}}function to(){return!!window.openDatabase}function ro(){return navigator.cpuClass}function no(){var e=navigator.platform;return e==="MacIntel"&&Q()&&!tt()?dn()?"iPad":"iPhone":e}function oo(){return navigator.vendor||""}function ao(){for(var e=[],t=0,r=["chrome","safari","__crWeb","__gCrWeb","yandex","__yb","__ybro","__firefox__","__edgeTrackingPreventionStatistics","webkit","oprt","samsungAr","ucweb","UCShellJava","puffinDevice"];t<r.length;t++){var n=r[t],a=window[n];a&&typeof a=="object"&&e.push(n)}return e.sort()}function io(){var e=document;try{e.cookie="cookietest=1; SameSite=Strict;";var t=e.cookie.indexOf("cookietest=")!==-1;return e.cookie="cookietest=1; SameSite=Strict; expires=Thu, 01-Jan-1970 00:00:01 GMT",t}catch{return!1}}function so(){var e=atob;return{abpIndo:["#Iklan-Melayang","#Kolom-Iklan-728","#SidebarIklan-wrapper",'[title="ALIENBOLA" i]',e("I0JveC1CYW5uZXItYWRz")],abpvn:[".quangcao","#mobileCatfish",e("LmNsb3NlLWFkcw=="),'[id^="bn_bottom_fixed_"]',"#pmadv"],adBlockFinland:[".mainostila",e("LnNwb25zb3JpdA=="),".ylamainos",e("YVtocmVmKj0iL2NsaWNrdGhyZ2guYXNwPyJd"),e("YVtocmVmXj0iaHR0cHM6Ly9hcHAucmVhZHBlYWsuY29tL2FkcyJd")],adBlockPersian:["#navbar_notice_50",".kadr",'TABLE[width="140px"]',"#divAgahi",e("YVtocmVmXj0iaHR0cDovL2cxLnYuZndtcm0ubmV0L2FkLyJd")],adBlockWarningRemoval:["#adblock-honeypot",".adblocker-root",".wp_adblock_detect",e("LmhlYWRlci1ibG9ja2VkLWFk"),e("I2FkX2Jsb2NrZXI=")],adGuardAnnoyances:[".hs-sosyal","#cookieconsentdiv",'div[class^="app_gdpr"]',".as-oil",'[data-cypress="soft-push-notification-modal"]'],adGuardBase:[".BetterJsPopOverlay",e("I2FkXzMwMFgyNTA="),e("I2Jhbm5lcmZsb2F0MjI="),e("I2NhbXBhaWduLWJhbm5lcg=="),e("I0FkLUNvbnRlbnQ=")],adGuardChinese:[e("LlppX2FkX2FfSA=="),e("YVtocmVmKj0iLmh0aGJldDM0LmNvbSJd"),"#widget-quan",e("YVtocmVmKj0iLzg0OTkyMDIwLnh5eiJd"),e("YVtocmVmKj0iLjE5NTZobC5jb20vIl0=")],adGuardFrench:["#pavePub",e("LmFkLWRlc2t0b3AtcmVjdGFuZ2xl"),".mobile_adhesion",".widgetadv",e("LmFkc19iYW4=")],adGuardGerman:['aside[data-portal-id="leaderboard"]'],adGuardJapanese:["#kauli_yad_1",e("YVtocmVmXj0iaHR0cDovL2FkMi50cmFmZmljZ2F0ZS5uZXQvIl0="),e("Ll9wb3BJbl9pbmZpbml0ZV9hZA=="),e("LmFkZ29vZ2xl"),e("Ll9faXNib29zdFJldHVybkFk")],adGuardMobile:[e("YW1wLWF1dG8tYWRz"),e("LmFtcF9hZA=="),'amp-embed[type="24smi"]',"#mgid_iframe1",e("I2FkX2ludmlld19hcmVh")],adGuardRussian:[e("YVtocmVmXj0iaHR0cHM6Ly9hZC5sZXRtZWFkcy5jb20vIl0="),e("LnJlY2xhbWE="),'div[id^="smi2adblock"]',e("ZGl2W2lkXj0iQWRGb3hfYmFubmVyXyJd"),"#psyduckpockeball"],adGuardSocial:[e("YVtocmVmXj0iLy93d3cuc3R1bWJsZXVwb24uY29tL3N1Ym1pdD91cmw9Il0="),e("YVtocmVmXj0iLy90ZWxlZ3JhbS5tZS9zaGFyZS91cmw/Il0="),".etsy-tweet","#inlineShare",".popup-social"],adGuardSpanishPortuguese:["#barraPublicidade","#Publicidade","#publiEspecial","#queTooltip",".cnt-publi"],adGuardTrackingProtection:["#qoo-counter",e("YVtocmVmXj0iaHR0cDovL2NsaWNrLmhvdGxvZy5ydS8iXQ=="),e("YVtocmVmXj0iaHR0cDovL2hpdGNvdW50ZXIucnUvdG9wL3N0YXQucGhwIl0="),e("YVtocmVmXj0iaHR0cDovL3RvcC5tYWlsLnJ1L2p1bXAiXQ=="),"#top100counter"],adGuardTurkish:["#backkapat",e("I3Jla2xhbWk="),e("YVtocmVmXj0iaHR0cDovL2Fkc2Vydi5vbnRlay5jb20udHIvIl0="),e("YVtocmVmXj0iaHR0cDovL2l6bGVuemkuY29tL2NhbXBhaWduLyJd"),e("YVtocmVmXj0iaHR0cDovL3d3dy5pbnN0YWxsYWRzLm5ldC8iXQ==")],bulgarian:[e("dGQjZnJlZW5ldF90YWJsZV9hZHM="),"#ea_intext_div",".lapni-pop-over","#xenium_hot_offers"],easyList:[".yb-floorad",e("LndpZGdldF9wb19hZHNfd2lkZ2V0"),e("LnRyYWZmaWNqdW5reS1hZA=="),".textad_headline",e("LnNwb25zb3JlZC10ZXh0LWxpbmtz")],easyListChina:[e("LmFwcGd1aWRlLXdyYXBbb25jbGljayo9ImJjZWJvcy5jb20iXQ=="),e("LmZyb250cGFnZUFkdk0="),"#taotaole","#aafoot.top_box",".cfa_popup"],easyListCookie:[".ezmob-footer",".cc-CookieWarning","[data-cookie-number]",e("LmF3LWNvb2tpZS1iYW5uZXI="),".sygnal24-gdpr-modal-wrap"],easyListCzechSlovak:["#onlajny-stickers",e("I3Jla2xhbW5pLWJveA=="),e("LnJla2xhbWEtbWVnYWJvYXJk"),".sklik",e("W2lkXj0ic2tsaWtSZWtsYW1hIl0=")],easyListDutch:[e("I2FkdmVydGVudGll"),e("I3ZpcEFkbWFya3RCYW5uZXJCbG9jaw=="),".adstekst",e("YVtocmVmXj0iaHR0cHM6Ly94bHR1YmUubmwvY2xpY2svIl0="),"#semilo-lrectangle"],easyListGermany:["#SSpotIMPopSlider",e("LnNwb25zb3JsaW5rZ3J1ZW4="),e("I3dlcmJ1bmdza3k="),e("I3Jla2xhbWUtcmVjaHRzLW1pdHRl"),e("YVtocmVmXj0iaHR0cHM6Ly9iZDc0Mi5jb20vIl0=")],easyListItaly:[e("LmJveF9hZHZfYW5udW5jaQ=="),".sb-box-pubbliredazionale",e("YVtocmVmXj0iaHR0cDovL2FmZmlsaWF6aW9uaWFkcy5zbmFpLml0LyJd"),e("YVtocmVmXj0iaHR0cHM6Ly9hZHNlcnZlci5odG1sLml0LyJd"),e("YVtocmVmXj0iaHR0cHM6Ly9hZmZpbGlhemlvbmlhZHMuc25haS5pdC8iXQ==")],easyListLithuania:[e("LnJla2xhbW9zX3RhcnBhcw=="),e("LnJla2xhbW9zX251b3JvZG9z"),e("aW1nW2FsdD0iUmVrbGFtaW5pcyBza3lkZWxpcyJd"),e("aW1nW2FsdD0iRGVkaWt1b3RpLmx0IHNlcnZlcmlhaSJd"),e("aW1nW2FsdD0iSG9zdGluZ2FzIFNlcnZlcmlhaS5sdCJd")],estonian:[e("QVtocmVmKj0iaHR0cDovL3BheTRyZXN1bHRzMjQuZXUiXQ==")],fanboyAnnoyances:["#ac-lre-player",".navigate-to-top","#subscribe_popup",".newsletter_holder","#back-top"],fanboyAntiFacebook:[".util-bar-module-firefly-visible"],fanboyEnhancedTrackers:[".open.pushModal","#issuem-leaky-paywall-articles-zero-remaining-nag","#sovrn_container",'div[class$="-hide"][zoompage-fontsize][style="display: block;"]',".BlockNag__Card"],fanboySocial:["#FollowUs","#meteored_share","#social_follow",".article-sharer",".community__social-desc"],frellwitSwedish:[e("YVtocmVmKj0iY2FzaW5vcHJvLnNlIl1bdGFyZ2V0PSJfYmxhbmsiXQ=="),e("YVtocmVmKj0iZG9rdG9yLXNlLm9uZWxpbmsubWUiXQ=="),"article.category-samarbete",e("ZGl2LmhvbGlkQWRz"),"ul.adsmodern"],greekAdBlock:[e("QVtocmVmKj0iYWRtYW4ub3RlbmV0LmdyL2NsaWNrPyJd"),e("QVtocmVmKj0iaHR0cDovL2F4aWFiYW5uZXJzLmV4b2R1cy5nci8iXQ=="),e("QVtocmVmKj0iaHR0cDovL2ludGVyYWN0aXZlLmZvcnRobmV0LmdyL2NsaWNrPyJd"),"DIV.agores300","TABLE.advright"],hungarian:["#cemp_doboz",".optimonk-iframe-container",e("LmFkX19tYWlu"),e("W2NsYXNzKj0iR29vZ2xlQWRzIl0="),"#hirdetesek_box"],iDontCareAboutCookies:['.alert-info[data-block-track*="CookieNotice"]',".ModuleTemplateCookieIndicator",".o--cookies--container","#cookies-policy-sticky","#stickyCookieBar"],icelandicAbp:[e("QVtocmVmXj0iL2ZyYW1ld29yay9yZXNvdXJjZXMvZm9ybXMvYWRzLmFzcHgiXQ==")],latvian:[e("YVtocmVmPSJodHRwOi8vd3d3LnNhbGlkemluaS5sdi8iXVtzdHlsZT0iZGlzcGxheTogYmxvY2s7IHdpZHRoOiAxMjBweDsgaGVpZ2h0OiA0MHB4OyBvdmVyZmxvdzogaGlkZGVuOyBwb3NpdGlvbjogcmVsYXRpdmU7Il0="),e("YVtocmVmPSJodHRwOi8vd3d3LnNhbGlkemluaS5sdi8iXVtzdHlsZT0iZGlzcGxheTogYmxvY2s7IHdpZHRoOiA4OHB4OyBoZWlnaHQ6IDMxcHg7IG92ZXJmbG93OiBoaWRkZW47IHBvc2l0aW9uOiByZWxhdGl2ZTsiXQ==")],listKr:[e("YVtocmVmKj0iLy9hZC5wbGFuYnBsdXMuY28ua3IvIl0="),e("I2xpdmVyZUFkV3JhcHBlcg=="),e("YVtocmVmKj0iLy9hZHYuaW1hZHJlcC5jby5rci8iXQ=="),e("aW5zLmZhc3R2aWV3LWFk"),".revenue_unit_item.dable"],listeAr:[e("LmdlbWluaUxCMUFk"),".right-and-left-sponsers",e("YVtocmVmKj0iLmFmbGFtLmluZm8iXQ=="),e("YVtocmVmKj0iYm9vcmFxLm9yZyJd"),e("YVtocmVmKj0iZHViaXp6bGUuY29tL2FyLz91dG1fc291cmNlPSJd")],listeFr:[e("YVtocmVmXj0iaHR0cDovL3Byb21vLnZhZG9yLmNvbS8iXQ=="),e("I2FkY29udGFpbmVyX3JlY2hlcmNoZQ=="),e("YVtocmVmKj0id2Vib3JhbWEuZnIvZmNnaS1iaW4vIl0="),".site-pub-interstitiel",'div[id^="crt-"][data-criteo-id]'],officialPolish:["#ceneo-placeholder-ceneo-12",e("W2hyZWZePSJodHRwczovL2FmZi5zZW5kaHViLnBsLyJd"),e("YVtocmVmXj0iaHR0cDovL2Fkdm1hbmFnZXIudGVjaGZ1bi5wbC9yZWRpcmVjdC8iXQ=="),e("YVtocmVmXj0iaHR0cDovL3d3dy50cml6ZXIucGwvP3V0bV9zb3VyY2UiXQ=="),e("ZGl2I3NrYXBpZWNfYWQ=")],ro:[e("YVtocmVmXj0iLy9hZmZ0cmsuYWx0ZXgucm8vQ291bnRlci9DbGljayJd"),e("YVtocmVmXj0iaHR0cHM6Ly9ibGFja2ZyaWRheXNhbGVzLnJvL3Ryay9zaG9wLyJd"),e("YVtocmVmXj0iaHR0cHM6Ly9ldmVudC4ycGVyZm9ybWFudC5jb20vZXZlbnRzL2NsaWNrIl0="),e("YVtocmVmXj0iaHR0cHM6Ly9sLnByb2ZpdHNoYXJlLnJvLyJd"),'a[href^="/url/"]'],ruAd:[e("YVtocmVmKj0iLy9mZWJyYXJlLnJ1LyJd"),e("YVtocmVmKj0iLy91dGltZy5ydS8iXQ=="),e("YVtocmVmKj0iOi8vY2hpa2lkaWtpLnJ1Il0="),"#pgeldiz",".yandex-rtb-block"],thaiAds:["a[href*=macau-uta-popup]",e("I2Fkcy1nb29nbGUtbWlkZGxlX3JlY3RhbmdsZS1ncm91cA=="),e("LmFkczMwMHM="),".bumq",".img-kosana"],webAnnoyancesUltralist:["#mod-social-share-2","#social-tools",e("LmN0cGwtZnVsbGJhbm5lcg=="),".zergnet-recommend",".yt.btn-link.btn-md.btn"]}}function lo(e){var t=e===void 0?{}:e,r=t.debug;return ee(this,void 0,void 0,function(){var n,a,o,i,s,l;return te(this,function(c){switch(c.label){case 0:return co()?(n=so(),a=Object.keys(n),o=(l=[]).concat.apply(l,a.map(function(d){return n[d]})),[4,uo(o)]):[2,void 0];case 1:return i=c.sent(),r&&fo(n,i),s=a.filter(function(d){var u=n[d],g=O(u.map(function(y){return i[y]}));return g>u.length*.6}),s.sort(),[2,s]}})})}function co(){return Q()||rt()}function uo(e){var t;return ee(this,void 0,void 0,function(){var r,n,a,o,l,i,s,l;return te(this,function(c){switch(c.label){case 0:for(r=document,n=r.createElement("div"),a=new Array(e.length),o={},pt(n),l=0;l<e.length;++l)i=xn(e[l]),i.tagName==="DIALOG"&&i.show(),s=r.createElement("div"),pt(s),s.appendChild(i),n.appendChild(s),a[l]=i;c.label=1;case 1:return r.body?[3,3]:[4,Ne(50)];case 2:return c.sent(),[3,1];case 3:r.body.appendChild(n);try{for(l=0;l<e.length;++l)a[l].offsetParent||(o[e[l]]=!0)}finally{(t=n.parentNode)===null||t===void 0||t.removeChild(n)}return[2,o]}})})}function pt(e){e.style.setProperty("visibility","hidden","important"),e.style.setProperty("display","block","important")}function fo(e,t){for(var r="DOM blockers debug:\n```",n=0,a=Object.keys(e);n<a.length;n++){var o=a[n];r+=`
 `.concat(o,":");for(var i=0,s=e[o];i<s.length;i++){var l=s[i];r+=`
   `.concat(t[l]?"🚫":"➡️"," ").concat(l)}}console.log("".concat(r,"\n```"))}function po(){for(var e=0,t=["rec2020","p3","srgb"];e<t.length;e++){var r=t[e];if(matchMedia("(color-gamut: ".concat(r,")")).matches)return r}}function mo(){if(mt("inverted"))return!0;if(mt("none"))return!1}function mt(e){return matchMedia("(inverted-colors: ".concat(e,")")).matches}function vo(){if(vt("active"))return!0;if(vt("none"))return!1}function vt(e){return matchMedia("(forced-colors: ".concat(e,")")).matches}var go=100;function ho(){if(matchMedia("(min-monochrome: 0)").matches){for(var e=0;e<=go;++e)if(matchMedia("(max-monochrome: ".concat(e,")")).matches)return e;throw new Error("Too high value")}}function bo(){if(he("no-preference"))return 0;if(he("high")||he("more"))return 1;if(he("low")||he("less"))return-1;if(he("forced"))return 10}function he(e){return matchMedia("(prefers-contrast: ".concat(e,")")).matches}function yo(){if(gt("reduce"))return!0;if(gt("no-preference"))return!1}function gt(e){return matchMedia("(prefers-reduced-motion: ".concat(e,")")).matches}function wo(){if(ht("reduce"))return!0;if(ht("no-preference"))return!1}function ht(e){return matchMedia("(prefers-reduced-transparency: ".concat(e,")")).matches}function xo(){if(bt("high"))return!0;if(bt("standard"))return!1}function bt(e){return matchMedia("(dynamic-range: ".concat(e,")")).matches}var F=Math,Z=function(){return 0};function ko(){var e=F.acos||Z,t=F.acosh||Z,r=F.asin||Z,n=F.asinh||Z,a=F.atanh||Z,o=F.atan||Z,i=F.sin||Z,s=F.sinh||Z,l=F.cos||Z,c=F.cosh||Z,d=F.tan||Z,u=F.tanh||Z,g=F.exp||Z,y=F.expm1||Z,S=F.log1p||Z,b=function(C){return F.pow(F.PI,C)},p=function(C){return F.log(C+F.sqrt(C*C-1))},h=function(C){return F.log(C+F.sqrt(C*C+1))},k=function(C){return F.log((1+C)/(1-C))/2},A=function(C){return F.exp(C)-1/F.exp(C)/2},L=function(C){return(F.exp(C)+1/F.exp(C))/2},G=function(C){return F.exp(C)-1},_=function(C){return(F.exp(2*C)-1)/(F.exp(2*C)+1)},j=function(C){return F.log(1+C)};return{acos:e(.12312423423423424),acosh:t(1e308),acoshPf:p(1e154),asin:r(.12312423423423424),asinh:n(1),asinhPf:h(1),atanh:a(.5),atanhPf:k(.5),atan:o(.5),sin:i(-1e300),sinh:s(1),sinhPf:A(1),cos:l(10.000000000123),cosh:c(1),coshPf:L(1),tan:d(-1e300),tanh:u(1),tanhPf:_(1),exp:g(1),expm1:y(1),expm1Pf:G(1),log1p:S(10),log1pPf:j(10),powPI:b(-100)}}var So="mmMwWLliI0fiflO&1",He={default:[],apple:[{font:"-apple-system-body"}],serif:[{fontFamily:"serif"}],sans:[{fontFamily:"sans-serif"}],mono:[{fontFamily:"monospace"}],min:[{fontSize:"1px"}],system:[{fontFamily:"system-ui"}]};function Co(){return Lo(function(e,t){for(var r={},n={},a=0,o=Object.keys(He);a<o.length;a++){var i=o[a],s=He[i],l=s[0],c=l===void 0?{}:l,d=s[1],u=d===void 0?So:d,g=e.createElement("span");g.textContent=u,g.style.whiteSpace="nowrap";for(var y=0,S=Object.keys(c);y<S.length;y++){var b=S[y],p=c[b];p!==void 0&&(g.style[b]=p)}r[i]=g,t.append(e.createElement("br"),g)}for(var h=0,k=Object.keys(He);h<k.length;h++){var i=k[h];n[i]=r[i].getBoundingClientRect().width}return n})}function Lo(e,t){return t===void 0&&(t=4e3),Nt(function(r,n){var a=n.document,o=a.body,i=o.style;i.width="".concat(t,"px"),i.webkitTextSizeAdjust=i.textSizeAdjust="none",Fe()?o.style.zoom="".concat(1/n.devicePixelRatio):Q()&&(o.style.zoom="reset");var s=a.createElement("div");return s.textContent=zt([],Array(t/20<<0),!0).map(function(){return"word"}).join(" "),o.appendChild(s),e(a,o)},'<!doctype html><html><head><meta name="viewport" content="width=device-width, initial-scale=1">')}function Mo(){return navigator.pdfViewerEnabled}function Fo(){var e=new Float32Array(1),t=new Uint8Array(e.buffer);return e[0]=1/0,e[0]=e[0]-e[0],t[3]}function Po(){var e=window.ApplePaySession;if(typeof(e==null?void 0:e.canMakePayments)!="function")return-1;if(Io())return-3;try{return e.canMakePayments()?1:0}catch(t){return Vo(t)}}var Io=Sn;function Vo(e){if(e instanceof Error&&e.name==="InvalidAccessError"&&/\bfrom\b.*\binsecure\b/i.test(e.message))return-2;throw e}function Ao(){var e,t=document.createElement("a"),r=(e=t.attributionSourceId)!==null&&e!==void 0?e:t.attributionsourceid;return r===void 0?void 0:String(r)}var _t=-1,jt=-2,zo=new Set([10752,2849,2884,2885,2886,2928,2929,2930,2931,2932,2960,2961,2962,2963,2964,2965,2966,2967,2968,2978,3024,3042,3088,3089,3106,3107,32773,32777,32777,32823,32824,32936,32937,32938,32939,32968,32969,32970,32971,3317,33170,3333,3379,3386,33901,33902,34016,34024,34076,3408,3410,3411,3412,3413,3414,3415,34467,34816,34817,34818,34819,34877,34921,34930,35660,35661,35724,35738,35739,36003,36004,36005,36347,36348,36349,37440,37441,37443,7936,7937,7938]),Ro=new Set([34047,35723,36063,34852,34853,34854,34229,36392,36795,38449]),To=["FRAGMENT_SHADER","VERTEX_SHADER"],Go=["LOW_FLOAT","MEDIUM_FLOAT","HIGH_FLOAT","LOW_INT","MEDIUM_INT","HIGH_INT"],Zt="WEBGL_debug_renderer_info",Wo="WEBGL_polygon_mode";function Eo(e){var t,r,n,a,o,i,s=e.cache,l=Dt(s);if(!l)return _t;if(!Ht(l))return jt;var c=Ot()?null:l.getExtension(Zt);return{version:((t=l.getParameter(l.VERSION))===null||t===void 0?void 0:t.toString())||"",vendor:((r=l.getParameter(l.VENDOR))===null||r===void 0?void 0:r.toString())||"",vendorUnmasked:c?(n=l.getParameter(c.UNMASKED_VENDOR_WEBGL))===null||n===void 0?void 0:n.toString():"",renderer:((a=l.getParameter(l.RENDERER))===null||a===void 0?void 0:a.toString())||"",rendererUnmasked:c?(o=l.getParameter(c.UNMASKED_RENDERER_WEBGL))===null||o===void 0?void 0:o.toString():"",shadingLanguageVersion:((i=l.getParameter(l.SHADING_LANGUAGE_VERSION))===null||i===void 0?void 0:i.toString())||""}}function No(e){var t=e.cache,r=Dt(t);if(!r)return _t;if(!Ht(r))return jt;var n=r.getSupportedExtensions(),a=r.getContextAttributes(),o=[],i=[],s=[],l=[],c=[];if(a)for(var d=0,u=Object.keys(a);d<u.length;d++){var g=u[d];i.push("".concat(g,"=").concat(a[g]))}for(var y=yt(r),S=0,b=y;S<b.length;S++){var p=b[S],h=r[p];s.push("".concat(p,"=").concat(h).concat(zo.has(h)?"=".concat(r.getParameter(h)):""))}if(n)for(var k=0,A=n;k<A.length;k++){var L=A[k];if(!(L===Zt&&Ot()||L===Wo&&Zo())){var G=r.getExtension(L);if(!G){o.push(L);continue}for(var _=0,j=yt(G);_<j.length;_++){var p=j[_],h=G[p];l.push("".concat(p,"=").concat(h).concat(Ro.has(h)?"=".concat(r.getParameter(h)):""))}}}for(var C=0,se=To;C<se.length;C++)for(var le=se[C],re=0,f=Go;re<f.length;re++){var v=f[re],m=_o(r,le,v);c.push("".concat(le,".").concat(v,"=").concat(m.join(",")))}return l.sort(),s.sort(),{contextAttributes:i,parameters:s,shaderPrecisions:c,extensions:n,extensionParameters:l,unsupportedExtensions:o}}function Dt(e){if(e.webgl)return e.webgl.context;var t=document.createElement("canvas"),r;t.addEventListener("webglCreateContextError",function(){return r=void 0});for(var n=0,a=["webgl","experimental-webgl"];n<a.length;n++){var o=a[n];try{r=t.getContext(o)}catch{}if(r)break}return e.webgl={context:r},r}function _o(e,t,r){var n=e.getShaderPrecisionFormat(e[t],e[r]);return n?[n.rangeMin,n.rangeMax,n.precision]:[]}function yt(e){var t=Object.keys(e.__proto__);return t.filter(jo)}function jo(e){return typeof e=="string"&&!e.match(/[^A-Z0-9_x]/)}function Ot(){return Et()}function Zo(){return Fe()||Q()}function Ht(e){return typeof e.getParameter=="function"}function Do(){var e=rt()||Q();if(!e)return-2;if(!window.AudioContext)return-1;var t=new AudioContext().baseLatency;return t==null?-1:isFinite(t)?t:-3}function Oo(){if(!window.Intl)return-1;var e=window.Intl.DateTimeFormat;if(!e)return-2;var t=e().resolvedOptions().locale;return!t&&t!==""?-3:t}var Ho={fonts:Mn,domBlockers:lo,fontPreferences:Co,audio:vn,screenFrame:Un,canvas:Pn,osCpu:Nn,languages:_n,colorDepth:jn,deviceMemory:Zn,screenResolution:Dn,hardwareConcurrency:Jn,timezone:$n,sessionStorage:Qn,localStorage:qn,indexedDB:eo,openDatabase:to,cpuClass:ro,platform:no,plugins:Fn,touchSupport:En,vendor:oo,vendorFlavors:ao,cookiesEnabled:io,colorGamut:po,invertedColors:mo,forcedColors:vo,monochrome:ho,contrast:bo,reducedMotion:yo,reducedTransparency:wo,hdr:xo,math:ko,pdfViewerEnabled:Mo,architecture:Fo,applePay:Po,privateClickMeasurement:Ao,audioBaseLatency:Do,dateTimeLocale:Oo,webGlBasics:Eo,webGlExtensions:No};function Bo(e){return an(Ho,e,[])}var Xo="$ if upgrade to Pro: https://fpjs.dev/pro";function Yo(e){var t=Uo(e),r=Jo(t);return{score:t,comment:Xo.replace(/\$/g,"".concat(r))}}function Uo(e){if(rt())return .4;if(Q())return tt()&&!(Ie()&&Pe())?.5:.3;var t="value"in e.platform?e.platform.value:"";return/^Win/.test(t)?.6:/^Mac/.test(t)?.5:.7}function Jo(e){return Gt(.99+.01*e,1e-4)}function $o(e){for(var t="",r=0,n=Object.keys(e).sort();r<n.length;r++){var a=n[r],o=e[a],i="error"in o?"error":JSON.stringify(o.value);t+="".concat(t?"|":"").concat(a.replace(/([:|\\])/g,"\\$1"),":").concat(i)}return t}function Bt(e){return JSON.stringify(e,function(t,r){return r instanceof Error?tn(r):r},2)}function Xt(e){return en($o(e))}function Ko(e){var t,r=Yo(e);return{get visitorId(){return t===void 0&&(t=Xt(this.components)),t},set visitorId(n){t=n},confidence:r,components:e,version:Rt}}function Qo(e){return e===void 0&&(e=50),Br(e,e*2)}function qo(e,t){var r=Date.now();return{get:function(n){return ee(this,void 0,void 0,function(){var a,o,i;return te(this,function(s){switch(s.label){case 0:return a=Date.now(),[4,e()];case 1:return o=s.sent(),i=Ko(o),(t||n!=null&&n.debug)&&console.log("Copy the text below to get the debug data:\n\n```\nversion: ".concat(i.version,`
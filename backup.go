@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"csz.net/tgstate/conf"
+	"csz.net/tgstate/control"
+)
+
+// runBackup 处理 backup 子命令：
+//
+//	tgstate backup [-o file] [-upload]
+//
+// 未指定 -o 时保存到 backupDir 并按 backupKeep 清理旧备份
+func runBackup(args []string) int {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	output := fs.String("o", "", "Backup file path")
+	upload := fs.Bool("upload", conf.BackupUpload, "Upload the backup to the channel")
+	fs.Parse(args)
+
+	conf.BackupUpload = *upload
+	if conf.BackupUpload && (conf.BotToken == "" || conf.ChannelName == "") {
+		fmt.Fprintln(os.Stderr, "上传备份需要先设置Bot Token和对象")
+		return 1
+	}
+	path, fileId, err := control.RunBackup(*output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "备份失败:", err)
+		return 1
+	}
+	fmt.Println(path)
+	if fileId != "" {
+		fmt.Println("file_id:", fileId)
+	}
+	return 0
+}
+
+// runRestore 处理 restore 子命令：
+//
+//	tgstate restore <file>           从本地备份恢复
+//	tgstate restore -file-id <id>    从频道中的备份恢复
+//
+// 恢复前请先停止服务，当前数据库会另存为 *.before-restore-时间
+func runRestore(args []string) int {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	fileId := fs.String("file-id", "", "Restore from a backup uploaded to the channel")
+	fs.Parse(args)
+
+	src := fs.Arg(0)
+	if *fileId != "" {
+		if conf.BotToken == "" {
+			fmt.Fprintln(os.Stderr, "从频道恢复需要先设置Bot Token")
+			return 1
+		}
+		tmp, err := os.CreateTemp("", "tgstate-restore-*.db")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		if err := control.DownloadBackup(*fileId, tmp.Name()); err != nil {
+			fmt.Fprintln(os.Stderr, "下载备份失败:", err)
+			return 1
+		}
+		src = tmp.Name()
+	}
+	if src == "" {
+		fmt.Fprintln(os.Stderr, "用法: restore <file> 或 restore -file-id <id>")
+		return 2
+	}
+
+	previous, err := control.RestoreDB(src)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "恢复失败:", err)
+		return 1
+	}
+	if previous != "" {
+		fmt.Println("原数据库已另存为", previous)
+	}
+	// 备份可能来自旧版本，恢复后补齐迁移
+	conn, err := control.InitDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "迁移失败:", err)
+		return 1
+	}
+	conn.Close()
+	fmt.Println("恢复完成")
+	return 0
+}
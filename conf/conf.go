@@ -8,6 +8,46 @@ var Mode string
 var BaseUrl string
 var AllowedExts string
 var ProxyUrl string
+var Compress string
+var CacheDir string
+var CacheMaxSize int64
+var VariantUpload bool
+var StripMetadata bool
+var Convert string
+var ConvertQuality int
+var UploadAsPhoto bool
+var FFmpeg string
+var DSN string
+var DBPath string
+var DBBusyTimeout int
+var NameLookup bool
+var BackupDir string
+var BackupInterval string
+var BackupKeep int
+var BackupUpload bool
+var AnonymousUpload bool
+var Registration bool
+var Admins string
+var DefaultRole string
+var QuotaDailyBytes int64
+var QuotaDailyFiles int64
+var QuotaTotalBytes int64
+var TrustedProxies string
+var ClientIPHeader string
+var RateApi string
+var RateChunk string
+var RateDownload string
+var RateShort string
+var RateAuth string
+var OIDCIssuer string
+var OIDCClientId string
+var OIDCClientSecret string
+var OIDCRedirectUrl string
+var OIDCScopes string
+var OIDCUsernameClaim string
+var OIDCGroupsClaim string
+var OIDCAdminGroup string
+var TelegramLogin bool
 
 type UploadResponse struct {
 	Code         int    `json:"code"`
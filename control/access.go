@@ -0,0 +1,114 @@
+package control
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"csz.net/tgstate/conf"
+)
+
+// 访问密码（pass）与管理密码（apiPass）的校验。
+// 浏览器在 /pwd 输入访问密码后得到签名 Cookie，其中只有过期时间与签名，不包含密码；
+// API 客户端可以在请求头中提交密码，未通过时返回 JSON 401 而不是跳转到 /pwd
+
+const (
+	// accessCookie 访问密码通过后写入的 Cookie，值为 {过期时间戳}.{签名}
+	accessCookie = "tgstate_access"
+	// accessTTL 访问 Cookie 的有效期
+	accessTTL = 30 * 24 * time.Hour
+	// passHeader API 客户端提交访问密码的请求头，兼容 url 参数 pass
+	passHeader = "X-Access-Password"
+	// apiPassHeader 提交管理密码的请求头，兼容 url 参数 password
+	apiPassHeader = "X-Api-Password"
+)
+
+// passRequired 是否设置了访问密码
+func passRequired() bool {
+	return conf.Pass != "" && conf.Pass != "none"
+}
+
+// secretEqual 常量时间比较，先取哈希使比较时间与长度无关
+func secretEqual(a, b string) bool {
+	ha, hb := sha256.Sum256([]byte(a)), sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(ha[:], hb[:]) == 1
+}
+
+// requestSecret 读取请求头中的密码，没有时读取 url 参数
+func requestSecret(r *http.Request, header, param string) string {
+	if v := r.Header.Get(header); v != "" {
+		return v
+	}
+	return r.URL.Query().Get(param)
+}
+
+// sign 使用由 Bot Token 派生的密钥签名，purpose 区分用途，避免一种签名被挪作他用
+func sign(purpose, value string) string {
+	key := sha256.Sum256([]byte("tgstate-sign\x00" + conf.BotToken))
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write([]byte(purpose + "\x00" + value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// accessSignature 签名中包含访问密码，修改密码后已签发的 Cookie 全部失效
+func accessSignature(expires string) string {
+	return sign("access\x00"+conf.Pass, expires)
+}
+
+// setAccessCookie 访问密码通过后写入签名 Cookie
+func setAccessCookie(w http.ResponseWriter, r *http.Request) {
+	expiresAt := time.Now().Add(accessTTL)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	http.SetCookie(w, &http.Cookie{
+		Name:     accessCookie,
+		Value:    expires + "." + accessSignature(expires),
+		Path:     "/",
+		Expires:  expiresAt,
+		MaxAge:   int(accessTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   secureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	// 清除旧版本保存明文密码的 Cookie
+	http.SetCookie(w, &http.Cookie{Name: "p", Value: "", Path: "/", MaxAge: -1})
+}
+
+// validAccessCookie 校验访问 Cookie 的签名与有效期
+func validAccessCookie(r *http.Request) bool {
+	cookie, err := r.Cookie(accessCookie)
+	if err != nil {
+		return false
+	}
+	expires, signature, ok := strings.Cut(cookie.Value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(accessSignature(expires))) {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	return err == nil && time.Now().Unix() < unix
+}
+
+// accessOK 判断请求是否通过访问密码：签名 Cookie、请求头或 url 参数中的密码、有效的 API 密钥均可
+func accessOK(r *http.Request) bool {
+	if !passRequired() || validAccessCookie(r) {
+		return true
+	}
+	if pass := requestSecret(r, passHeader, "pass"); pass != "" && secretEqual(pass, conf.Pass) {
+		return true
+	}
+	key, _, valid := requestAPIKey(r)
+	return valid && key != nil
+}
+
+// apiClient 判断请求是否来自 API 客户端，这类请求未通过校验时返回 JSON 而不是跳转
+func apiClient(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, "/api") || r.Header.Get("Authorization") != "" || r.Header.Get(passHeader) != "" {
+		return true
+	}
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html")
+}
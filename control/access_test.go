@@ -0,0 +1,197 @@
+package control
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"csz.net/tgstate/conf"
+)
+
+// openTestDB 在临时目录中创建已迁移的 SQLite 数据库并替换全局 db，测试结束后恢复
+func openTestDB(t testing.TB) {
+	t.Helper()
+	oldDSN, oldDB := conf.DSN, db
+	conf.DSN = "sqlite://" + t.TempDir() + "/test.db"
+	d, err := OpenDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ApplyMigrations(d, false); err != nil {
+		t.Fatal(err)
+	}
+	db = d
+	t.Cleanup(func() {
+		d.Close()
+		conf.DSN, db = oldDSN, oldDB
+	})
+}
+
+// setAccessConf 设置访问密码与签名用的 Bot Token，测试结束后恢复
+func setAccessConf(t *testing.T, pass string) {
+	oldPass, oldToken := conf.Pass, conf.BotToken
+	conf.Pass, conf.BotToken = pass, "123456:test-token"
+	t.Cleanup(func() { conf.Pass, conf.BotToken = oldPass, oldToken })
+}
+
+// accessCookieFor 使用 /pwd 提交密码，返回写入的访问 Cookie
+func accessCookieFor(t *testing.T, pass string) *http.Cookie {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/pwd", strings.NewReader(url.Values{"p": {pass}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	Pwd(w, r)
+	for _, c := range w.Result().Cookies() {
+		if c.Name == accessCookie && c.Value != "" {
+			return c
+		}
+	}
+	return nil
+}
+
+// testAPIKey 创建账号与 API 密钥，返回完整密钥
+func testAPIKey(t *testing.T) string {
+	t.Helper()
+	user, err := CreateUser("keyowner", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := apiKeyPrefix + randomToken()
+	if _, err := CreateAPIKey(APIKey{UserId: user.Id, Label: "test", Prefix: token[:8], Scopes: []string{scopeUpload}}, hashToken(token)); err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAccessMiddleware(t *testing.T) {
+	openTestDB(t)
+	setAccessConf(t, "secret")
+	cookie := accessCookieFor(t, "secret")
+	if cookie == nil {
+		t.Fatal("correct password did not set the access cookie")
+	}
+	if accessCookieFor(t, "wrong") != nil {
+		t.Fatal("wrong password set the access cookie")
+	}
+	if strings.Contains(cookie.Value, "secret") {
+		t.Fatalf("access cookie contains the password: %q", cookie.Value)
+	}
+	expired := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	apiKey := testAPIKey(t)
+
+	handler := Middleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	tests := []struct {
+		name   string
+		target string
+		header map[string]string
+		cookie *http.Cookie
+		want   int
+	}{
+		{"browser without credentials", "/", nil, nil, http.StatusSeeOther},
+		{"api without credentials", "/api", nil, nil, http.StatusUnauthorized},
+		{"json client without credentials", "/", map[string]string{"Accept": "application/json"}, nil, http.StatusUnauthorized},
+		{"browser accept header", "/", map[string]string{"Accept": "text/html,application/json"}, nil, http.StatusSeeOther},
+		{"cookie", "/", nil, cookie, http.StatusOK},
+		{"tampered cookie", "/", nil, &http.Cookie{Name: accessCookie, Value: cookie.Value + "0"}, http.StatusSeeOther},
+		{"expired cookie", "/", nil, &http.Cookie{Name: accessCookie, Value: expired + "." + accessSignature(expired)}, http.StatusSeeOther},
+		{"legacy plaintext cookie", "/", nil, &http.Cookie{Name: "p", Value: "secret"}, http.StatusSeeOther},
+		{"header", "/", map[string]string{passHeader: "secret"}, nil, http.StatusOK},
+		{"wrong header", "/", map[string]string{passHeader: "wrong"}, nil, http.StatusUnauthorized},
+		{"query", "/?pass=secret", nil, nil, http.StatusOK},
+		{"wrong query", "/?pass=wrong", nil, nil, http.StatusSeeOther},
+		{"api key", "/api", map[string]string{"Authorization": "Bearer " + apiKey}, nil, http.StatusOK},
+		{"unknown api key", "/", map[string]string{"Authorization": "Bearer " + apiKeyPrefix + "unknown"}, nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		for key, value := range tt.header {
+			r.Header.Set(key, value)
+		}
+		if tt.cookie != nil {
+			r.AddCookie(tt.cookie)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+			continue
+		}
+		switch tt.want {
+		case http.StatusSeeOther:
+			if loc := w.Header().Get("Location"); loc != "/pwd" {
+				t.Errorf("%s: redirected to %q, want /pwd", tt.name, loc)
+			}
+		case http.StatusUnauthorized:
+			var body conf.ResponseResult
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil || body.Code == 0 {
+				t.Errorf("%s: body is not a JSON error: %v", tt.name, err)
+			}
+			if w.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("%s: missing WWW-Authenticate", tt.name)
+			}
+		}
+	}
+}
+
+func TestAccessCookieInvalidatedByPasswordChange(t *testing.T) {
+	setAccessConf(t, "secret")
+	cookie := accessCookieFor(t, "secret")
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(cookie)
+	if !accessOK(r) {
+		t.Fatal("cookie rejected before the password changed")
+	}
+	conf.Pass = "changed"
+	if accessOK(r) {
+		t.Fatal("cookie accepted after the password changed")
+	}
+}
+
+func TestAccessWithoutPassword(t *testing.T) {
+	for _, pass := range []string{"", "none"} {
+		setAccessConf(t, pass)
+		w := httptest.NewRecorder()
+		Middleware(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})(w, httptest.NewRequest(http.MethodGet, "/api", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("pass %q: status = %d, want 200", pass, w.Code)
+		}
+	}
+}
+
+func TestRequestAPIKeyResolvedOnce(t *testing.T) {
+	openTestDB(t)
+	token := testAPIKey(t)
+	r := httptest.NewRequest(http.MethodPost, "/api", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	r = withAPIKey(r)
+
+	key, user, ok := requestAPIKey(r)
+	if !ok || key == nil || user == nil {
+		t.Fatalf("requestAPIKey = %v, %v, %v", key, user, ok)
+	}
+	// 查询结果缓存在请求上下文中，之后删除密钥也不会再次查询
+	if _, err := db.Exec("DELETE FROM api_keys"); err != nil {
+		t.Fatal(err)
+	}
+	if again, _, ok := requestAPIKey(r); !ok || again != key {
+		t.Errorf("second requestAPIKey = %v, %v, want the cached key", again, ok)
+	}
+	if withAPIKey(r) != r {
+		t.Error("withAPIKey replaced an existing cache")
+	}
+	// 没有缓存的请求每次都查询
+	plain := httptest.NewRequest(http.MethodPost, "/api", nil)
+	plain.Header.Set("Authorization", "Bearer "+token)
+	if _, _, ok := requestAPIKey(plain); ok {
+		t.Error("deleted key accepted without the request cache")
+	}
+}
@@ -0,0 +1,309 @@
+package control
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"csz.net/tgstate/conf"
+)
+
+// AdminAPI 管理接口，所有请求先经过 requireAdmin：
+//
+//	GET    /api/admin/stats                   统计
+//	GET    /api/admin/files                   搜索所有文件 ?q=&userId=&ip=&fingerprint=&shared=&page=&pageSize=
+//	DELETE /api/admin/files/{fileId}          删除文件记录与短链
+//	POST   /api/admin/files/{fileId}/unshare  从广场撤下
+//	GET    /api/admin/users                   搜索账号 ?q=&page=&pageSize=
+//	PUT    /api/admin/users/{id}/role         修改角色 {"role":"viewer"}
+//	GET    /api/admin/users/{id}/quota        查看账号的配额与用量
+//	PUT    /api/admin/users/{id}/quota        单独设置配额 {"dailyBytes":0,"dailyFiles":0,"totalBytes":0}，0 使用默认配额，-1 不限制
+//	GET    /api/admin/bans                    列出封禁
+//	POST   /api/admin/bans                    封禁 {"kind":"ip|fingerprint|user","value":"...","reason":"..."}
+//	DELETE /api/admin/bans/{id}               解除封禁
+func AdminAPI(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/"), "/"), "/")
+	switch {
+	case parts[0] == "stats" && len(parts) == 1 && r.Method == http.MethodGet:
+		adminStats(w)
+	case parts[0] == "files" && len(parts) == 1 && r.Method == http.MethodGet:
+		adminSearchFiles(w, r)
+	case parts[0] == "files" && len(parts) == 2 && r.Method == http.MethodDelete:
+		adminDeleteFile(w, parts[1])
+	case parts[0] == "files" && len(parts) == 3 && parts[2] == "unshare" && r.Method == http.MethodPost:
+		adminUnshareFile(w, parts[1])
+	case parts[0] == "users" && len(parts) == 1 && r.Method == http.MethodGet:
+		adminSearchUsers(w, r)
+	case parts[0] == "users" && len(parts) == 3 && parts[2] == "role" && r.Method == http.MethodPut:
+		adminSetRole(w, r, parts[1])
+	case parts[0] == "users" && len(parts) == 3 && parts[2] == "quota" && r.Method == http.MethodGet:
+		adminUserQuota(w, parts[1])
+	case parts[0] == "users" && len(parts) == 3 && parts[2] == "quota" && r.Method == http.MethodPut:
+		adminSetQuota(w, r, parts[1])
+	case parts[0] == "bans" && len(parts) == 1 && r.Method == http.MethodGet:
+		adminListBans(w)
+	case parts[0] == "bans" && len(parts) == 1 && r.Method == http.MethodPost:
+		adminCreateBan(w, r, admin)
+	case parts[0] == "bans" && len(parts) == 2 && r.Method == http.MethodDelete:
+		adminDeleteBan(w, parts[1])
+	default:
+		jsonError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// writeData 返回成功的 JSON 响应
+func writeData(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(conf.ResponseResult{Code: 0, Message: "ok", Data: data})
+}
+
+// pageParams 读取分页参数，pageSize 最大 100
+func pageParams(r *http.Request) (page, pageSize int) {
+	page, pageSize = 1, 20
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if ps, err := strconv.Atoi(r.URL.Query().Get("pageSize")); err == nil && ps > 0 && ps <= 100 {
+		pageSize = ps
+	}
+	return page, pageSize
+}
+
+func pageData(key string, items interface{}, page, pageSize, total int) map[string]interface{} {
+	return map[string]interface{}{
+		key: items,
+		"pagination": map[string]interface{}{
+			"page":     page,
+			"pageSize": pageSize,
+			"total":    total,
+			"hasMore":  page*pageSize < total,
+		},
+	}
+}
+
+func adminStats(w http.ResponseWriter) {
+	stats, err := GetStats()
+	if err != nil {
+		log.Printf("统计失败: %v", err)
+		jsonError(w, http.StatusInternalServerError, "Failed to get stats")
+		return
+	}
+	writeData(w, stats)
+}
+
+func adminSearchFiles(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := FileFilter{Query: q.Get("q"), Ip: q.Get("ip"), Fingerprint: q.Get("fingerprint")}
+	if userId := q.Get("userId"); userId != "" {
+		id, err := strconv.ParseInt(userId, 10, 64)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid userId")
+			return
+		}
+		filter.UserId = id
+	}
+	if shared := q.Get("shared"); shared != "" {
+		b, err := strconv.ParseBool(shared)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid shared")
+			return
+		}
+		filter.Shared = &b
+	}
+	page, pageSize := pageParams(r)
+	records, total, err := SearchFiles(filter, page, pageSize)
+	if err != nil {
+		log.Printf("搜索文件失败: %v", err)
+		jsonError(w, http.StatusInternalServerError, "Failed to search files")
+		return
+	}
+	writeData(w, pageData("files", records, page, pageSize, total))
+}
+
+func adminDeleteFile(w http.ResponseWriter, fileId string) {
+	n, err := DeleteFile(fileId)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, "Failed to delete file")
+		return
+	}
+	if n == 0 {
+		jsonError(w, http.StatusNotFound, "File not found")
+		return
+	}
+	writeData(w, map[string]int64{"deleted": n})
+}
+
+func adminUnshareFile(w http.ResponseWriter, fileId string) {
+	found, err := SetFileShared(fileId, false)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, "Failed to update file")
+		return
+	}
+	if !found {
+		jsonError(w, http.StatusNotFound, "File not found")
+		return
+	}
+	writeData(w, nil)
+}
+
+func adminSearchUsers(w http.ResponseWriter, r *http.Request) {
+	page, pageSize := pageParams(r)
+	users, total, err := SearchUsers(r.URL.Query().Get("q"), page, pageSize)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, "Failed to search users")
+		return
+	}
+	// 返回实际生效的角色
+	for i := range users {
+		users[i].Role = users[i].role()
+	}
+	writeData(w, pageData("users", users, page, pageSize, total))
+}
+
+func adminSetRole(w http.ResponseWriter, r *http.Request, userId string) {
+	id, err := strconv.ParseInt(userId, 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid user id")
+		return
+	}
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	// 空字符串表示使用 defaultRole
+	if _, ok := rolePermissions[req.Role]; !ok && req.Role != "" {
+		jsonError(w, http.StatusBadRequest, "Unknown role, use admin, uploader or viewer")
+		return
+	}
+	user, err := GetUserById(id)
+	if err != nil {
+		jsonError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err := SetUserRole(user.Id, req.Role); err != nil {
+		jsonError(w, http.StatusInternalServerError, "Failed to update role")
+		return
+	}
+	user.Role = req.Role
+	user.Role = user.role()
+	writeData(w, user)
+}
+
+func adminUserQuota(w http.ResponseWriter, userId string) {
+	id, err := strconv.ParseInt(userId, 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid user id")
+		return
+	}
+	user, err := GetUserById(id)
+	if err != nil {
+		jsonError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	override, err := GetUserQuota(id)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, "Failed to get quota")
+		return
+	}
+	checks, err := ownerQuotas(UploadOwner{UserId: id}, &user, "")
+	if err != nil {
+		log.Printf("查询上传配额失败: %v", err)
+		jsonError(w, http.StatusInternalServerError, "Failed to get usage")
+		return
+	}
+	writeData(w, map[string]interface{}{"override": override, "quota": checks[0]})
+}
+
+func adminSetQuota(w http.ResponseWriter, r *http.Request, userId string) {
+	id, err := strconv.ParseInt(userId, 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid user id")
+		return
+	}
+	var quota Quota
+	if err := json.NewDecoder(r.Body).Decode(&quota); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	found, err := SetUserQuota(id, quota)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, "Failed to update quota")
+		return
+	}
+	if !found {
+		jsonError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	writeData(w, quota)
+}
+
+func adminListBans(w http.ResponseWriter) {
+	bans, err := GetBans()
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, "Failed to list bans")
+		return
+	}
+	writeData(w, bans)
+}
+
+func adminCreateBan(w http.ResponseWriter, r *http.Request, admin *User) {
+	var ban Ban
+	if err := json.NewDecoder(r.Body).Decode(&ban); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	ban.Value = strings.TrimSpace(ban.Value)
+	switch ban.Kind {
+	case banIP, banFingerprint:
+	case banUser:
+		if _, err := strconv.ParseInt(ban.Value, 10, 64); err != nil {
+			jsonError(w, http.StatusBadRequest, "User bans take the user id as value")
+			return
+		}
+	default:
+		jsonError(w, http.StatusBadRequest, "Unknown kind, use ip, fingerprint or user")
+		return
+	}
+	if ban.Value == "" {
+		jsonError(w, http.StatusBadRequest, "Missing value")
+		return
+	}
+	ban.CreatedBy = admin.id()
+	added, err := CreateBan(ban)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, "Failed to create ban")
+		return
+	}
+	if !added {
+		jsonError(w, http.StatusConflict, "Already banned")
+		return
+	}
+	writeData(w, nil)
+}
+
+func adminDeleteBan(w http.ResponseWriter, banId string) {
+	id, err := strconv.ParseInt(banId, 10, 64)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid ban id")
+		return
+	}
+	found, err := DeleteBan(id)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, "Failed to delete ban")
+		return
+	}
+	if !found {
+		jsonError(w, http.StatusNotFound, "Ban not found")
+		return
+	}
+	writeData(w, nil)
+}
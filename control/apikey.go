@@ -0,0 +1,200 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"csz.net/tgstate/conf"
+)
+
+const (
+	// apiKeyPrefix API 密钥的固定前缀，便于在日志或代码中识别
+	apiKeyPrefix = "tgs_"
+	// apiKeyTouchInterval 最后使用时间的更新间隔，避免每个请求都写数据库
+	apiKeyTouchInterval = time.Minute
+)
+
+// API 密钥的权限范围，admin 包含所有权限
+const (
+	scopeUpload = "upload"
+	scopeRead   = "read"
+	scopeAdmin  = "admin"
+)
+
+var apiKeyScopes = []string{scopeUpload, scopeRead, scopeAdmin}
+
+// HasScope 判断密钥是否有指定权限
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == scopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// bearerToken 读取 Authorization: Bearer 请求头
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// apiKeyContextKey 请求上下文中 API 密钥查询结果的键
+type apiKeyContextKey struct{}
+
+// resolvedAPIKey 一个请求的 API 密钥查询结果，访问校验、鉴权、配额与权限检查共用
+type resolvedAPIKey struct {
+	once sync.Once
+	key  *APIKey
+	user *User
+	ok   bool
+}
+
+// withAPIKey 在请求上下文中放入 API 密钥的查询结果缓存，之后的 requestAPIKey 只查询一次数据库
+func withAPIKey(r *http.Request) *http.Request {
+	if _, ok := r.Context().Value(apiKeyContextKey{}).(*resolvedAPIKey); ok {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, &resolvedAPIKey{}))
+}
+
+// ResolveAPIKey 为所有请求启用 API 密钥查询结果的缓存
+func ResolveAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, withAPIKey(r))
+	})
+}
+
+// requestAPIKey 读取请求中的 API 密钥，没有携带时返回 nil；密钥无效时 ok 为 false。
+// 请求经过 withAPIKey 时同一请求只查询一次
+func requestAPIKey(r *http.Request) (key *APIKey, user *User, ok bool) {
+	if cached, found := r.Context().Value(apiKeyContextKey{}).(*resolvedAPIKey); found {
+		cached.once.Do(func() { cached.key, cached.user, cached.ok = lookupAPIKey(r) })
+		return cached.key, cached.user, cached.ok
+	}
+	return lookupAPIKey(r)
+}
+
+// lookupAPIKey 按 Authorization 请求头查询 API 密钥及所属账号，并更新最后使用时间
+func lookupAPIKey(r *http.Request) (key *APIKey, user *User, ok bool) {
+	token := bearerToken(r)
+	if !strings.HasPrefix(token, apiKeyPrefix) {
+		return nil, nil, token == ""
+	}
+	k, err := GetAPIKeyByHash(hashToken(token))
+	if err != nil {
+		return nil, nil, false
+	}
+	u, err := GetUserById(k.UserId)
+	if err != nil {
+		return nil, nil, false
+	}
+	if now := time.Now(); k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) > apiKeyTouchInterval {
+		TouchAPIKey(k.Id, now)
+	}
+	return &k, &u, true
+}
+
+// createKeyRequest 创建密钥的请求体
+type createKeyRequest struct {
+	Label  string   `json:"label"`
+	Scopes []string `json:"scopes"`
+	Quota  Quota    `json:"quota"` // 可选，密钥单独的配额
+}
+
+// APIKeysAPI 管理当前账号的 API 密钥，只能通过登录会话访问：
+//
+//	GET    /api/keys        列出密钥
+//	POST   /api/keys        创建密钥 {"label":"xxx","scopes":["upload"],"quota":{"dailyBytes":0}}，完整密钥只在响应中出现一次
+//	DELETE /api/keys/{id}   吊销密钥
+func APIKeysAPI(w http.ResponseWriter, r *http.Request) {
+	if bearerToken(r) != "" {
+		jsonError(w, http.StatusForbidden, "API keys cannot manage API keys")
+		return
+	}
+	user, ok := authUser(w, r, "")
+	if !ok {
+		return
+	}
+	if user == nil {
+		jsonError(w, http.StatusUnauthorized, "Login required")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := GetAPIKeysByUser(user.Id)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Failed to list API keys")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(conf.ResponseResult{Code: 0, Message: "ok", Data: keys})
+	case http.MethodPost:
+		var req createKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		req.Label = strings.TrimSpace(req.Label)
+		if req.Label == "" || len(req.Label) > 100 {
+			jsonError(w, http.StatusBadRequest, "Label must be 1-100 characters")
+			return
+		}
+		if len(req.Scopes) == 0 {
+			req.Scopes = []string{scopeUpload}
+		}
+		for _, scope := range req.Scopes {
+			if !containsString(apiKeyScopes, scope) {
+				jsonError(w, http.StatusBadRequest, "Unknown scope "+scope+", use upload, read or admin")
+				return
+			}
+			if !user.can(scope) {
+				jsonError(w, http.StatusForbidden, "Your role does not allow the "+scope+" scope")
+				return
+			}
+		}
+		if req.Quota.DailyBytes < 0 || req.Quota.DailyFiles < 0 || req.Quota.TotalBytes < 0 {
+			jsonError(w, http.StatusBadRequest, "Quota values must not be negative")
+			return
+		}
+		token := apiKeyPrefix + randomToken()
+		key, err := CreateAPIKey(APIKey{UserId: user.Id, Label: req.Label, Prefix: token[:len(apiKeyPrefix)+6], Scopes: req.Scopes, Quota: req.Quota}, hashToken(token))
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Failed to create API key")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(conf.ResponseResult{Code: 0, Message: "ok", Data: map[string]interface{}{
+			"key":    token,
+			"apiKey": key,
+		}})
+	case http.MethodDelete:
+		id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/api/keys/"), 10, 64)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid key id")
+			return
+		}
+		found, err := RevokeAPIKey(user.Id, id)
+		if err != nil {
+			jsonError(w, http.StatusInternalServerError, "Failed to revoke API key")
+			return
+		}
+		if !found {
+			jsonError(w, http.StatusNotFound, "API key not found")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(conf.ResponseResult{Code: 0, Message: "ok"})
+	default:
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,338 @@
+package control
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"csz.net/tgstate/conf"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// sessionCookie 登录会话 Cookie，值为随机令牌，数据库中只保存其哈希
+	sessionCookie = "tgstate_session"
+	// sessionTTL 会话有效期
+	sessionTTL = 30 * 24 * time.Hour
+	// csrfHeader 使用会话 Cookie 的写操作需要在该请求头（或表单字段 csrfToken）中提交 CSRF 令牌
+	csrfHeader = "X-CSRF-Token"
+	// maxPasswordLength bcrypt 只使用前 72 字节
+	maxPasswordLength = 72
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,32}$`)
+
+// dummyPasswordHash 用户不存在时也做一次比较，避免通过响应时间判断用户名是否存在
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("tgstate"), bcrypt.DefaultCost)
+
+// randomToken 生成 URL 安全的随机令牌
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// secureRequest 判断请求是否通过 HTTPS 访问，决定 Cookie 是否带 Secure
+func secureRequest(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" || strings.HasPrefix(conf.BaseUrl, "https://")
+}
+
+// requestSession 读取请求中的会话，未登录时返回 false
+func requestSession(r *http.Request) (User, Session, bool) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil || cookie.Value == "" {
+		return User{}, Session{}, false
+	}
+	session, err := GetSession(hashToken(cookie.Value))
+	if err != nil {
+		return User{}, Session{}, false
+	}
+	user, err := GetUserById(session.UserId)
+	if err != nil {
+		return User{}, Session{}, false
+	}
+	return user, session, true
+}
+
+// authUser 返回当前用户，未登录时返回 nil。
+// 已登录时要求账号未被封禁且角色具备 scope 权限（为空时不检查），携带 API 密钥时还要求密钥具备该 scope；
+// 使用会话 Cookie 的写操作校验 CSRF 令牌。校验失败时写入错误响应并返回 ok 为 false
+func authUser(w http.ResponseWriter, r *http.Request, scope string) (user *User, ok bool) {
+	if bearerToken(r) != "" {
+		key, keyUser, valid := requestAPIKey(r)
+		if !valid || key == nil {
+			jsonError(w, http.StatusUnauthorized, "Invalid API key")
+			return nil, false
+		}
+		if scope != "" && !key.HasScope(scope) {
+			jsonError(w, http.StatusForbidden, "API key lacks the "+scope+" scope")
+			return nil, false
+		}
+		return keyUser, checkRole(w, keyUser, scope)
+	}
+	u, session, found := requestSession(r)
+	if !found {
+		return nil, true
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions {
+		token := r.Header.Get(csrfHeader)
+		if token == "" {
+			token = r.FormValue("csrfToken")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(session.CsrfToken)) != 1 {
+			jsonError(w, http.StatusForbidden, "Invalid CSRF token")
+			return nil, false
+		}
+	}
+	return &u, checkRole(w, &u, scope)
+}
+
+// checkRole 检查账号未被封禁且角色具备 scope 权限，未通过时返回 403
+func checkRole(w http.ResponseWriter, user *User, scope string) bool {
+	if IsBanned(banUser, strconv.FormatInt(user.Id, 10)) {
+		jsonError(w, http.StatusForbidden, "Account banned")
+		return false
+	}
+	if scope != "" && !user.can(scope) {
+		jsonError(w, http.StatusForbidden, "Your role does not allow this action")
+		return false
+	}
+	return true
+}
+
+// uploadUser 返回上传者账号；未登录且不允许匿名上传时返回 401，IP 或浏览器指纹被封禁时返回 403
+func uploadUser(w http.ResponseWriter, r *http.Request) (*User, bool) {
+	user, ok := authUser(w, r, scopeUpload)
+	if !ok {
+		return nil, false
+	}
+	if user == nil && !conf.AnonymousUpload {
+		jsonError(w, http.StatusUnauthorized, "Login required")
+		return nil, false
+	}
+	if uploadBanned(r, user, r.FormValue("userFingerprint")) {
+		jsonError(w, http.StatusForbidden, "Uploads from this client are banned")
+		return nil, false
+	}
+	return user, true
+}
+
+// id 返回账号 id，匿名时为 0
+func (u *User) id() int64 {
+	if u == nil {
+		return 0
+	}
+	return u.Id
+}
+
+// jsonError 返回带状态码的 JSON 错误
+func jsonError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(conf.ResponseResult{Code: 1, Message: msg})
+}
+
+// startSession 创建会话并写入 Cookie，返回 CSRF 令牌
+func startSession(w http.ResponseWriter, r *http.Request, user User) (string, error) {
+	token := randomToken()
+	session := Session{UserId: user.Id, CsrfToken: randomToken(), ExpiresAt: time.Now().Add(sessionTTL)}
+	if err := CreateSession(hashToken(token), session); err != nil {
+		return "", err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		MaxAge:   int(sessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   secureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return session.CsrfToken, nil
+}
+
+// credentials 注册与登录的请求体
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func readCredentials(r *http.Request) (credentials, error) {
+	var c credentials
+	if strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			return c, errors.New("Invalid request body")
+		}
+	} else {
+		c.Username, c.Password = r.FormValue("username"), r.FormValue("password")
+	}
+	c.Username = strings.TrimSpace(c.Username)
+	return c, nil
+}
+
+// writeLogin 返回当前用户（含实际生效的角色）与 CSRF 令牌
+func writeLogin(w http.ResponseWriter, user User, csrfToken string) {
+	user.Role = user.role()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(conf.ResponseResult{Code: 0, Message: "ok", Data: map[string]interface{}{
+		"user":      user,
+		"csrfToken": csrfToken,
+	}})
+}
+
+// RegisterAPI 注册账号并登录：POST /api/register {"username","password"}
+func RegisterAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+	if !conf.Registration {
+		jsonError(w, http.StatusForbidden, "Registration is disabled")
+		return
+	}
+	c, err := readCredentials(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !usernamePattern.MatchString(c.Username) {
+		jsonError(w, http.StatusBadRequest, "Username must be 3-32 letters, digits, '_', '.' or '-'")
+		return
+	}
+	if len(c.Password) < 8 || len(c.Password) > maxPasswordLength {
+		jsonError(w, http.StatusBadRequest, "Password must be 8-72 bytes")
+		return
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(c.Password), bcrypt.DefaultCost)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, "Failed to hash password")
+		return
+	}
+	user, err := CreateUser(c.Username, string(hash))
+	if errors.Is(err, errUserExists) {
+		jsonError(w, http.StatusConflict, "Username already exists")
+		return
+	} else if err != nil {
+		log.Printf("创建用户失败: %v", err)
+		jsonError(w, http.StatusInternalServerError, "Failed to create user")
+		return
+	}
+	csrfToken, err := startSession(w, r, user)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+	writeLogin(w, user, csrfToken)
+}
+
+// LoginAPI 登录：POST /api/login {"username","password"}
+func LoginAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+	c, err := readCredentials(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	user, hash, err := GetUserByName(c.Username)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("查询用户失败: %v", err)
+		}
+		hash = string(dummyPasswordHash)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(c.Password)) != nil || err != nil {
+		jsonError(w, http.StatusUnauthorized, "Invalid username or password")
+		return
+	}
+	csrfToken, err := startSession(w, r, user)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+	writeLogin(w, user, csrfToken)
+}
+
+// LogoutAPI 退出登录：POST /api/logout
+func LogoutAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := authUser(w, r, "")
+	if !ok {
+		return
+	}
+	if cookie, err := r.Cookie(sessionCookie); user != nil && err == nil {
+		if err := DeleteSession(hashToken(cookie.Value)); err != nil {
+			jsonError(w, http.StatusInternalServerError, "Failed to delete session")
+			return
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "", Path: "/", MaxAge: -1, HttpOnly: true, Secure: secureRequest(r), SameSite: http.SameSiteLaxMode})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(conf.ResponseResult{Code: 0, Message: "ok"})
+}
+
+// MeAPI 返回当前登录的用户与 CSRF 令牌：GET /api/me
+func MeAPI(w http.ResponseWriter, r *http.Request) {
+	user, session, ok := requestSession(r)
+	if !ok {
+		jsonError(w, http.StatusUnauthorized, "Not logged in")
+		return
+	}
+	writeLogin(w, user, session.CsrfToken)
+}
+
+// DeleteFileAPI 删除自己上传的文件记录：DELETE /api/files/{fileId}
+func DeleteFileAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := authUser(w, r, scopeUpload)
+	if !ok {
+		return
+	}
+	if user == nil {
+		jsonError(w, http.StatusUnauthorized, "Login required")
+		return
+	}
+	fileId := strings.TrimPrefix(r.URL.Path, "/api/files/")
+	if fileId == "" {
+		jsonError(w, http.StatusBadRequest, "Missing fileId")
+		return
+	}
+	n, err := DeleteUserFile(user.Id, fileId)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, "Failed to delete file")
+		return
+	}
+	if n == 0 {
+		jsonError(w, http.StatusNotFound, "File not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(conf.ResponseResult{Code: 0, Message: "ok"})
+}
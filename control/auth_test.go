@@ -0,0 +1,175 @@
+package control
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"csz.net/tgstate/conf"
+)
+
+// setRegistration 开关注册，测试结束后恢复
+func setRegistration(t *testing.T, enabled bool) {
+	old := conf.Registration
+	conf.Registration = enabled
+	t.Cleanup(func() { conf.Registration = old })
+}
+
+// postCredentials 以 JSON 调用注册或登录接口
+func postCredentials(handler http.HandlerFunc, path, username, password string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(credentials{Username: username, Password: password})
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// csrfTokenOf 解析登录或 /api/me 响应中的 CSRF 令牌
+func csrfTokenOf(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	var res struct {
+		Data struct {
+			CsrfToken string `json:"csrfToken"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Data.CsrfToken == "" {
+		t.Fatal("response has no CSRF token")
+	}
+	return res.Data.CsrfToken
+}
+
+// loginResult 解析登录响应，返回会话 Cookie 与 CSRF 令牌
+func loginResult(t *testing.T, w *httptest.ResponseRecorder) (*http.Cookie, string) {
+	t.Helper()
+	token := csrfTokenOf(t, w)
+	for _, c := range w.Result().Cookies() {
+		if c.Name == sessionCookie && c.Value != "" {
+			return c, token
+		}
+	}
+	t.Fatal("login did not set the session cookie")
+	return nil, ""
+}
+
+// authStatus 携带会话 Cookie 调用只做 authUser 校验的处理函数，返回状态码
+func authStatus(method string, cookie *http.Cookie, header map[string]string) (int, *User) {
+	var user *User
+	r := httptest.NewRequest(method, "/api", nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	func(w http.ResponseWriter, r *http.Request) {
+		u, ok := authUser(w, r, scopeUpload)
+		if !ok {
+			return
+		}
+		user = u
+		w.WriteHeader(http.StatusOK)
+	}(w, r)
+	return w.Code, user
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	openTestDB(t)
+	setRegistration(t, true)
+
+	for _, tt := range []struct {
+		username, password string
+		status             int
+	}{
+		{"ab", "password123", http.StatusBadRequest},
+		{"bad name", "password123", http.StatusBadRequest},
+		{"alice", "short", http.StatusBadRequest},
+		{"alice", strings.Repeat("x", maxPasswordLength+1), http.StatusBadRequest},
+	} {
+		if w := postCredentials(RegisterAPI, "/api/register", tt.username, tt.password); w.Code != tt.status {
+			t.Errorf("register %q/%d bytes: status = %d, want %d", tt.username, len(tt.password), w.Code, tt.status)
+		}
+	}
+
+	loginResult(t, postCredentials(RegisterAPI, "/api/register", "alice", "password123"))
+	if w := postCredentials(RegisterAPI, "/api/register", "alice", "password456"); w.Code != http.StatusConflict {
+		t.Errorf("duplicate register: status = %d, want 409", w.Code)
+	}
+
+	if w := postCredentials(LoginAPI, "/api/login", "alice", "wrongpassword"); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password: status = %d, want 401", w.Code)
+	}
+	if w := postCredentials(LoginAPI, "/api/login", "nobody", "password123"); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown user: status = %d, want 401", w.Code)
+	}
+	cookie, _ := loginResult(t, postCredentials(LoginAPI, "/api/login", "alice", "password123"))
+	if status, user := authStatus(http.MethodGet, cookie, nil); status != http.StatusOK || user == nil || user.Username != "alice" {
+		t.Errorf("session GET: status = %d, user %v", status, user)
+	}
+
+	setRegistration(t, false)
+	if w := postCredentials(RegisterAPI, "/api/register", "bob", "password123"); w.Code != http.StatusForbidden {
+		t.Errorf("registration disabled: status = %d, want 403", w.Code)
+	}
+}
+
+func TestSessionCSRF(t *testing.T) {
+	openTestDB(t)
+	setRegistration(t, true)
+	cookie, csrf := loginResult(t, postCredentials(RegisterAPI, "/api/register", "alice", "password123"))
+
+	if status, user := authStatus(http.MethodPost, cookie, map[string]string{csrfHeader: csrf}); status != http.StatusOK || user == nil {
+		t.Errorf("POST with CSRF token: status = %d, user %v", status, user)
+	}
+	if status, _ := authStatus(http.MethodPost, cookie, nil); status != http.StatusForbidden {
+		t.Errorf("POST without CSRF token: status = %d, want 403", status)
+	}
+	if status, _ := authStatus(http.MethodPost, cookie, map[string]string{csrfHeader: csrf + "x"}); status != http.StatusForbidden {
+		t.Errorf("POST with wrong CSRF token: status = %d, want 403", status)
+	}
+	// 没有会话时按匿名处理，不要求 CSRF 令牌
+	if status, user := authStatus(http.MethodPost, nil, nil); status != http.StatusOK || user != nil {
+		t.Errorf("anonymous POST: status = %d, user %v", status, user)
+	}
+
+	// /api/me 返回同一个令牌，供网页端读取
+	r := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	r.AddCookie(cookie)
+	if token := csrfTokenOf(t, serve(MeAPI, r)); token != csrf {
+		t.Errorf("/api/me csrfToken = %q, want %q", token, csrf)
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	openTestDB(t)
+	setRegistration(t, true)
+	cookie, csrf := loginResult(t, postCredentials(RegisterAPI, "/api/register", "alice", "password123"))
+	if _, err := db.Exec("UPDATE sessions SET expires_at = ? WHERE token_hash = ?", time.Now().Add(-time.Minute).UTC(), hashToken(cookie.Value)); err != nil {
+		t.Fatal(err)
+	}
+	if status, user := authStatus(http.MethodPost, cookie, map[string]string{csrfHeader: csrf}); status != http.StatusOK || user != nil {
+		t.Errorf("expired session: status = %d, user %v, want anonymous", status, user)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	r.AddCookie(cookie)
+	if w := serve(MeAPI, r); w.Code != http.StatusUnauthorized {
+		t.Errorf("/api/me with expired session: status = %d, want 401", w.Code)
+	}
+}
+
+// serve 调用处理函数并返回响应
+func serve(handler http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
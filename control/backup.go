@@ -0,0 +1,330 @@
+package control
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"csz.net/tgstate/conf"
+	"csz.net/tgstate/utils"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	// backupPrefix 备份文件名前缀，清理旧备份时只处理这类文件
+	backupPrefix = "tgstate-backup-"
+	// backupChunkSize 上传到频道时的分块大小，getFile 只能下载 20MB 以内的文件
+	backupChunkSize = 19 * 1024 * 1024
+)
+
+// errBackupUnsupported 只有 SQLite 支持在线备份，其他数据库请使用各自的备份工具
+var errBackupUnsupported = errors.New("backup and restore only support SQLite, use pg_dump or mysqldump instead")
+
+// BackupFileName 生成带时间的备份文件名
+func BackupFileName(t time.Time) string {
+	return backupPrefix + t.Format("20060102-150405") + ".db"
+}
+
+// Backup 使用 SQLite 在线备份 API 生成一致的快照，备份期间不影响读写
+func (d *DB) Backup(dst string) error {
+	if d.Dialect != DialectSQLite {
+		return errBackupUnsupported
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp := dst + ".tmp"
+	os.Remove(tmp)
+	if err := copySQLite(d.DB, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// copySQLite 把 src 连接中的数据库完整复制到 dst 文件
+func copySQLite(src *sql.DB, dst string) error {
+	destDB, err := sql.Open("sqlite3", dst)
+	if err != nil {
+		return err
+	}
+	defer destDB.Close()
+	return backupSQLite(destDB, src)
+}
+
+// backupSQLite 通过在线备份 API 用 src 的内容覆盖 dest
+func backupSQLite(dest, src *sql.DB) error {
+	ctx := context.Background()
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destRaw interface{}) error {
+		return srcConn.Raw(func(srcRaw interface{}) error {
+			backup, err := destRaw.(*sqlite3.SQLiteConn).Backup("main", srcRaw.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return err
+			}
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
+
+// RestoreDB 用备份文件（支持 gzip 压缩）覆盖当前配置的 SQLite 数据库，
+// 覆盖前会把现有数据库另存一份，返回另存的路径。恢复时应先停止服务
+func RestoreDB(src string) (string, error) {
+	driver, source, dialect, err := parseDSN(conf.DSN)
+	if err != nil {
+		return "", err
+	}
+	if dialect != DialectSQLite {
+		return "", errBackupUnsupported
+	}
+
+	plain, cleanup, err := decompressBackup(src)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	backupDB, err := sql.Open(driver, plain+"?mode=ro")
+	if err != nil {
+		return "", err
+	}
+	defer backupDB.Close()
+	if err := checkBackup(backupDB); err != nil {
+		return "", fmt.Errorf("invalid backup %s: %v", src, err)
+	}
+
+	file, _, _ := strings.Cut(source, "?")
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return "", err
+	}
+	target, err := sql.Open(driver, source)
+	if err != nil {
+		return "", err
+	}
+	defer target.Close()
+	previous := ""
+	if info, err := os.Stat(file); err == nil && info.Size() > 0 {
+		previous = file + ".before-restore-" + time.Now().Format("20060102-150405")
+		if err := copySQLite(target, previous); err != nil {
+			return "", fmt.Errorf("save current database: %v", err)
+		}
+	}
+	return previous, backupSQLite(target, backupDB)
+}
+
+// decompressBackup gzip 压缩的备份先解压到临时文件，未压缩时原样返回
+func decompressBackup(path string) (string, func(), error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	magic := make([]byte, 2)
+	if n, _ := io.ReadFull(f, magic); n < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return path, func() {}, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", nil, err
+	}
+	tmp, err := os.CreateTemp("", "tgstate-restore-*.db")
+	if err != nil {
+		return "", nil, err
+	}
+	tmp.Close()
+	cleanup := func() { os.Remove(tmp.Name()) }
+	if err := writeBackup(f, tmp.Name()); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmp.Name(), cleanup, nil
+}
+
+// checkBackup 检查备份文件完整且是 tgState 的数据库
+func checkBackup(conn *sql.DB) error {
+	var result string
+	if err := conn.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+	var version int
+	return conn.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+}
+
+// UploadBackup 压缩备份文件并上传到频道，超过 getFile 下载限制时分块上传，返回可用于恢复的 file_id
+func UploadBackup(path string) (string, error) {
+	data, err := gzipFile(path)
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(path) + ".gz"
+	if len(data) <= backupChunkSize {
+		fileId := utils.UpDocument(utils.TgFileData(name, bytes.NewReader(data)))
+		if fileId == "" {
+			return "", fmt.Errorf("upload %s failed", name)
+		}
+		return fileId, nil
+	}
+	var chunkIds []string
+	for offset := 0; offset < len(data); offset += backupChunkSize {
+		end := offset + backupChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		chunkId := utils.UpDocument(utils.TgFileData(fmt.Sprintf("%s.part%d", name, len(chunkIds)), bytes.NewReader(data[offset:end])))
+		if chunkId == "" {
+			return "", fmt.Errorf("upload %s chunk %d failed", name, len(chunkIds))
+		}
+		chunkIds = append(chunkIds, chunkId)
+	}
+	fileId := utils.CreateMergedFile(name, chunkIds, int64(len(data)))
+	if fileId == "" {
+		return "", fmt.Errorf("upload %s manifest failed", name)
+	}
+	return fileId, nil
+}
+
+func gzipFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.Copy(zw, f); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DownloadBackup 从频道下载备份保存到 dst
+func DownloadBackup(fileId, dst string) error {
+	body, err := utils.OpenFile(fileId)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return writeBackup(body, dst)
+}
+
+// writeBackup 把备份写入 dst，gzip 压缩的内容自动解压
+func writeBackup(r io.Reader, dst string) error {
+	br := bufio.NewReader(r)
+	var reader io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		reader = zr
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, reader); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// RunBackup 备份当前数据库，dst 为空时保存到 backupDir 并按 backupKeep 清理旧备份，
+// 开启 backupUpload 时同时上传到频道，返回备份路径与频道中的 file_id
+func RunBackup(dst string) (string, string, error) {
+	if dst == "" {
+		dst = filepath.Join(conf.BackupDir, BackupFileName(time.Now()))
+		defer pruneBackups(conf.BackupDir, conf.BackupKeep)
+	}
+	if err := db.Backup(dst); err != nil {
+		return "", "", err
+	}
+	log.Printf("数据库已备份到 %s", dst)
+	if !conf.BackupUpload {
+		return dst, "", nil
+	}
+	fileId, err := UploadBackup(dst)
+	if err != nil {
+		return dst, "", err
+	}
+	log.Printf("备份已上传到频道，恢复时使用: restore -file-id %s", fileId)
+	return dst, fileId, nil
+}
+
+// pruneBackups 只保留最新的 keep 个备份，keep 小于等于 0 时不清理
+func pruneBackups(dir string, keep int) {
+	if keep <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var names []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), backupPrefix) && strings.HasSuffix(entry.Name(), ".db") {
+			names = append(names, entry.Name())
+		}
+	}
+	// 文件名中的时间可以直接按字符串排序
+	sort.Strings(names)
+	for len(names) > keep {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			log.Printf("删除旧备份失败: %v", err)
+		}
+		names = names[1:]
+	}
+}
+
+// StartBackupScheduler 按 backupInterval 定时备份
+func StartBackupScheduler() {
+	if conf.BackupInterval == "" {
+		return
+	}
+	interval, err := time.ParseDuration(conf.BackupInterval)
+	if err != nil || interval <= 0 {
+		log.Printf("backupInterval 无效，定时备份已禁用: %s", conf.BackupInterval)
+		return
+	}
+	if db.Dialect != DialectSQLite {
+		log.Println("定时备份只支持 SQLite，已禁用")
+		return
+	}
+	go func() {
+		for range time.Tick(interval) {
+			if _, _, err := RunBackup(""); err != nil {
+				log.Printf("定时备份失败: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,147 @@
+package control
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"csz.net/tgstate/conf"
+)
+
+var (
+	trustedOnce sync.Once
+	trustedNets []*net.IPNet
+)
+
+// trustedProxies 解析 trustedProxies 参数，支持 CIDR 与单个 IP
+func trustedProxies() []*net.IPNet {
+	trustedOnce.Do(func() {
+		for _, item := range strings.Split(conf.TrustedProxies, ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			if !strings.Contains(item, "/") {
+				if ip := net.ParseIP(item); ip != nil && ip.To4() != nil {
+					item += "/32"
+				} else {
+					item += "/128"
+				}
+			}
+			_, network, err := net.ParseCIDR(item)
+			if err != nil {
+				log.Printf("忽略无效的可信代理 %s: %v", item, err)
+				continue
+			}
+			trustedNets = append(trustedNets, network)
+		}
+	})
+	return trustedNets
+}
+
+// trustedProxy 判断 IP 是否属于可信代理
+func trustedProxy(ip net.IP) bool {
+	for _, network := range trustedProxies() {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseHopIP 解析代理请求头中的一个地址，去掉引号、IPv6 的方括号与端口，无法解析时返回 nil
+func parseHopIP(value string) net.IP {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	if strings.HasPrefix(value, "[") {
+		end := strings.Index(value, "]")
+		if end < 0 {
+			return nil
+		}
+		value = value[1:end]
+	} else if strings.Count(value, ":") == 1 {
+		value, _, _ = strings.Cut(value, ":")
+	}
+	return net.ParseIP(value)
+}
+
+// forwardedFor 按顺序返回 RFC 7239 Forwarded 请求头中每一跳的 for 参数，没有 for 的跳为空字符串
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			hop := ""
+			for _, pair := range strings.Split(element, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hop = val
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// lastUntrustedHop 从右往左返回第一个不是可信代理的地址；遇到无法解析的地址（如 unknown 或混淆的标识）时停止，
+// 返回其右侧最后一个地址。没有可用地址时返回 nil
+func lastUntrustedHop(hops []string) net.IP {
+	var ip net.IP
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := parseHopIP(hops[i])
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !trustedProxy(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+// remoteIP 返回直接连接的地址（不含端口）
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	return host
+}
+
+// headerIP 从 clientIPHeader 指定的请求头中读取客户端 IP。X-Forwarded-For 与 Forwarded 是每经过一个代理追加一跳的列表，
+// 左侧的地址可以由客户端伪造，因此从右往左取第一个不可信的地址；其他请求头由代理整体覆盖，取最后一个值
+func headerIP(r *http.Request, header string) net.IP {
+	values := r.Header.Values(header)
+	if len(values) == 0 {
+		return nil
+	}
+	switch http.CanonicalHeaderKey(header) {
+	case "X-Forwarded-For":
+		return lastUntrustedHop(strings.Split(strings.Join(values, ","), ","))
+	case "Forwarded":
+		return lastUntrustedHop(forwardedFor(values))
+	}
+	return parseHopIP(values[len(values)-1])
+}
+
+// clientIP 返回请求方的 IP（不含端口），记录上传、封禁、配额与限流都使用该地址。
+// 只有直接连接的地址是可信代理时才读取 clientIPHeader 指定的请求头，其他请求头一律忽略；
+// 未设置该参数、请求头缺失或无法解析时使用直接连接的地址
+func clientIP(r *http.Request) string {
+	remote := remoteIP(r)
+	if conf.ClientIPHeader == "" {
+		return remote
+	}
+	if ip := net.ParseIP(remote); ip == nil || !trustedProxy(ip) {
+		return remote
+	}
+	if ip := headerIP(r, conf.ClientIPHeader); ip != nil {
+		return ip.String()
+	}
+	return remote
+}
@@ -0,0 +1,131 @@
+package control
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"csz.net/tgstate/conf"
+)
+
+// setClientIPConf 设置可信代理与客户端 IP 请求头，测试结束后恢复
+func setClientIPConf(t *testing.T, proxies, header string) {
+	oldProxies, oldHeader := conf.TrustedProxies, conf.ClientIPHeader
+	conf.TrustedProxies, conf.ClientIPHeader = proxies, header
+	trustedOnce, trustedNets = sync.Once{}, nil
+	t.Cleanup(func() {
+		conf.TrustedProxies, conf.ClientIPHeader = oldProxies, oldHeader
+		trustedOnce, trustedNets = sync.Once{}, nil
+	})
+}
+
+func TestParseHopIP(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"203.0.113.7", "203.0.113.7"},
+		{" 203.0.113.7 ", "203.0.113.7"},
+		{"203.0.113.7:4711", "203.0.113.7"},
+		{`"203.0.113.7:4711"`, "203.0.113.7"},
+		{"2001:db8::1", "2001:db8::1"},
+		{"[2001:db8::1]", "2001:db8::1"},
+		{`"[2001:db8::1]:4711"`, "2001:db8::1"},
+		{"[2001:db8::1", ""},
+		{"unknown", ""},
+		{"_hidden", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if ip := parseHopIP(tt.in); ip != nil {
+			got = ip.String()
+		}
+		if got != tt.want {
+			t.Errorf("parseHopIP(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestForwardedFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []string
+	}{
+		{"single", []string{"for=192.0.2.60;proto=http;by=203.0.113.43"}, []string{"192.0.2.60"}},
+		{"list", []string{"for=192.0.2.43, for=198.51.100.17"}, []string{"192.0.2.43", "198.51.100.17"}},
+		{"multiple headers", []string{"for=192.0.2.43", "For=\"[2001:db8:cafe::17]:4711\""}, []string{"192.0.2.43", "\"[2001:db8:cafe::17]:4711\""}},
+		{"missing for", []string{"proto=https, for=192.0.2.43"}, []string{"", "192.0.2.43"}},
+	}
+	for _, tt := range tests {
+		got := forwardedFor(tt.values)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: forwardedFor = %q, want %q", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: forwardedFor = %q, want %q", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	const proxies = "127.0.0.1,10.0.0.0/8,::1"
+	tests := []struct {
+		name    string
+		header  string // clientIPHeader
+		remote  string
+		headers map[string][]string
+		want    string
+	}{
+		{"direct", "X-Forwarded-For", "198.51.100.9:5000", nil, "198.51.100.9"},
+		{"untrusted peer ignores headers", "X-Forwarded-For", "198.51.100.9:5000",
+			map[string][]string{"X-Forwarded-For": {"203.0.113.7"}}, "198.51.100.9"},
+		{"xff single hop", "X-Forwarded-For", "127.0.0.1:5000",
+			map[string][]string{"X-Forwarded-For": {"203.0.113.7"}}, "203.0.113.7"},
+		{"xff spoofed left hop", "X-Forwarded-For", "127.0.0.1:5000",
+			map[string][]string{"X-Forwarded-For": {"1.2.3.4, 203.0.113.7"}}, "203.0.113.7"},
+		{"xff skips trusted hops", "X-Forwarded-For", "127.0.0.1:5000",
+			map[string][]string{"X-Forwarded-For": {"1.2.3.4, 203.0.113.7, 10.0.0.2"}}, "203.0.113.7"},
+		{"xff multiple headers", "X-Forwarded-For", "127.0.0.1:5000",
+			map[string][]string{"X-Forwarded-For": {"1.2.3.4", "203.0.113.7, 10.0.0.2"}}, "203.0.113.7"},
+		{"xff all trusted", "X-Forwarded-For", "127.0.0.1:5000",
+			map[string][]string{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}}, "10.0.0.3"},
+		{"xff unparsable hop stops", "X-Forwarded-For", "127.0.0.1:5000",
+			map[string][]string{"X-Forwarded-For": {"203.0.113.7, garbage, 10.0.0.2"}}, "10.0.0.2"},
+		{"xff with port", "X-Forwarded-For", "[::1]:5000",
+			map[string][]string{"X-Forwarded-For": {"[2001:db8::1]:4711"}}, "2001:db8::1"},
+		{"xff ignores spoofed forwarded", "X-Forwarded-For", "127.0.0.1:5000",
+			map[string][]string{"Forwarded": {"for=1.2.3.4"}, "X-Forwarded-For": {"203.0.113.7"}}, "203.0.113.7"},
+		{"xff ignores spoofed cf-connecting-ip", "X-Forwarded-For", "127.0.0.1:5000",
+			map[string][]string{"Cf-Connecting-Ip": {"1.2.3.4"}, "X-Real-Ip": {"1.2.3.5"}}, "127.0.0.1"},
+		{"forwarded", "Forwarded", "127.0.0.1:5000",
+			map[string][]string{"Forwarded": {"for=1.2.3.4, for=\"203.0.113.7:4711\";proto=https, for=10.0.0.2"}}, "203.0.113.7"},
+		{"forwarded ignores xff", "Forwarded", "127.0.0.1:5000",
+			map[string][]string{"X-Forwarded-For": {"1.2.3.4"}}, "127.0.0.1"},
+		{"x-real-ip", "X-Real-IP", "127.0.0.1:5000",
+			map[string][]string{"X-Real-Ip": {"203.0.113.7"}}, "203.0.113.7"},
+		{"cf-connecting-ip takes last value", "CF-Connecting-IP", "127.0.0.1:5000",
+			map[string][]string{"Cf-Connecting-Ip": {"1.2.3.4", "203.0.113.7"}}, "203.0.113.7"},
+		{"invalid header value", "X-Real-IP", "127.0.0.1:5000",
+			map[string][]string{"X-Real-Ip": {"unknown"}}, "127.0.0.1"},
+		{"headers disabled", "", "127.0.0.1:5000",
+			map[string][]string{"X-Forwarded-For": {"203.0.113.7"}}, "127.0.0.1"},
+		{"remote without port", "X-Forwarded-For", "198.51.100.9", nil, "198.51.100.9"},
+	}
+	for _, tt := range tests {
+		setClientIPConf(t, proxies, tt.header)
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remote
+		for key, values := range tt.headers {
+			r.Header[key] = values
+		}
+		if got := clientIP(r); got != tt.want {
+			t.Errorf("%s: clientIP = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
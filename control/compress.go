@@ -0,0 +1,204 @@
+package control
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"csz.net/tgstate/conf"
+	"github.com/klauspost/compress/zstd"
+)
+
+// sniffLen http.DetectContentType 最多使用的字节数
+const sniffLen = 512
+
+// compressibleTypes 除 text/* 外适合压缩的 MIME 类型
+var compressibleTypes = map[string]bool{
+	"application/json": true, "application/x-ndjson": true, "application/xml": true,
+	"application/javascript": true, "application/x-javascript": true, "application/ecmascript": true,
+	"application/yaml": true, "application/x-yaml": true, "application/toml": true,
+	"application/sql": true, "application/x-sh": true, "image/svg+xml": true,
+}
+
+// compressibleType 判断 MIME 类型是否适合压缩，+json、+xml 结尾的类型也视为文本
+func compressibleType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || compressibleTypes[mediaType] ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+// compressibleContent 按内容嗅探 MIME 类型，无法识别时再按扩展名判断；
+// 图片、压缩包等已压缩的格式会被嗅探出来，即使扩展名是 .txt 也不会再次压缩
+func compressibleContent(fileName string, data []byte) bool {
+	sniffed := http.DetectContentType(data)
+	if !strings.HasPrefix(sniffed, "application/octet-stream") {
+		return compressibleType(sniffed)
+	}
+	return compressibleType(mime.TypeByExtension(strings.ToLower(filepath.Ext(fileName))))
+}
+
+// compressors 支持的存储压缩算法
+var compressors = map[string]func(io.Writer) (io.WriteCloser, error){
+	"gzip": func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, gzip.BestCompression)
+	},
+	"zstd": func(w io.Writer) (io.WriteCloser, error) {
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	},
+}
+
+// decompressors 与 compressors 对应的解压实现
+var decompressors = map[string]func(io.Reader) (io.ReadCloser, error){
+	"gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"zstd": func(r io.Reader) (io.ReadCloser, error) {
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	},
+}
+
+// compressionFor 根据服务端默认值与上传参数 compress 决定本次使用的压缩算法，空字符串表示不压缩
+func compressionFor(r *http.Request) (string, error) {
+	encoding := strings.ToLower(conf.Compress)
+	if v := strings.ToLower(r.FormValue("compress")); v != "" {
+		encoding = v
+	}
+	if encoding == "" || encoding == "none" {
+		return "", nil
+	}
+	if _, ok := compressors[encoding]; !ok {
+		return "", fmt.Errorf("unsupported compression %s, supported: gzip, zstd", encoding)
+	}
+	return encoding, nil
+}
+
+// compressUpload 边读边把长度为 size 的上传内容压缩到临时文件，只按开头 512 字节嗅探类型，不把整个文件读入内存。
+// 内容不是文本类型或压缩后没有变小时返回 nil，file 已回到开头；否则返回已回到开头的临时文件与压缩后的大小，
+// 调用方用完后需要 removeTemp
+func compressUpload(encoding, fileName string, file io.ReadSeeker, size int64) (*os.File, int64, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, 0, err
+	}
+	if !compressibleContent(fileName, head[:n]) {
+		_, err := file.Seek(0, io.SeekStart)
+		return nil, 0, err
+	}
+	tmp, err := os.CreateTemp("", "tgstate-compress-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	compressed, err := func() (int64, error) {
+		zw, err := compressors[encoding](tmp)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := zw.Write(head[:n]); err != nil {
+			return 0, err
+		}
+		if _, err := io.Copy(zw, file); err != nil {
+			return 0, err
+		}
+		if err := zw.Close(); err != nil {
+			return 0, err
+		}
+		return tmp.Seek(0, io.SeekCurrent)
+	}()
+	if err == nil && compressed < size {
+		log.Printf("文件已压缩(%s): %d -> %d 字节", encoding, size, compressed)
+		_, err = tmp.Seek(0, io.SeekStart)
+		if err == nil {
+			return tmp, compressed, nil
+		}
+	}
+	removeTemp(tmp)
+	if err != nil {
+		return nil, 0, err
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	return nil, 0, err
+}
+
+// removeTemp 关闭并删除临时文件
+func removeTemp(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// acceptsEncoding 检查客户端 Accept-Encoding 是否接受指定编码
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name != encoding && name != "*" {
+			continue
+		}
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// serveCompressed 输出压缩存储的文件：客户端支持时直接返回压缩数据，否则边下载边解压
+func serveCompressed(w http.ResponseWriter, r *http.Request, record FileRecord, body io.Reader, storedLength string) {
+	w.Header().Set("Content-Type", getContentTypeFromExtension(record.Filename))
+	w.Header().Set("Vary", "Accept-Encoding")
+	disposition := "attachment"
+	if isMediaFile(record.Filename) {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=\"%s\"; filename*=UTF-8''%s", disposition, record.Filename, url.QueryEscape(record.Filename)))
+
+	if acceptsEncoding(r, record.Encoding) {
+		w.Header().Set("Content-Encoding", record.Encoding)
+		if storedLength != "" {
+			w.Header().Set("Content-Length", storedLength)
+		}
+		if _, err := io.Copy(w, body); err != nil {
+			log.Println("写入压缩内容时出错:", err)
+		}
+		return
+	}
+
+	newReader, ok := decompressors[record.Encoding]
+	if !ok {
+		http.Error(w, "Unsupported content encoding", http.StatusInternalServerError)
+		return
+	}
+	zr, err := newReader(body)
+	if err != nil {
+		http.Error(w, "Failed to decompress content", http.StatusInternalServerError)
+		return
+	}
+	defer zr.Close()
+	if record.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(record.Size, 10))
+	}
+	if _, err := io.Copy(w, zr); err != nil {
+		log.Println("解压内容时出错:", err)
+	}
+}
@@ -0,0 +1,113 @@
+package control
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCompressibleContent(t *testing.T) {
+	tests := []struct {
+		name, data string
+		want       bool
+	}{
+		{"notes.txt", "hello world", true},
+		{"data.json", `{"a":1}`, true},
+		{"table.csv", "a,b\n1,2\n", true},
+		{"page.html", "<!DOCTYPE html><html></html>", true},
+		{"icon.svg", `<svg xmlns="http://www.w3.org/2000/svg"></svg>`, true},
+		{"no-extension", "plain text without an extension", true},
+		// 内容嗅探出已压缩的格式时忽略扩展名
+		{"image.txt", "\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR", false},
+		{"archive.log", "PK\x03\x04\x14\x00\x00\x00", false},
+		{"backup.json", "\x1f\x8b\x08\x00\x00\x00\x00\x00", false},
+		// 内容无法识别时按扩展名
+		{"app.js", "\x00\x00function(){}", true},
+		{"blob.bin", "\x00\x01\x02\x03", false},
+		{"blob", "\x00\x01\x02\x03", false},
+	}
+	for _, tt := range tests {
+		if got := compressibleContent(tt.name, []byte(tt.data)); got != tt.want {
+			t.Errorf("compressibleContent(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompressUpload(t *testing.T) {
+	text := strings.Repeat("2024-01-01 12:00:00 INFO request handled in 12ms\n", 2000)
+	for encoding := range compressors {
+		file := strings.NewReader(text)
+		compressed, size, err := compressUpload(encoding, "app.log", file, int64(len(text)))
+		if err != nil || compressed == nil {
+			t.Fatalf("%s: compressed = %v, err = %v", encoding, compressed, err)
+		}
+		defer removeTemp(compressed)
+		if size <= 0 || size >= int64(len(text)) {
+			t.Errorf("%s: compressed size %d of %d", encoding, size, len(text))
+		}
+		zr, err := decompressors[encoding](compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(zr)
+		zr.Close()
+		if err != nil || string(got) != text {
+			t.Errorf("%s: round trip failed: %v", encoding, err)
+		}
+	}
+
+	// 不适合压缩或压缩后没有变小时返回 nil，原文件回到开头
+	rng := rand.New(rand.NewSource(1))
+	random := make([]byte, 4096)
+	rng.Read(random)
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		{"image.png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR" + strings.Repeat("a", 1000))},
+		{"random.txt", append([]byte("text "), random...)},
+		{"empty.txt", nil},
+	} {
+		file := bytes.NewReader(tt.data)
+		compressed, _, err := compressUpload("gzip", tt.name, file, int64(len(tt.data)))
+		if err != nil || compressed != nil {
+			t.Errorf("%s: compressed = %v, err = %v, want nil", tt.name, compressed, err)
+			continue
+		}
+		if rest, _ := io.ReadAll(file); !bytes.Equal(rest, tt.data) {
+			t.Errorf("%s: original not rewound", tt.name)
+		}
+	}
+}
+
+func TestServeCompressed(t *testing.T) {
+	text := strings.Repeat("hello zstd\n", 100)
+	var buf bytes.Buffer
+	zw, _ := compressors["zstd"](&buf)
+	zw.Write([]byte(text))
+	zw.Close()
+	record := FileRecord{Filename: "a.txt", Encoding: "zstd", Size: int64(len(text))}
+
+	for _, tt := range []struct {
+		accept   string
+		encoding string
+		body     string
+	}{
+		{"gzip, zstd", "zstd", buf.String()},
+		{"gzip, zstd;q=0", "", text},
+		{"", "", text},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/d/x", nil)
+		r.Header.Set("Accept-Encoding", tt.accept)
+		w := httptest.NewRecorder()
+		serveCompressed(w, r, record, bytes.NewReader(buf.Bytes()), strconv.Itoa(buf.Len()))
+		if got := w.Header().Get("Content-Encoding"); got != tt.encoding || w.Body.String() != tt.body {
+			t.Errorf("Accept-Encoding %q: Content-Encoding %q, body matches %v", tt.accept, got, w.Body.String() == tt.body)
+		}
+	}
+}
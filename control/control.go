@@ -1,6 +1,7 @@
 package control
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -93,11 +94,18 @@ func isMediaFile(filename string) bool {
 	return false
 }
 
+// metadataExts 上传时需要清理元数据的图片格式
+var metadataExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".webp": true}
+
 // UploadAPI 上传图片api
 func UploadAPI(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	if r.Method == http.MethodPost {
+		user, ok := uploadUser(w, r)
+		if !ok {
+			return
+		}
 		// 获取上传的文件
 		file, header, err := r.FormFile("file")
 
@@ -143,19 +151,124 @@ func UploadAPI(w http.ResponseWriter, r *http.Request) {
 			// http.Error(w, "Invalid file type. Only .jpg, .jpeg, and .png are allowed.", http.StatusBadRequest)
 			return
 		}
+		owner := uploadOwner(r, user)
+		if !checkQuota(w, owner, user, header.Size, 1, "") {
+			return
+		}
+		// 端到端加密上传：文件已在浏览器中加密，这里只记录解密参数
+		e2eMeta, err := e2eUploadMeta(r)
+		if err != nil {
+			errJsonMsg(err.Error(), w)
+			return
+		}
+		// 文本类文件按需压缩后再存储
+		var upload io.ReadSeeker = file
+		var encoding string
+		storedSize := header.Size
+		// 清理照片中的 EXIF/GPS 等元数据，可通过 keepMetadata=true 保留
+		if e2eMeta == nil && conf.StripMetadata && r.FormValue("keepMetadata") != "true" && metadataExts[strings.ToLower(ext)] {
+			data, err := io.ReadAll(file)
+			if err != nil {
+				errJsonMsg("Unable to read file", w)
+				return
+			}
+			cleaned, _, err := utils.StripImageMetadata(data)
+			if err != nil {
+				errJsonMsg("Unable to strip image metadata, upload with keepMetadata=true to skip", w)
+				return
+			}
+			upload, storedSize = bytes.NewReader(cleaned), int64(len(cleaned))
+		}
+		if e2eMeta == nil {
+			conversion, err := conversionFor(r, fileName)
+			if err != nil {
+				errJsonMsg(err.Error(), w)
+				return
+			}
+			if conversion != nil {
+				raw, err := io.ReadAll(upload)
+				if err != nil {
+					errJsonMsg("Unable to read file", w)
+					return
+				}
+				data, name := convertUpload(*conversion, fileName, raw)
+				upload, fileName, storedSize = bytes.NewReader(data), name, int64(len(data))
+			}
+			compression, err := compressionFor(r)
+			if err != nil {
+				errJsonMsg(err.Error(), w)
+				return
+			}
+			if compression != "" {
+				compressed, size, err := compressUpload(compression, fileName, upload, storedSize)
+				if err != nil {
+					errJsonMsg("Unable to read file", w)
+					return
+				}
+				if compressed != nil {
+					defer removeTemp(compressed)
+					upload, encoding, storedSize = compressed, compression, size
+				}
+			}
+		}
 		res := conf.UploadResponse{
 			Code:    1,
 			Message: "error",
 		}
-		fileId := utils.UpDocument(utils.TgFileData(fileName, file))
+		var fileId string
+		var media utils.MediaMeta
+		var photoSizes []utils.PhotoSize
+		// 以照片形式上传时 Telegram 会生成多个尺寸，失败时退回普通文件上传
+		if e2eMeta == nil && encoding == "" && photoUploadFor(r, fileName, storedSize) {
+			data, err := io.ReadAll(upload)
+			if err != nil {
+				errJsonMsg("Unable to read file", w)
+				return
+			}
+			sizes, err := utils.UpPhoto(utils.TgFileData(fileName, bytes.NewReader(data)))
+			if err != nil {
+				log.Printf("以照片形式上传失败，改为文件上传: %v", err)
+				upload = bytes.NewReader(data)
+			} else {
+				largest := sizes[len(sizes)-1]
+				fileId, photoSizes = largest.FileId, sizes
+				fileName, storedSize = photoFileName(fileName), int64(largest.FileSize)
+				media = utils.MediaMeta{Width: largest.Width, Height: largest.Height}
+			}
+		}
+		if fileId == "" {
+			fileId, media = utils.UpDocumentWithMeta(utils.TgFileData(fileName, upload))
+		}
+		if len(photoSizes) > 0 {
+			if err := SavePhotoSizes(fileId, photoSizes); err != nil {
+				log.Printf("保存照片尺寸失败: %v", err)
+			}
+		}
 		if fileName != "blob" {
-			ip := r.RemoteAddr // 获取上传者IP
-			userFingerprint := r.FormValue("userFingerprint")
 			shared := r.FormValue("shared") == "true"
+			if e2eMeta != nil {
+				// 没有密钥无法预览，加密文件不进入广场
+				shared = false
+			}
 			// 插入数据到数据库
-			err := SaveFileRecord(fileId, fileName, ip, userFingerprint, shared)
+			err := SaveFileRecord(FileRecord{
+				FileId:          fileId,
+				Filename:        fileName,
+				Ip:              owner.Ip, // 获取上传者IP
+				UserFingerprint: r.FormValue("userFingerprint"),
+				Shared:          shared,
+				Encryption:      e2eMeta,
+				Encoding:        encoding,
+				Size:            header.Size,
+				StoredSize:      storedSize,
+				Media:           mediaOrNil(media),
+				UserId:          user.id(),
+				ApiKeyId:        owner.ApiKeyId,
+			})
 			if err != nil {
 				errJsonMsg("Unable to save file record", w)
+			} else {
+				queueHLS(FileRecord{FileId: fileId, Filename: fileName, Encryption: e2eMeta, Encoding: encoding})
 			}
 		}
 
@@ -231,6 +344,14 @@ func S(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, conf.FileRoute+fileId, http.StatusFound)
 }
 
+// resolveFile 按 file_id 查找文件记录，开启 nameLookup 时允许按文件名查找最新上传的同名文件
+func resolveFile(idOrName string) (FileRecord, error) {
+	if conf.NameLookup {
+		return GetFileNameByIDOrName(idOrName)
+	}
+	return GetFileById(idOrName)
+}
+
 // D 下载文件
 func D(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Path
@@ -242,9 +363,52 @@ func D(w http.ResponseWriter, r *http.Request) {
 		errJsonMsg("404 Not Found", w)
 		return
 	}
-	record, err := GetFileNameByIDOrName(fileId)
-	if err == nil && record.FileId != "" {
+	// /d/{id}/{filename} 为带文件名的规范地址，文件名必须与记录一致
+	var record FileRecord
+	var err error
+	if id, name, ok := strings.Cut(fileId, "/"); ok {
+		record, err = GetFileById(id)
+		if err != nil || record.Filename != name {
+			http.NotFound(w, r)
+			return
+		}
 		fileId = record.FileId
+	} else {
+		record, err = resolveFile(fileId)
+		if err == nil && record.FileId != "" {
+			fileId = record.FileId
+		}
+	}
+
+	// 端到端加密文件默认返回解密页面，?raw=1 时才返回密文
+	encrypted := record.Encryption != nil
+	if encrypted && r.URL.Query().Get("raw") == "" {
+		serveE2EViewer(w, record)
+		return
+	}
+
+	// 图片缩略图/格式转换：?w= ?h= ?fit= ?format=
+	variant, err := parseVariant(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if variant != nil && canResize(record) {
+		serveVariant(w, record, *variant)
+		return
+	}
+
+	// 以照片形式上传的文件可通过 ?size=small|medium|large|original 选择 Telegram 生成的尺寸
+	if size := r.URL.Query().Get("size"); size != "" && record.FileId != "" {
+		sizes, err := GetPhotoSizes(record.FileId)
+		if err == nil && len(sizes) > 0 {
+			picked, err := pickPhotoSize(sizes, size)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			fileId = picked.FileId
+		}
 	}
 
 	// 发起HTTP GET请求来获取Telegram文件
@@ -257,8 +421,11 @@ func D(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 如果客户端发送了Range请求头，转发给Telegram
+	// 如果客户端发送了Range请求头，转发给Telegram（压缩存储的文件无法按原始偏移读取，忽略Range）
 	rangeHeader := r.Header.Get("Range")
+	if record.Encoding != "" {
+		rangeHeader = ""
+	}
 	if rangeHeader != "" {
 		req.Header.Set("Range", rangeHeader)
 	}
@@ -277,6 +444,10 @@ func D(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("404 Not Found"))
 		return
 	}
+	if record.Encoding != "" {
+		serveCompressed(w, r, record, resp.Body, resp.Header.Get("Content-Length"))
+		return
+	}
 	// 获取内容长度
 	var contentLength int
 	var buffer []byte
@@ -352,11 +523,19 @@ func D(w http.ResponseWriter, r *http.Request) {
 				contentType = detectedType
 			}
 		}
+		if encrypted {
+			// 密文不能按原始扩展名解析
+			contentType = "application/octet-stream"
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("Cache-Control", "no-store")
+		}
 
 		w.Header().Set("Content-Type", contentType)
 
 		// 设置文件名和Content-Disposition，优先使用数据库中的原始文件名
-		if err == nil && record.Filename != "" {
+		if encrypted {
+			w.Header().Set("Content-Disposition", "attachment")
+		} else if err == nil && record.Filename != "" {
 			// 对文件名进行URL编码以处理特殊字符
 			encodedFilename := url.QueryEscape(record.Filename)
 
@@ -502,37 +681,58 @@ func Pwd(w http.ResponseWriter, r *http.Request) {
 
 		// 直接将HTML内容发送给客户端
 		w.Header().Set("Content-Type", "text/html")
-		if err := tmpl.Execute(w, nil); err != nil {
+		if err := tmpl.Execute(w, map[string]interface{}{"OIDC": OIDCEnabled()}); err != nil {
 			http.Error(w, "Error rendering HTML template", http.StatusInternalServerError)
 		}
 		return
 	}
-	// 设置cookie
-	cookie := http.Cookie{
-		Name:  "p",
-		Value: r.FormValue("p"),
+	// 密码正确时写入签名 Cookie，错误时回到输入页
+	if !passRequired() || !secretEqual(r.FormValue("p"), conf.Pass) {
+		http.Redirect(w, r, "/pwd", http.StatusSeeOther)
+		return
 	}
-	http.SetCookie(w, &cookie)
+	setAccessCookie(w, r)
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
+// listScope 文件与短链列表的访问范围：管理员查看全部（返回 0），其他账号只能查看自己上传的
+func listScope(w http.ResponseWriter, r *http.Request) (userId int64, ok bool) {
+	if apiPassGiven(r) {
+		_, ok := requireAdmin(w, r)
+		return 0, ok
+	}
+	user, ok := authUser(w, r, scopeRead)
+	if !ok {
+		return 0, false
+	}
+	if user == nil {
+		jsonError(w, http.StatusUnauthorized, "Login required")
+		return 0, false
+	}
+	if requestCan(r, user, scopeAdmin) {
+		return 0, true
+	}
+	return user.Id, true
+}
+
 func FilesAPI(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	password := r.URL.Query().Get("password")
+	userId, ok := listScope(w, r)
+	if !ok {
+		return
+	}
 	response := conf.ResponseResult{
 		Code:    0,
 		Message: "ok",
 	}
 
-	if conf.ApiPass != "" && password != conf.ApiPass {
-		response.Message = "Unauthorized"
-		response.Code = 1
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(response)
-		return
+	var record []FileRecord
+	var err error
+	if userId != 0 {
+		record, err = SelectRecordsByUser(userId)
+	} else {
+		record, err = SelectAllRecord()
 	}
-
-	record, err := SelectAllRecord()
 	response.Data = record
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -544,21 +744,22 @@ func FilesAPI(w http.ResponseWriter, r *http.Request) {
 // ShortLinksAPI 短链统计API
 func ShortLinksAPI(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	password := r.URL.Query().Get("password")
+	userId, ok := listScope(w, r)
+	if !ok {
+		return
+	}
 	response := conf.ResponseResult{
 		Code:    0,
 		Message: "ok",
 	}
 
-	if conf.ApiPass != "" && password != conf.ApiPass {
-		response.Message = "Unauthorized"
-		response.Code = 1
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(response)
-		return
+	var shortLinks []ShortLink
+	var err error
+	if userId != 0 {
+		shortLinks, err = GetShortLinksByUser(userId)
+	} else {
+		shortLinks, err = GetAllShortLinks()
 	}
-
-	shortLinks, err := GetAllShortLinks()
 	response.Data = shortLinks
 	if err != nil {
 		response.Message = "Failed to get short links"
@@ -574,8 +775,14 @@ func HistoryAPI(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
+	// 登录后按账号查询，否则按浏览器指纹查询匿名上传的记录
+	user, ok := authUser(w, r, scopeRead)
+	if !ok {
+		return
+	}
+	loggedIn := user != nil
 	userFingerprint := r.URL.Query().Get("fingerprint")
-	if userFingerprint == "" {
+	if !loggedIn && userFingerprint == "" {
 		response := conf.ResponseResult{
 			Code:    1,
 			Message: "Missing fingerprint parameter",
@@ -599,7 +806,13 @@ func HistoryAPI(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	records, err := GetFilesByUserFingerprint(userFingerprint, page, pageSize)
+	var records []FileRecord
+	var err error
+	if loggedIn {
+		records, err = GetFilesByUser(user.Id, page, pageSize)
+	} else {
+		records, err = GetFilesByUserFingerprint(userFingerprint, page, pageSize)
+	}
 	if err != nil {
 		response := conf.ResponseResult{
 			Code:    1,
@@ -611,7 +824,12 @@ func HistoryAPI(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 获取总数
-	total, _ := GetUserFilesCount(userFingerprint)
+	var total int
+	if loggedIn {
+		total, _ = GetFilesCountByUser(user.Id)
+	} else {
+		total, _ = GetUserFilesCount(userFingerprint)
+	}
 
 	responseData := map[string]interface{}{
 		"files": records,
@@ -693,8 +911,13 @@ func ChunkUploadAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	user, ok := uploadUser(w, r)
+	if !ok {
+		return
+	}
+
 	// 获取上传的分片文件
-	file, _, err := r.FormFile("file")
+	file, header, err := r.FormFile("file")
 	if err != nil {
 		errJsonMsg("Unable to get chunk file", w)
 		return
@@ -709,6 +932,27 @@ func ChunkUploadAPI(w http.ResponseWriter, r *http.Request) {
 		errJsonMsg("Missing required parameters", w)
 		return
 	}
+	if index, err := strconv.Atoi(chunkIndex); err != nil || index < 0 {
+		errJsonMsg("Invalid chunkIndex", w)
+		return
+	}
+
+	// 上传 ID 已有其他上传者的分片时拒绝，避免向别人的上传中插入分片
+	owner := uploadOwner(r, user)
+	records, err := GetChunkRecords(uploadId)
+	if err != nil {
+		errJsonMsg("Failed to read chunk records", w)
+		return
+	}
+	if !owner.ownsChunks(records) {
+		jsonError(w, http.StatusForbidden, "uploadId belongs to another uploader")
+		return
+	}
+
+	// 分片属于一个新文件，按一个文件检查配额
+	if !checkQuota(w, owner, user, header.Size, 1, "") {
+		return
+	}
 
 	// 上传分片到Telegram
 	chunkFileName := fmt.Sprintf("%s.chunk.%s", fileName, chunkIndex)
@@ -720,9 +964,8 @@ func ChunkUploadAPI(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 保存分片信息到数据库
-	ip := r.RemoteAddr
 	userFingerprint := r.FormValue("userFingerprint")
-	err = SaveChunkRecord(uploadId, chunkIndex, chunkId, fileName, ip, userFingerprint)
+	err = SaveChunkRecord(uploadId, chunkIndex, chunkId, fileName, userFingerprint, header.Size, owner)
 	if err != nil {
 		errJsonMsg("Failed to save chunk record", w)
 		return
@@ -748,13 +991,19 @@ func MergeChunksAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	user, ok := uploadUser(w, r)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		UploadId        string   `json:"uploadId"`
 		FileName        string   `json:"fileName"`
-		ChunkIds        []string `json:"chunkIds"`
 		FileSize        int64    `json:"fileSize"`
 		UserFingerprint string   `json:"userFingerprint"`
 		Shared          bool     `json:"shared"`
+		E2E             bool     `json:"e2e"`
+		E2EMeta         *E2EMeta `json:"e2eMeta"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -762,25 +1011,92 @@ func MergeChunksAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.UploadId == "" || req.FileName == "" || len(req.ChunkIds) == 0 {
+	if req.UploadId == "" || req.FileName == "" {
 		errJsonMsg("Missing required parameters", w)
 		return
 	}
+	// JSON 请求体中的浏览器指纹在 uploadUser 中读不到，这里单独检查
+	if IsBanned(banFingerprint, req.UserFingerprint) {
+		jsonError(w, http.StatusForbidden, "Uploads from this client are banned")
+		return
+	}
+	// 分片列表与文件大小都以服务端的分片记录为准（请求中的 chunkIds 被忽略），
+	// 声明的大小不一致时拒绝，避免少报大小绕过配额
+	records, err := GetChunkRecords(req.UploadId)
+	if err != nil {
+		errJsonMsg("Failed to read chunk records", w)
+		return
+	}
+	if len(records) == 0 {
+		jsonError(w, http.StatusBadRequest, "No chunks uploaded for this uploadId")
+		return
+	}
+	owner := uploadOwner(r, user)
+	if !owner.ownsChunks(records) {
+		jsonError(w, http.StatusForbidden, "uploadId belongs to another uploader")
+		return
+	}
+	chunkIds := make([]string, len(records))
+	var chunkSize int64
+	for i, record := range records {
+		if record.ChunkIndex != i {
+			jsonError(w, http.StatusBadRequest, fmt.Sprintf("Chunk %d is missing", i))
+			return
+		}
+		chunkIds[i] = record.ChunkId
+		chunkSize += record.Size
+	}
+	if chunkSize != req.FileSize {
+		jsonError(w, http.StatusBadRequest, fmt.Sprintf("fileSize %d does not match the %d bytes of uploaded chunks", req.FileSize, chunkSize))
+		return
+	}
+	if !checkQuota(w, owner, user, chunkSize, 1, req.UploadId) {
+		return
+	}
+
+	// 端到端加密的分片文件：分片内容为整体密文的切片
+	if req.E2E {
+		if req.E2EMeta == nil {
+			errJsonMsg("missing e2e metadata", w)
+			return
+		}
+		if err := req.E2EMeta.validate(); err != nil {
+			errJsonMsg(err.Error(), w)
+			return
+		}
+		req.Shared = false
+	} else {
+		req.E2EMeta = nil
+	}
 
 	// 创建合并文件的元数据
-	mergedFileId := utils.CreateMergedFile(req.FileName, req.ChunkIds, req.FileSize)
+	mergedFileId := utils.CreateMergedFile(req.FileName, chunkIds, chunkSize)
 	if mergedFileId == "" {
 		errJsonMsg("Failed to create merged file", w)
 		return
 	}
 
 	// 保存文件记录
-	ip := r.RemoteAddr
-	err := SaveFileRecord(mergedFileId, req.FileName, ip, req.UserFingerprint, req.Shared)
+	err = SaveFileRecord(FileRecord{
+		FileId:          mergedFileId,
+		Filename:        req.FileName,
+		Ip:              owner.Ip,
+		UserFingerprint: req.UserFingerprint,
+		Shared:          req.Shared,
+		Encryption:      req.E2EMeta,
+		Size:            chunkSize,
+		StoredSize:      chunkSize,
+		UserId:          user.id(),
+		ApiKeyId:        owner.ApiKeyId,
+	})
 	if err != nil {
 		errJsonMsg("Failed to save file record", w)
 		return
 	}
+	queueHLS(FileRecord{FileId: mergedFileId, Filename: req.FileName, Encryption: req.E2EMeta})
+	if _, err := SaveManifestRecord(ManifestRecord{FileId: mergedFileId, FileName: req.FileName, Size: chunkSize, ChunkIds: chunkIds}); err != nil {
+		log.Printf("保存分片元数据失败: %v", err)
+	}
 
 	// 生成短链
 	downloadUrl := conf.FileRoute + mergedFileId
@@ -888,17 +1204,18 @@ func ServeDistFiles(w http.ResponseWriter, r *http.Request) {
 	http.NotFound(w, r)
 }
 
+// Middleware 校验访问密码，API 客户端未通过时返回 JSON 401，浏览器跳转到 /pwd
 func Middleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// 只有当密码设置并且不为"none"时，才进行检查
-		if conf.Pass != "" && conf.Pass != "none" {
-			if strings.HasPrefix(r.URL.Path, "/api") && r.URL.Query().Get("pass") == conf.Pass {
-				return
-			}
-			if cookie, err := r.Cookie("p"); err != nil || cookie.Value != conf.Pass {
+		r = withAPIKey(r)
+		if !accessOK(r) {
+			if apiClient(r) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="tgState"`)
+				jsonError(w, http.StatusUnauthorized, "Access password required")
+			} else {
 				http.Redirect(w, r, "/pwd", http.StatusSeeOther)
-				return
 			}
+			return
 		}
 		next(w, r)
 	}
@@ -0,0 +1,72 @@
+package control
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"csz.net/tgstate/conf"
+	"csz.net/tgstate/utils"
+)
+
+// convertibleExts 上传时可以转换格式的图片扩展名
+var convertibleExts = map[string]bool{".png": true}
+
+// imageConversion 上传时的格式转换参数
+type imageConversion struct {
+	Format  string // webp（无损）或 jpeg
+	Quality int    // JPEG 质量，0 表示默认
+}
+
+// conversionFor 根据服务端默认值与上传参数 convert、quality 决定是否转换格式，返回 nil 表示不转换
+func conversionFor(r *http.Request, fileName string) (*imageConversion, error) {
+	format := strings.ToLower(conf.Convert)
+	if v := strings.ToLower(r.FormValue("convert")); v != "" {
+		format = v
+	}
+	switch format {
+	case "", "none":
+		return nil, nil
+	case "jpg":
+		format = "jpeg"
+	case "webp", "jpeg":
+	default:
+		return nil, fmt.Errorf("unsupported conversion %s", format)
+	}
+	quality := conf.ConvertQuality
+	if v := r.FormValue("quality"); v != "" {
+		q, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quality")
+		}
+		quality = q
+	}
+	if quality < 0 || quality > 100 {
+		return nil, fmt.Errorf("quality must be between 1 and 100")
+	}
+	if !convertibleExts[strings.ToLower(filepath.Ext(fileName))] {
+		return nil, nil
+	}
+	return &imageConversion{Format: format, Quality: quality}, nil
+}
+
+// convertUpload 转换图片格式并返回新的文件名，转换失败或没有变小时返回原始内容与文件名
+func convertUpload(c imageConversion, fileName string, raw []byte) ([]byte, string) {
+	data, err := utils.ConvertImage(raw, c.Format, c.Quality)
+	if err != nil {
+		log.Printf("图片格式转换失败 %s: %v", fileName, err)
+		return raw, fileName
+	}
+	if len(data) >= len(raw) {
+		return raw, fileName
+	}
+	ext := "." + c.Format
+	if c.Format == "jpeg" {
+		ext = ".jpg"
+	}
+	log.Printf("图片已转换(%s): %d -> %d 字节", c.Format, len(raw), len(data))
+	return data, strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ext
+}
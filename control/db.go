@@ -2,96 +2,143 @@ package control
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"csz.net/tgstate/conf"
+	"csz.net/tgstate/utils"
 )
 
 var (
-	db   *sql.DB
+	db   *DB
 	once sync.Once
 )
 
-// InitDB 初始化数据库，创建表结构
-func InitDB() (*sql.DB, error) {
+// InitDB 初始化数据库并执行未完成的迁移
+func InitDB() (*DB, error) {
 	var err error
 	// 使用 sync.Once 确保数据库只初始化一次
 	once.Do(func() {
-		db, err = sql.Open("sqlite3", "./files.db")
+		db, err = OpenDB()
 		if err != nil {
 			log.Fatal("Failed to open database:", err)
 		}
 
-		query := `CREATE TABLE IF NOT EXISTS uploaded_files (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			fileId TEXT NOT NULL,
-			filename TEXT NOT NULL,
-			ip TEXT NOT NULL,
-			user_fingerprint TEXT,
-			shared INTEGER DEFAULT 0,
-			time TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		);`
-		_, err = db.Exec(query)
+		applied, err := ApplyMigrations(db, false)
 		if err != nil {
-			log.Fatal("Failed to create table:", err)
-		}
-
-		// 创建短链表
-		shortLinkQuery := `CREATE TABLE IF NOT EXISTS short_links (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			short_code TEXT UNIQUE NOT NULL,
-			file_id TEXT NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			access_count INTEGER DEFAULT 0
-		);`
-		_, err = db.Exec(shortLinkQuery)
-		if err != nil {
-			log.Fatal("Failed to create short_links table:", err)
-		}
-
-		// 创建分片记录表
-		chunkQuery := `CREATE TABLE IF NOT EXISTS chunk_records (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			upload_id TEXT NOT NULL,
-			chunk_index INTEGER NOT NULL,
-			chunk_id TEXT NOT NULL,
-			file_name TEXT NOT NULL,
-			ip TEXT NOT NULL,
-			user_fingerprint TEXT,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			UNIQUE(upload_id, chunk_index)
-		);`
-		_, err = db.Exec(chunkQuery)
-		if err != nil {
-			log.Fatal("Failed to create chunk_records table:", err)
+			log.Fatal("Failed to migrate database:", err)
+		}
+		for _, m := range applied {
+			log.Printf("数据库迁移完成: %04d_%s", m.Version, m.Name)
 		}
-
-		// 迁移：为现有表添加 user_fingerprint 字段（如果不存在）
-		migrationQuery := `ALTER TABLE uploaded_files ADD COLUMN user_fingerprint TEXT;`
-		_, _ = db.Exec(migrationQuery) // 忽略错误，因为字段可能已存在
-
-		migrationQuery2 := `ALTER TABLE chunk_records ADD COLUMN user_fingerprint TEXT;`
-		_, _ = db.Exec(migrationQuery2) // 忽略错误，因为字段可能已存在
-
-		// 迁移：为现有表添加 shared 字段（如果不存在）
-		migrationQuery3 := `ALTER TABLE uploaded_files ADD COLUMN shared INTEGER DEFAULT 0;`
-		_, _ = db.Exec(migrationQuery3) // 忽略错误，因为字段可能已存在
 	})
 
 	return db, err
 }
 
+// OpenDB 按 dsn 配置打开数据库连接，不执行迁移
+func OpenDB() (*DB, error) {
+	driver, source, dialect, err := parseDSN(conf.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if dialect == DialectSQLite {
+		// 数据库所在目录不存在时自动创建
+		file, _, _ := strings.Cut(source, "?")
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return nil, err
+		}
+	}
+	conn, err := sql.Open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	d := &DB{DB: conn, Dialect: dialect}
+	if dialect == DialectSQLite {
+		d.writeMu = &sync.Mutex{}
+	}
+	return d, nil
+}
+
 type FileRecord struct {
-	FileId          string    `json:"fileId"`
-	Filename        string    `json:"filename"`
-	Ip              string    `json:"ip"`
-	UserFingerprint string    `json:"userFingerprint"`
-	Shared          bool      `json:"shared"`
-	Time            time.Time `json:"time"`
+	FileId          string           `json:"fileId"`
+	Filename        string           `json:"filename"`
+	Ip              string           `json:"ip"`
+	UserFingerprint string           `json:"userFingerprint"`
+	Shared          bool             `json:"shared"`
+	Time            time.Time        `json:"time"`
+	Encryption      *E2EMeta         `json:"encryption,omitempty"`
+	Encoding        string           `json:"encoding,omitempty"`   // 存储时使用的压缩算法，空表示未压缩
+	Size            int64            `json:"size,omitempty"`       // 原始文件大小
+	StoredSize      int64            `json:"storedSize,omitempty"` // 实际存储到 Telegram 的大小
+	Media           *utils.MediaMeta `json:"media,omitempty"`      // 视频/音频的时长、分辨率与缩略图
+	UserId          int64            `json:"userId,omitempty"`     // 上传者账号，匿名上传为 0
+	ApiKeyId        int64            `json:"apiKeyId,omitempty"`   // 上传时使用的 API 密钥，用于统计密钥的配额
+}
+
+// fileRecordColumns 查询 uploaded_files 时统一使用的字段列表，顺序需与 scanFileRecord 保持一致
+const fileRecordColumns = "fileId, filename, ip, COALESCE(user_fingerprint, ''), COALESCE(shared, 0), time, COALESCE(encryption, ''), COALESCE(encoding, ''), COALESCE(size, 0), COALESCE(stored_size, 0), " +
+	"COALESCE(duration, 0), COALESCE(width, 0), COALESCE(height, 0), COALESCE(thumb_file_id, ''), COALESCE(performer, ''), COALESCE(title, ''), COALESCE(user_id, 0), COALESCE(api_key_id, 0)"
+
+// rowScanner 兼容 *sql.Row 与 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanFileRecord 将一行 fileRecordColumns 查询结果解析为 FileRecord
+func scanFileRecord(row rowScanner) (FileRecord, error) {
+	var record FileRecord
+	var shared int
+	var encryption string
+	var media utils.MediaMeta
+	err := row.Scan(&record.FileId, &record.Filename, &record.Ip, &record.UserFingerprint, &shared, &record.Time, &encryption, &record.Encoding, &record.Size, &record.StoredSize,
+		&media.Duration, &media.Width, &media.Height, &media.ThumbFileId, &media.Performer, &media.Title, &record.UserId, &record.ApiKeyId)
+	if err != nil {
+		return FileRecord{}, err
+	}
+	record.Shared = shared == 1
+	if !media.IsZero() {
+		record.Media = &media
+	}
+	if encryption != "" {
+		var meta E2EMeta
+		if err := json.Unmarshal([]byte(encryption), &meta); err != nil {
+			return FileRecord{}, fmt.Errorf("invalid encryption metadata for %s: %v", record.FileId, err)
+		}
+		record.Encryption = &meta
+	}
+	return record, nil
+}
+
+// scanFileRecords 读取多行 fileRecordColumns 查询结果
+func scanFileRecords(rows *sql.Rows) ([]FileRecord, error) {
+	defer rows.Close()
+
+	var records []FileRecord
+	for rows.Next() {
+		record, err := scanFileRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
 }
 
 type ShortLink struct {
@@ -102,62 +149,64 @@ type ShortLink struct {
 	AccessCount int       `json:"accessCount"`
 }
 
-// GetFileNameByIDOrName 查询文件名
+// GetFileNameByIDOrName 查询文件记录，先按 file_id 精确查找，找不到时再按文件名查找最新的一条
 func GetFileNameByIDOrName(idOrName string) (FileRecord, error) {
-	var record FileRecord
-	var shared int
-	// 执行查询，获取对应id或name的file记录
-	query := "SELECT fileId, filename, ip, COALESCE(user_fingerprint, '') as user_fingerprint, COALESCE(shared, 0) as shared, time FROM uploaded_files WHERE fileId = ? OR filename = ? ORDER BY time DESC LIMIT 1"
-	err := db.QueryRow(query, idOrName, idOrName).Scan(&record.FileId, &record.Filename, &record.Ip, &record.UserFingerprint, &shared, &record.Time)
+	record, err := GetFileById(idOrName)
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return record, err
+	}
+	record, err = scanFileRecord(db.QueryRow("SELECT "+fileRecordColumns+" FROM uploaded_files WHERE filename = ? ORDER BY time DESC LIMIT 1", idOrName))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return FileRecord{}, fmt.Errorf("no file found with idOrName %s", idOrName)
 		}
 		return FileRecord{}, err
 	}
-	record.Shared = shared == 1
 
 	return record, nil
 }
 
-func SaveFileRecord(fileID string, fileName string, ip string, userFingerprint string, shared bool) error {
+// GetFileById 按 file_id 查询文件记录，找不到时返回 sql.ErrNoRows
+func GetFileById(fileId string) (FileRecord, error) {
+	return scanFileRecord(db.QueryRow("SELECT "+fileRecordColumns+" FROM uploaded_files WHERE fileId = ? ORDER BY time DESC LIMIT 1", fileId))
+}
+
+// SaveFileRecord 保存上传文件记录，record.Time 为空时由数据库自动填充
+func SaveFileRecord(record FileRecord) error {
 	// 插入数据到数据库
 	sharedInt := 0
-	if shared {
+	if record.Shared {
 		sharedInt = 1
 	}
-	_, err := db.Exec("INSERT INTO uploaded_files (fileId, filename, ip, user_fingerprint, shared) VALUES (?, ?, ?, ?, ?)", fileID, fileName, ip, userFingerprint, sharedInt)
+	var encryption interface{}
+	if record.Encryption != nil {
+		data, err := json.Marshal(record.Encryption)
+		if err != nil {
+			return err
+		}
+		encryption = string(data)
+	}
+	var media utils.MediaMeta
+	if record.Media != nil {
+		media = *record.Media
+	}
+	columns := []string{"fileId", "filename", "ip", "user_fingerprint", "shared", "encryption", "encoding", "size", "stored_size", "duration", "width", "height", "thumb_file_id", "performer", "title", "user_id", "api_key_id"}
+	args := []interface{}{record.FileId, record.Filename, record.Ip, record.UserFingerprint, sharedInt, encryption, record.Encoding, record.Size, record.StoredSize,
+		media.Duration, media.Width, media.Height, media.ThumbFileId, media.Performer, media.Title, record.UserId, record.ApiKeyId}
+	if !record.Time.IsZero() {
+		columns, args = append(columns, "time"), append(args, record.Time.UTC())
+	}
+	_, err := db.Exec(insertSQL("uploaded_files", columns), args...)
 	return err
 }
 
 func SelectAllRecord() ([]FileRecord, error) {
 	// 查询所有记录
-	rows, err := db.Query("SELECT fileId, filename, ip, COALESCE(user_fingerprint, '') as user_fingerprint, COALESCE(shared, 0) as shared, time FROM uploaded_files ORDER BY time DESC")
+	rows, err := db.Query("SELECT " + fileRecordColumns + " FROM uploaded_files ORDER BY time DESC")
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var records []FileRecord
-
-	// 迭代查询结果
-	for rows.Next() {
-		var record FileRecord
-		var shared int
-		err := rows.Scan(&record.FileId, &record.Filename, &record.Ip, &record.UserFingerprint, &shared, &record.Time)
-		if err != nil {
-			return nil, err
-		}
-		record.Shared = shared == 1
-		records = append(records, record)
-	}
-
-	// 检查查询错误
-	if err = rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return records, nil
+	return scanFileRecords(rows)
 }
 
 // CreateShortLink 创建短链
@@ -228,16 +277,16 @@ func GetShortCodeByFileId(fileId string) (string, error) {
 	return shortCode, nil
 }
 
-// SaveChunkRecord 保存分片记录
-func SaveChunkRecord(uploadId, chunkIndex, chunkId, fileName, ip, userFingerprint string) error {
-	_, err := db.Exec("INSERT OR REPLACE INTO chunk_records (upload_id, chunk_index, chunk_id, file_name, ip, user_fingerprint) VALUES (?, ?, ?, ?, ?, ?)",
-		uploadId, chunkIndex, chunkId, fileName, ip, userFingerprint)
+// SaveChunkRecord 保存分片记录，分片大小与上传者在合并前计入配额
+func SaveChunkRecord(uploadId, chunkIndex, chunkId, fileName, userFingerprint string, size int64, owner UploadOwner) error {
+	_, err := db.Exec(db.Dialect.Upsert("chunk_records", []string{"upload_id", "chunk_index", "chunk_id", "file_name", "ip", "user_fingerprint", "size", "user_id", "api_key_id"}, []string{"upload_id", "chunk_index"}),
+		uploadId, chunkIndex, chunkId, fileName, owner.Ip, userFingerprint, size, owner.UserId, owner.ApiKeyId)
 	return err
 }
 
-// GetChunkRecords 获取指定上传ID的所有分片记录
+// GetChunkRecords 获取指定上传ID的所有分片记录，按分片序号排序
 func GetChunkRecords(uploadId string) ([]ChunkRecord, error) {
-	rows, err := db.Query("SELECT upload_id, chunk_index, chunk_id, file_name, ip, created_at FROM chunk_records WHERE upload_id = ? ORDER BY chunk_index", uploadId)
+	rows, err := db.Query("SELECT upload_id, chunk_index, chunk_id, file_name, ip, COALESCE(user_fingerprint, ''), COALESCE(size, 0), COALESCE(user_id, 0), COALESCE(api_key_id, 0), created_at FROM chunk_records WHERE upload_id = ? ORDER BY chunk_index", uploadId)
 	if err != nil {
 		return nil, err
 	}
@@ -246,7 +295,8 @@ func GetChunkRecords(uploadId string) ([]ChunkRecord, error) {
 	var records []ChunkRecord
 	for rows.Next() {
 		var record ChunkRecord
-		err := rows.Scan(&record.UploadId, &record.ChunkIndex, &record.ChunkId, &record.FileName, &record.Ip, &record.CreatedAt)
+		err := rows.Scan(&record.UploadId, &record.ChunkIndex, &record.ChunkId, &record.FileName, &record.Ip, &record.UserFingerprint,
+			&record.Size, &record.UserId, &record.ApiKeyId, &record.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -273,75 +323,870 @@ type ChunkRecord struct {
 	FileName        string    `json:"fileName"`
 	Ip              string    `json:"ip"`
 	UserFingerprint string    `json:"userFingerprint"`
+	Size            int64     `json:"size"`
+	UserId          int64     `json:"userId"`
+	ApiKeyId        int64     `json:"apiKeyId"`
 	CreatedAt       time.Time `json:"createdAt"`
 }
 
-// GetFilesByUserFingerprint 根据用户指纹获取历史文件
+// GetFilesByUserFingerprint 根据用户指纹获取匿名上传的历史文件，登录后上传的文件只能通过账号查看
 func GetFilesByUserFingerprint(userFingerprint string, page, pageSize int) ([]FileRecord, error) {
 	offset := (page - 1) * pageSize
-	rows, err := db.Query("SELECT fileId, filename, ip, user_fingerprint, COALESCE(shared, 0) as shared, time FROM uploaded_files WHERE user_fingerprint = ? ORDER BY time DESC LIMIT ? OFFSET ?", userFingerprint, pageSize, offset)
+	rows, err := db.Query("SELECT "+fileRecordColumns+" FROM uploaded_files WHERE user_fingerprint = ? AND COALESCE(user_id, 0) = 0 ORDER BY time DESC LIMIT ? OFFSET ?", userFingerprint, pageSize, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanFileRecords(rows)
+}
+
+// GetSharedFiles 获取广场文件（分页）
+func GetSharedFiles(page, pageSize int) ([]FileRecord, error) {
+	offset := (page - 1) * pageSize
+	rows, err := db.Query("SELECT "+fileRecordColumns+" FROM uploaded_files WHERE shared = 1 ORDER BY time DESC LIMIT ? OFFSET ?", pageSize, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanFileRecords(rows)
+}
+
+// GetSharedFilesCount 获取广场文件总数
+func GetSharedFilesCount() (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM uploaded_files WHERE shared = 1").Scan(&count)
+	return count, err
+}
+
+// GetUserFilesCount 获取用户指纹匿名上传的文件总数
+func GetUserFilesCount(userFingerprint string) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM uploaded_files WHERE user_fingerprint = ? AND COALESCE(user_id, 0) = 0", userFingerprint).Scan(&count)
+	return count, err
+}
+
+// GetFilesByUser 获取账号上传的文件（分页）
+func GetFilesByUser(userId int64, page, pageSize int) ([]FileRecord, error) {
+	offset := (page - 1) * pageSize
+	rows, err := db.Query("SELECT "+fileRecordColumns+" FROM uploaded_files WHERE user_id = ? ORDER BY time DESC LIMIT ? OFFSET ?", userId, pageSize, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanFileRecords(rows)
+}
+
+// GetFilesCountByUser 获取账号上传的文件总数
+func GetFilesCountByUser(userId int64) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM uploaded_files WHERE user_id = ?", userId).Scan(&count)
+	return count, err
+}
+
+// DeleteUserFile 删除账号上传的文件记录及其短链，返回删除的记录数。Telegram 中的文件不会被删除
+func DeleteUserFile(userId int64, fileId string) (int64, error) {
+	return deleteFileRecords(fileId, "DELETE FROM uploaded_files WHERE fileId = ? AND user_id = ?", fileId, userId)
+}
+
+// DeleteFile 删除文件的所有记录及其短链（管理员），返回删除的记录数
+func DeleteFile(fileId string) (int64, error) {
+	return deleteFileRecords(fileId, "DELETE FROM uploaded_files WHERE fileId = ?", fileId)
+}
+
+func deleteFileRecords(fileId, query string, args ...interface{}) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	// 其他记录仍引用同一文件时保留短链
+	var remaining int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM uploaded_files WHERE fileId = ?", fileId).Scan(&remaining); err != nil {
+		return 0, err
+	}
+	if remaining == 0 {
+		if _, err := tx.Exec("DELETE FROM short_links WHERE file_id = ?", fileId); err != nil {
+			return 0, err
+		}
+	}
+	return n, tx.Commit()
+}
+
+// SetFileShared 修改文件是否出现在广场，返回是否找到文件
+func SetFileShared(fileId string, shared bool) (bool, error) {
+	sharedInt := 0
+	if shared {
+		sharedInt = 1
+	}
+	result, err := db.Exec("UPDATE uploaded_files SET shared = ? WHERE fileId = ?", sharedInt, fileId)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// FileFilter 管理员搜索文件的条件，空值表示不限
+type FileFilter struct {
+	Query       string // 文件名包含
+	UserId      int64
+	Ip          string
+	Fingerprint string
+	Shared      *bool
+}
+
+func (f FileFilter) where() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if f.Query != "" {
+		conditions = append(conditions, "LOWER(filename) LIKE ?")
+		args = append(args, "%"+strings.ToLower(f.Query)+"%")
+	}
+	if f.UserId != 0 {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, f.UserId)
+	}
+	if f.Ip != "" {
+		conditions = append(conditions, "ip = ?")
+		args = append(args, f.Ip)
+	}
+	if f.Fingerprint != "" {
+		conditions = append(conditions, "user_fingerprint = ?")
+		args = append(args, f.Fingerprint)
+	}
+	if f.Shared != nil {
+		shared := 0
+		if *f.Shared {
+			shared = 1
+		}
+		conditions = append(conditions, "COALESCE(shared, 0) = ?")
+		args = append(args, shared)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// SearchFiles 按条件搜索所有文件（分页），同时返回总数
+func SearchFiles(filter FileFilter, page, pageSize int) ([]FileRecord, int, error) {
+	where, args := filter.where()
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM uploaded_files"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := db.Query("SELECT "+fileRecordColumns+" FROM uploaded_files"+where+" ORDER BY time DESC LIMIT ? OFFSET ?",
+		append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	records, err := scanFileRecords(rows)
+	return records, total, err
+}
+
+// User 用户账号
+type User struct {
+	Id        int64     `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+const userColumns = "id, username, COALESCE(role, ''), created_at"
+
+func scanUser(row rowScanner, extra ...interface{}) (User, error) {
+	var user User
+	err := row.Scan(append([]interface{}{&user.Id, &user.Username, &user.Role, &user.CreatedAt}, extra...)...)
+	return user, err
+}
+
+// errUserExists 用户名已被注册
+var errUserExists = errors.New("username already exists")
+
+// CreateUser 创建账号，用户名已存在时返回 errUserExists
+func CreateUser(username, passwordHash string) (User, error) {
+	if _, _, err := GetUserByName(username); err == nil {
+		return User{}, errUserExists
+	}
+	id, err := db.InsertId("INSERT INTO users (username, password_hash) VALUES (?, ?)", username, passwordHash)
+	if err != nil {
+		// 并发注册同名账号时由唯一约束拦截
+		if _, _, lookupErr := GetUserByName(username); lookupErr == nil {
+			return User{}, errUserExists
+		}
+		return User{}, err
+	}
+	return GetUserById(id)
+}
+
+// GetUserByName 按用户名查询账号及密码哈希
+func GetUserByName(username string) (User, string, error) {
+	var passwordHash string
+	user, err := scanUser(db.QueryRow("SELECT "+userColumns+", password_hash FROM users WHERE username = ?", username), &passwordHash)
+	return user, passwordHash, err
+}
+
+// GetUserById 按 id 查询账号
+func GetUserById(id int64) (User, error) {
+	return scanUser(db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = ?", id))
+}
+
+// SetUserRole 修改账号角色
+func SetUserRole(id int64, role string) error {
+	_, err := db.Exec("UPDATE users SET role = ? WHERE id = ?", role, id)
+	return err
+}
+
+// GetUserByIdentity 按外部登录（OIDC、Telegram 等）的身份查找账号
+func GetUserByIdentity(provider, subject string) (User, error) {
+	var userId int64
+	if err := db.QueryRow("SELECT user_id FROM user_identities WHERE provider = ? AND subject = ?", provider, subject).Scan(&userId); err != nil {
+		return User{}, err
+	}
+	return GetUserById(userId)
+}
+
+// LinkIdentity 把外部登录的身份关联到账号，同一身份只能关联一个账号
+func LinkIdentity(userId int64, provider, subject string) error {
+	_, err := db.Exec(insertSQL("user_identities", []string{"provider", "subject", "user_id"}), provider, subject, userId)
+	return err
+}
+
+// Session 登录会话，数据库中只保存令牌的哈希
+type Session struct {
+	UserId    int64
+	CsrfToken string
+	ExpiresAt time.Time
+}
+
+// CreateSession 保存登录会话，同时清理已过期的会话
+func CreateSession(tokenHash string, session Session) error {
+	if _, err := db.Exec("DELETE FROM sessions WHERE expires_at < ?", time.Now().UTC()); err != nil {
+		return err
+	}
+	_, err := db.Exec("INSERT INTO sessions (token_hash, user_id, csrf_token, expires_at) VALUES (?, ?, ?, ?)",
+		tokenHash, session.UserId, session.CsrfToken, session.ExpiresAt.UTC())
+	return err
+}
+
+// GetSession 查询未过期的会话
+func GetSession(tokenHash string) (Session, error) {
+	var session Session
+	err := db.QueryRow("SELECT user_id, csrf_token, expires_at FROM sessions WHERE token_hash = ?", tokenHash).
+		Scan(&session.UserId, &session.CsrfToken, &session.ExpiresAt)
+	if err != nil {
+		return Session{}, err
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return Session{}, sql.ErrNoRows
+	}
+	return session, nil
+}
+
+// DeleteSession 删除会话（退出登录）
+func DeleteSession(tokenHash string) error {
+	_, err := db.Exec("DELETE FROM sessions WHERE token_hash = ?", tokenHash)
+	return err
+}
+
+// ImageVariant 已上传到 Telegram 的图片变体
+type ImageVariant struct {
+	FileId        string    `json:"fileId"`
+	VariantKey    string    `json:"variantKey"`
+	VariantFileId string    `json:"variantFileId"`
+	ContentType   string    `json:"contentType"`
+	Size          int64     `json:"size"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// SaveImageVariant 记录图片变体的 file_id
+func SaveImageVariant(variant ImageVariant) error {
+	_, err := db.Exec(db.Dialect.Upsert("image_variants", []string{"file_id", "variant_key", "variant_file_id", "content_type", "size"}, []string{"file_id", "variant_key"}),
+		variant.FileId, variant.VariantKey, variant.VariantFileId, variant.ContentType, variant.Size)
+	return err
+}
+
+// GetImageVariant 查询图片变体
+func GetImageVariant(fileId, variantKey string) (ImageVariant, error) {
+	var variant ImageVariant
+	err := db.QueryRow("SELECT file_id, variant_key, variant_file_id, content_type, size, created_at FROM image_variants WHERE file_id = ? AND variant_key = ?", fileId, variantKey).
+		Scan(&variant.FileId, &variant.VariantKey, &variant.VariantFileId, &variant.ContentType, &variant.Size, &variant.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ImageVariant{}, fmt.Errorf("image variant not found: %s %s", fileId, variantKey)
+		}
+		return ImageVariant{}, err
+	}
+	return variant, nil
+}
+
+// SavePhotoSizes 保存照片的所有尺寸，fileId 为记录中的主文件（最大尺寸）
+func SavePhotoSizes(fileId string, sizes []utils.PhotoSize) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, size := range sizes {
+		_, err := tx.Exec(db.Dialect.Upsert("photo_sizes", []string{"file_id", "size_file_id", "width", "height", "file_size"}, []string{"file_id", "size_file_id"}),
+			fileId, size.FileId, size.Width, size.Height, size.FileSize)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetPhotoSizes 获取照片的所有尺寸，按从小到大排序
+func GetPhotoSizes(fileId string) ([]utils.PhotoSize, error) {
+	rows, err := db.Query("SELECT size_file_id, width, height, file_size FROM photo_sizes WHERE file_id = ? ORDER BY width * height", fileId)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var records []FileRecord
+	var sizes []utils.PhotoSize
 	for rows.Next() {
-		var record FileRecord
-		var shared int
-		err := rows.Scan(&record.FileId, &record.Filename, &record.Ip, &record.UserFingerprint, &shared, &record.Time)
-		if err != nil {
+		var size utils.PhotoSize
+		if err := rows.Scan(&size.FileId, &size.Width, &size.Height, &size.FileSize); err != nil {
 			return nil, err
 		}
-		record.Shared = shared == 1
-		records = append(records, record)
+		sizes = append(sizes, size)
 	}
+	return sizes, rows.Err()
+}
 
-	if err = rows.Err(); err != nil {
+// HLS 任务状态
+const (
+	HLSPending = "pending"
+	HLSRunning = "running"
+	HLSDone    = "done"
+	HLSFailed  = "failed"
+)
+
+// HLSJob 视频切片任务
+type HLSJob struct {
+	FileId    string    `json:"fileId"`
+	Filename  string    `json:"filename"`
+	Status    string    `json:"status"`
+	Playlist  string    `json:"-"` // ffmpeg 生成的 m3u8，分片名在输出时替换为 /d/ 地址
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CreateHLSJob 创建切片任务，已存在时不重复创建，返回是否新建
+func CreateHLSJob(fileId, filename string) (bool, error) {
+	result, err := db.Exec(db.Dialect.InsertIgnore("hls_jobs", []string{"file_id", "filename", "status"}), fileId, filename, HLSPending)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// UpdateHLSJob 更新切片任务状态
+func UpdateHLSJob(fileId, status, playlist, errMsg string) error {
+	_, err := db.Exec("UPDATE hls_jobs SET status = ?, playlist = ?, error = ?, updated_at = CURRENT_TIMESTAMP WHERE file_id = ?",
+		status, playlist, errMsg, fileId)
+	return err
+}
+
+// GetHLSJob 获取切片任务
+func GetHLSJob(fileId string) (HLSJob, error) {
+	var job HLSJob
+	err := db.QueryRow("SELECT file_id, filename, status, COALESCE(playlist, ''), COALESCE(error, ''), created_at, updated_at FROM hls_jobs WHERE file_id = ?", fileId).
+		Scan(&job.FileId, &job.Filename, &job.Status, &job.Playlist, &job.Error, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return HLSJob{}, fmt.Errorf("hls job not found: %s", fileId)
+		}
+		return HLSJob{}, err
+	}
+	return job, nil
+}
+
+// GetUnfinishedHLSJobs 获取未完成的切片任务，用于重启后恢复
+func GetUnfinishedHLSJobs() ([]HLSJob, error) {
+	rows, err := db.Query("SELECT file_id, filename FROM hls_jobs WHERE status IN (?, ?) ORDER BY created_at", HLSPending, HLSRunning)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return records, nil
+	var jobs []HLSJob
+	for rows.Next() {
+		var job HLSJob
+		if err := rows.Scan(&job.FileId, &job.Filename); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
 }
 
-// GetSharedFiles 获取广场文件（分页）
-func GetSharedFiles(page, pageSize int) ([]FileRecord, error) {
-	offset := (page - 1) * pageSize
-	rows, err := db.Query("SELECT fileId, filename, ip, COALESCE(user_fingerprint, '') as user_fingerprint, shared, time FROM uploaded_files WHERE shared = 1 ORDER BY time DESC LIMIT ? OFFSET ?", pageSize, offset)
+// SaveHLSSegment 记录上传到 Telegram 的分片
+func SaveHLSSegment(fileId, name, segmentFileId string) error {
+	_, err := db.Exec(db.Dialect.Upsert("hls_segments", []string{"file_id", "name", "segment_file_id"}, []string{"file_id", "name"}), fileId, name, segmentFileId)
+	return err
+}
+
+// GetHLSSegments 获取分片名到 Telegram file_id 的映射
+func GetHLSSegments(fileId string) (map[string]string, error) {
+	rows, err := db.Query("SELECT name, segment_file_id FROM hls_segments WHERE file_id = ?", fileId)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var records []FileRecord
+	segments := make(map[string]string)
+	for rows.Next() {
+		var name, segmentFileId string
+		if err := rows.Scan(&name, &segmentFileId); err != nil {
+			return nil, err
+		}
+		segments[name] = segmentFileId
+	}
+	return segments, rows.Err()
+}
+
+// SaveChannelMessage 记录频道中的文件消息，已记录的消息会被更新
+func SaveChannelMessage(file utils.ChannelFile) error {
+	_, err := db.Exec(db.Dialect.Upsert("channel_messages",
+		[]string{"chat_id", "message_id", "file_id", "file_unique_id", "file_name", "file_size", "mime_type", "media_type", "date"},
+		[]string{"chat_id", "message_id"}),
+		file.ChatId, file.MessageId, file.FileId, file.FileUniqueId, file.FileName, file.FileSize, file.MimeType, file.MediaType, file.Date.UTC())
+	return err
+}
+
+// ChannelMessageExists 检查频道消息是否已记录
+func ChannelMessageExists(chatId int64, messageId int) bool {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM channel_messages WHERE chat_id = ? AND message_id = ?", chatId, messageId).Scan(&count)
+	return err == nil && count > 0
+}
+
+// GetChannelMessages 按消息顺序返回所有已记录的频道文件
+func GetChannelMessages() ([]utils.ChannelFile, error) {
+	rows, err := db.Query("SELECT chat_id, message_id, file_id, COALESCE(file_unique_id, ''), COALESCE(file_name, ''), COALESCE(file_size, 0), COALESCE(mime_type, ''), COALESCE(media_type, ''), date FROM channel_messages ORDER BY chat_id, message_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []utils.ChannelFile
 	for rows.Next() {
-		var record FileRecord
-		var shared int
-		err := rows.Scan(&record.FileId, &record.Filename, &record.Ip, &record.UserFingerprint, &shared, &record.Time)
+		var file utils.ChannelFile
+		var date sql.NullTime
+		if err := rows.Scan(&file.ChatId, &file.MessageId, &file.FileId, &file.FileUniqueId, &file.FileName, &file.FileSize, &file.MimeType, &file.MediaType, &date); err != nil {
+			return nil, err
+		}
+		file.Date = date.Time
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+// EachFileRecord 按上传时间依次读取所有文件记录，用于导出时避免一次性加载
+func EachFileRecord(fn func(FileRecord) error) error {
+	rows, err := db.Query("SELECT " + fileRecordColumns + " FROM uploaded_files ORDER BY time")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		record, err := scanFileRecord(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// EachShortLink 依次读取所有短链
+func EachShortLink(fn func(ShortLink) error) error {
+	rows, err := db.Query("SELECT id, short_code, file_id, created_at, access_count FROM short_links ORDER BY created_at")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var link ShortLink
+		if err := rows.Scan(&link.ID, &link.ShortCode, &link.FileId, &link.CreatedAt, &link.AccessCount); err != nil {
+			return err
+		}
+		if err := fn(link); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ImportShortLink 导入短链，短链码已存在时忽略，返回是否新增
+func ImportShortLink(link ShortLink) (bool, error) {
+	createdAt := link.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	result, err := db.Exec(db.Dialect.InsertIgnore("short_links", []string{"short_code", "file_id", "created_at", "access_count"}),
+		link.ShortCode, link.FileId, createdAt.UTC(), link.AccessCount)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ManifestRecord 分片文件的元数据
+type ManifestRecord struct {
+	FileId   string   `json:"fileId"`
+	FileName string   `json:"fileName"`
+	Size     int64    `json:"size"`
+	ChunkIds []string `json:"chunkIds"`
+}
+
+// SaveManifestRecord 保存分片文件的元数据，已存在时忽略，返回是否新增
+func SaveManifestRecord(manifest ManifestRecord) (bool, error) {
+	result, err := db.Exec(db.Dialect.InsertIgnore("blob_manifests", []string{"file_id", "filename", "size", "chunk_ids"}),
+		manifest.FileId, manifest.FileName, manifest.Size, strings.Join(manifest.ChunkIds, "\n"))
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// EachManifestRecord 依次读取所有分片文件的元数据
+func EachManifestRecord(fn func(ManifestRecord) error) error {
+	rows, err := db.Query("SELECT file_id, filename, COALESCE(size, 0), chunk_ids FROM blob_manifests ORDER BY created_at")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var manifest ManifestRecord
+		var chunkIds string
+		if err := rows.Scan(&manifest.FileId, &manifest.FileName, &manifest.Size, &chunkIds); err != nil {
+			return err
+		}
+		manifest.ChunkIds = strings.Fields(chunkIds)
+		if err := fn(manifest); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// APIKey 用户的 API 密钥
+type APIKey struct {
+	Id         int64      `json:"id"`
+	UserId     int64      `json:"userId"`
+	Label      string     `json:"label"`
+	Prefix     string     `json:"prefix"` // 密钥开头几位，便于辨认
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	Quota      Quota      `json:"quota"` // 密钥单独的配额，与账号的配额同时生效
+}
+
+const apiKeyColumns = "id, user_id, label, prefix, scopes, created_at, last_used_at, revoked_at, " +
+	"COALESCE(quota_daily_bytes, 0), COALESCE(quota_daily_files, 0), COALESCE(quota_total_bytes, 0)"
+
+func scanAPIKey(row rowScanner) (APIKey, error) {
+	var key APIKey
+	var scopes string
+	var lastUsed, revoked sql.NullTime
+	if err := row.Scan(&key.Id, &key.UserId, &key.Label, &key.Prefix, &scopes, &key.CreatedAt, &lastUsed, &revoked,
+		&key.Quota.DailyBytes, &key.Quota.DailyFiles, &key.Quota.TotalBytes); err != nil {
+		return APIKey{}, err
+	}
+	key.Scopes = strings.Split(scopes, ",")
+	if lastUsed.Valid {
+		key.LastUsedAt = &lastUsed.Time
+	}
+	if revoked.Valid {
+		key.RevokedAt = &revoked.Time
+	}
+	return key, nil
+}
+
+// CreateAPIKey 保存 API 密钥
+func CreateAPIKey(key APIKey, keyHash string) (APIKey, error) {
+	id, err := db.InsertId("INSERT INTO api_keys (user_id, label, prefix, key_hash, scopes, quota_daily_bytes, quota_daily_files, quota_total_bytes) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		key.UserId, key.Label, key.Prefix, keyHash, strings.Join(key.Scopes, ","), key.Quota.DailyBytes, key.Quota.DailyFiles, key.Quota.TotalBytes)
+	if err != nil {
+		return APIKey{}, err
+	}
+	return GetAPIKeyById(id)
+}
+
+// GetAPIKeyById 按 id 查询 API 密钥
+func GetAPIKeyById(id int64) (APIKey, error) {
+	return scanAPIKey(db.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys WHERE id = ?", id))
+}
+
+// GetAPIKeyByHash 查询未吊销的 API 密钥
+func GetAPIKeyByHash(keyHash string) (APIKey, error) {
+	return scanAPIKey(db.QueryRow("SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = ? AND revoked_at IS NULL", keyHash))
+}
+
+// GetAPIKeysByUser 列出账号的所有 API 密钥（包括已吊销的）
+func GetAPIKeysByUser(userId int64) ([]APIKey, error) {
+	rows, err := db.Query("SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = ? ORDER BY created_at DESC", userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := []APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
 		if err != nil {
 			return nil, err
 		}
-		record.Shared = shared == 1
-		records = append(records, record)
+		keys = append(keys, key)
 	}
+	return keys, rows.Err()
+}
 
-	if err = rows.Err(); err != nil {
+// RevokeAPIKey 吊销账号的 API 密钥，返回是否找到
+func RevokeAPIKey(userId, id int64) (bool, error) {
+	result, err := db.Exec("UPDATE api_keys SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL", time.Now().UTC(), id, userId)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// TouchAPIKey 记录 API 密钥的最后使用时间
+func TouchAPIKey(id int64, at time.Time) error {
+	_, err := db.Exec("UPDATE api_keys SET last_used_at = ? WHERE id = ?", at.UTC().Truncate(time.Second), id)
+	return err
+}
+
+// GetShortLinksByUser 获取账号上传的文件的短链
+func GetShortLinksByUser(userId int64) ([]ShortLink, error) {
+	rows, err := db.Query("SELECT id, short_code, file_id, created_at, access_count FROM short_links WHERE file_id IN (SELECT fileId FROM uploaded_files WHERE user_id = ?) ORDER BY created_at DESC", userId)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return records, nil
+	var shortLinks []ShortLink
+	for rows.Next() {
+		var link ShortLink
+		if err := rows.Scan(&link.ID, &link.ShortCode, &link.FileId, &link.CreatedAt, &link.AccessCount); err != nil {
+			return nil, err
+		}
+		shortLinks = append(shortLinks, link)
+	}
+	return shortLinks, rows.Err()
 }
 
-// GetSharedFilesCount 获取广场文件总数
-func GetSharedFilesCount() (int, error) {
-	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM uploaded_files WHERE shared = 1").Scan(&count)
-	return count, err
+// SelectRecordsByUser 查询账号上传的所有文件
+func SelectRecordsByUser(userId int64) ([]FileRecord, error) {
+	rows, err := db.Query("SELECT "+fileRecordColumns+" FROM uploaded_files WHERE user_id = ? ORDER BY time DESC", userId)
+	if err != nil {
+		return nil, err
+	}
+	return scanFileRecords(rows)
 }
 
-// GetUserFilesCount 获取用户文件总数
-func GetUserFilesCount(userFingerprint string) (int, error) {
-	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM uploaded_files WHERE user_fingerprint = ?", userFingerprint).Scan(&count)
-	return count, err
+// SearchUsers 按用户名搜索账号（分页），同时返回总数
+func SearchUsers(query string, page, pageSize int) ([]User, int, error) {
+	where, args := "", []interface{}{}
+	if query != "" {
+		where, args = " WHERE LOWER(username) LIKE ?", append(args, "%"+strings.ToLower(query)+"%")
+	}
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := db.Query("SELECT "+userColumns+" FROM users"+where+" ORDER BY id LIMIT ? OFFSET ?", append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var users []User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	return users, total, rows.Err()
+}
+
+// Ban 一条封禁，Kind 为 ip、fingerprint 或 user（Value 为账号 id）
+type Ban struct {
+	Id        int64     `json:"id"`
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	Reason    string    `json:"reason"`
+	CreatedBy int64     `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CreateBan 添加封禁，已存在时返回 false
+func CreateBan(ban Ban) (bool, error) {
+	result, err := db.Exec(db.Dialect.InsertIgnore("bans", []string{"kind", "value", "reason", "created_by"}), ban.Kind, ban.Value, ban.Reason, ban.CreatedBy)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetBans 列出所有封禁
+func GetBans() ([]Ban, error) {
+	rows, err := db.Query("SELECT id, kind, value, reason, COALESCE(created_by, 0), created_at FROM bans ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var bans []Ban
+	for rows.Next() {
+		var ban Ban
+		if err := rows.Scan(&ban.Id, &ban.Kind, &ban.Value, &ban.Reason, &ban.CreatedBy, &ban.CreatedAt); err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+	return bans, rows.Err()
+}
+
+// DeleteBan 解除封禁，返回是否找到
+func DeleteBan(id int64) (bool, error) {
+	result, err := db.Exec("DELETE FROM bans WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// IsBanned 判断某个值是否被封禁，空值不会被封禁
+func IsBanned(kind, value string) bool {
+	if value == "" {
+		return false
+	}
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM bans WHERE kind = ? AND value = ?", kind, value).Scan(&n); err != nil {
+		log.Printf("查询封禁失败: %v", err)
+		return false
+	}
+	return n > 0
+}
+
+// Stats 管理员统计
+type Stats struct {
+	Files       int   `json:"files"`
+	TotalSize   int64 `json:"totalSize"`
+	StoredSize  int64 `json:"storedSize"`
+	SharedFiles int   `json:"sharedFiles"`
+	FilesToday  int   `json:"filesLast24h"`
+	ShortLinks  int   `json:"shortLinks"`
+	Users       int   `json:"users"`
+	APIKeys     int   `json:"apiKeys"`
+	Bans        int   `json:"bans"`
+}
+
+// GetStats 统计文件、账号与封禁数量
+func GetStats() (Stats, error) {
+	var stats Stats
+	err := db.QueryRow("SELECT COUNT(*), COALESCE(SUM(size), 0), COALESCE(SUM(stored_size), 0), COALESCE(SUM(CASE WHEN shared = 1 THEN 1 ELSE 0 END), 0) FROM uploaded_files").
+		Scan(&stats.Files, &stats.TotalSize, &stats.StoredSize, &stats.SharedFiles)
+	if err != nil {
+		return stats, err
+	}
+	counts := []struct {
+		query string
+		args  []interface{}
+		dest  *int
+	}{
+		{"SELECT COUNT(*) FROM uploaded_files WHERE time >= ?", []interface{}{time.Now().Add(-24 * time.Hour).UTC()}, &stats.FilesToday},
+		{"SELECT COUNT(*) FROM short_links", nil, &stats.ShortLinks},
+		{"SELECT COUNT(*) FROM users", nil, &stats.Users},
+		{"SELECT COUNT(*) FROM api_keys WHERE revoked_at IS NULL", nil, &stats.APIKeys},
+		{"SELECT COUNT(*) FROM bans", nil, &stats.Bans},
+	}
+	for _, c := range counts {
+		if err := db.QueryRow(c.query, c.args...).Scan(c.dest); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// Quota 上传配额，每项为 0 时不限制。也用于返回已用量
+type Quota struct {
+	DailyBytes int64 `json:"dailyBytes"` // 每天（UTC）上传的字节数
+	DailyFiles int64 `json:"dailyFiles"` // 每天（UTC）上传的文件数
+	TotalBytes int64 `json:"totalBytes"` // 累计上传的字节数
+}
+
+// UploadOwner 上传者，配额分别按 API 密钥、账号与匿名上传的 IP 统计
+type UploadOwner struct {
+	UserId   int64
+	ApiKeyId int64
+	Ip       string
+}
+
+// GetUserQuota 返回管理员为账号单独设置的配额
+func GetUserQuota(userId int64) (Quota, error) {
+	var q Quota
+	err := db.QueryRow("SELECT COALESCE(quota_daily_bytes, 0), COALESCE(quota_daily_files, 0), COALESCE(quota_total_bytes, 0) FROM users WHERE id = ?", userId).
+		Scan(&q.DailyBytes, &q.DailyFiles, &q.TotalBytes)
+	return q, err
+}
+
+// SetUserQuota 为账号单独设置配额，返回是否找到账号
+func SetUserQuota(userId int64, q Quota) (bool, error) {
+	result, err := db.Exec("UPDATE users SET quota_daily_bytes = ?, quota_daily_files = ?, quota_total_bytes = ? WHERE id = ?", q.DailyBytes, q.DailyFiles, q.TotalBytes, userId)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// usageColumns 统计用量时可用的条件，匿名上传的 IP 只统计未登录的记录
+var usageColumns = map[string]string{
+	"api_key_id": "COALESCE(api_key_id, 0) = ?",
+	"user_id":    "COALESCE(user_id, 0) = ?",
+	"ip":         "COALESCE(user_id, 0) = 0 AND ip = ?",
+}
+
+// GetUsage 统计 column 为 value 的上传量：since 之后的字节数与文件数、累计字节数。
+// since 之后保存、尚未合并的分片也计入字节数，excludeUpload 为正在合并的上传 ID，其分片不重复计算
+func GetUsage(column string, value interface{}, since time.Time, excludeUpload string) (Quota, error) {
+	var usage Quota
+	cond, ok := usageColumns[column]
+	if !ok {
+		return usage, fmt.Errorf("unknown usage column %s", column)
+	}
+	since = since.UTC()
+	err := db.QueryRow("SELECT COALESCE(SUM(CASE WHEN time >= ? THEN size ELSE 0 END), 0), COALESCE(SUM(CASE WHEN time >= ? THEN 1 ELSE 0 END), 0), COALESCE(SUM(size), 0) FROM uploaded_files WHERE "+cond,
+		since, since, value).Scan(&usage.DailyBytes, &usage.DailyFiles, &usage.TotalBytes)
+	if err != nil {
+		return usage, err
+	}
+	var pending int64
+	err = db.QueryRow("SELECT COALESCE(SUM(size), 0) FROM chunk_records WHERE "+cond+" AND created_at >= ? AND upload_id <> ?", value, since, excludeUpload).Scan(&pending)
+	if err != nil {
+		return usage, err
+	}
+	usage.DailyBytes += pending
+	usage.TotalBytes += pending
+	return usage, nil
 }
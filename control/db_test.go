@@ -0,0 +1,116 @@
+package control
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// benchFileRows 基准测试数据库中的文件记录数
+const benchFileRows = 1000000
+
+// lookupIndexes 0002_lookup_indexes 添加的索引
+var lookupIndexes = []string{
+	"idx_uploaded_files_file_id",
+	"idx_uploaded_files_filename",
+	"idx_uploaded_files_fingerprint",
+	"idx_uploaded_files_shared_time",
+	"idx_short_links_file_id",
+}
+
+// seedBenchDB 写入 benchFileRows 条文件记录与十分之一数量的短链：文件名每 5 条重复一次，
+// 指纹 1 万个，每 50 条有一条分享到广场
+func seedBenchDB(b *testing.B) {
+	b.Helper()
+	tx, err := db.DB.Begin()
+	if err != nil {
+		b.Fatal(err)
+	}
+	files, err := tx.Prepare("INSERT INTO uploaded_files (fileId, filename, ip, user_fingerprint, shared, time, size) VALUES (?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		b.Fatal(err)
+	}
+	links, err := tx.Prepare("INSERT INTO short_links (short_code, file_id) VALUES (?, ?)")
+	if err != nil {
+		b.Fatal(err)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < benchFileRows; i++ {
+		fileId := fmt.Sprintf("file%07d", i)
+		shared := 0
+		if i%50 == 0 {
+			shared = 1
+		}
+		if _, err := files.Exec(fileId, fmt.Sprintf("name%06d.jpg", i/5), fmt.Sprintf("10.0.%d.%d", i/256%256, i%256),
+			fmt.Sprintf("fp%04d", i%10000), shared, start.Add(time.Duration(i)*time.Second), 1024); err != nil {
+			b.Fatal(err)
+		}
+		if i%10 == 0 {
+			if _, err := links.Exec(fmt.Sprintf("s%07d", i), fileId); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := db.Exec("ANALYZE"); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// benchLookups 对下载、历史记录、广场与短链使用的查询分别计时
+func benchLookups(b *testing.B) {
+	run := func(name string, query func(i int) error) {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := query(i); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+	run("GetFileById", func(i int) error {
+		_, err := GetFileById(fmt.Sprintf("file%07d", i*7919%benchFileRows))
+		return err
+	})
+	run("GetFileNameByIDOrName", func(i int) error {
+		_, err := GetFileNameByIDOrName(fmt.Sprintf("name%06d.jpg", i*7919%(benchFileRows/5)))
+		return err
+	})
+	run("GetFilesByUserFingerprint", func(i int) error {
+		_, err := GetFilesByUserFingerprint(fmt.Sprintf("fp%04d", i%10000), 1, 20)
+		return err
+	})
+	run("GetUserFilesCount", func(i int) error {
+		_, err := GetUserFilesCount(fmt.Sprintf("fp%04d", i%10000))
+		return err
+	})
+	run("GetSharedFiles", func(i int) error {
+		_, err := GetSharedFiles(i%10+1, 20)
+		return err
+	})
+	run("GetSharedFilesCount", func(i int) error {
+		_, err := GetSharedFilesCount()
+		return err
+	})
+	run("GetShortCodeByFileId", func(i int) error {
+		_, err := GetShortCodeByFileId(fmt.Sprintf("file%07d", i*7919%(benchFileRows/10)*10))
+		return err
+	})
+}
+
+// BenchmarkLookupIndexes 在 100 万条记录的 SQLite 数据库上对比 0002_lookup_indexes 的索引删除前后的查询耗时：
+//
+//	go test ./control -run '^$' -bench LookupIndexes -benchtime 200x
+func BenchmarkLookupIndexes(b *testing.B) {
+	openTestDB(b)
+	seedBenchDB(b)
+	b.Run("indexed", benchLookups)
+	for _, index := range lookupIndexes {
+		if _, err := db.Exec("DROP INDEX " + index); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.Run("unindexed", benchLookups)
+}
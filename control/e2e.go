@@ -0,0 +1,123 @@
+package control
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"csz.net/tgstate/assets"
+	"csz.net/tgstate/conf"
+)
+
+// E2EMeta 端到端加密文件的解密参数
+// 加密在浏览器中完成，密钥只存在于链接的 # 片段中，服务端只保存这些公开参数，无法解密文件内容
+type E2EMeta struct {
+	Version   int    `json:"v"`
+	Cipher    string `json:"cipher"`              // 加密算法，目前仅支持 AES-GCM
+	KeySize   int    `json:"keySize"`             // 密钥长度(bit)：128 或 256
+	Nonce     string `json:"nonce"`               // base64url 编码的 12 字节 IV
+	TagLength int    `json:"tagLength"`           // 认证标签长度(bit)，默认 128
+	ChunkSize int64  `json:"chunkSize,omitempty"` // 分段加密时每段明文的长度，0 表示整体加密
+}
+
+const (
+	e2eVersion    = 1
+	e2eCipher     = "AES-GCM"
+	e2eNonceSize  = 12
+	e2eDefaultTag = 128
+)
+
+// parseE2EMeta 解析并校验客户端提交的加密参数
+func parseE2EMeta(raw string) (*E2EMeta, error) {
+	if raw == "" {
+		return nil, fmt.Errorf("missing e2e metadata")
+	}
+	var meta E2EMeta
+	if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+		return nil, fmt.Errorf("invalid e2e metadata: %v", err)
+	}
+	if err := meta.validate(); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// validate 校验加密参数，并补全默认值
+func (m *E2EMeta) validate() error {
+	if m.Version == 0 {
+		m.Version = e2eVersion
+	}
+	if m.Version != e2eVersion {
+		return fmt.Errorf("unsupported e2e version %d", m.Version)
+	}
+	if m.Cipher == "" {
+		m.Cipher = e2eCipher
+	}
+	if !strings.EqualFold(m.Cipher, e2eCipher) {
+		return fmt.Errorf("unsupported e2e cipher %s", m.Cipher)
+	}
+	m.Cipher = e2eCipher
+	if m.KeySize != 128 && m.KeySize != 256 {
+		return fmt.Errorf("unsupported e2e key size %d", m.KeySize)
+	}
+	if m.TagLength == 0 {
+		m.TagLength = e2eDefaultTag
+	}
+	if m.TagLength < 96 || m.TagLength > 128 || m.TagLength%8 != 0 {
+		return fmt.Errorf("unsupported e2e tag length %d", m.TagLength)
+	}
+	nonce, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(m.Nonce, "="))
+	if err != nil || len(nonce) != e2eNonceSize {
+		return fmt.Errorf("e2e nonce must be %d bytes base64url", e2eNonceSize)
+	}
+	if m.ChunkSize < 0 {
+		return fmt.Errorf("invalid e2e chunk size %d", m.ChunkSize)
+	}
+	return nil
+}
+
+// e2eUploadMeta 读取上传表单中的端到端加密标记，未开启时返回 nil
+func e2eUploadMeta(r *http.Request) (*E2EMeta, error) {
+	if r.FormValue("e2e") != "true" {
+		return nil, nil
+	}
+	return parseE2EMeta(r.FormValue("e2eMeta"))
+}
+
+// serveE2EViewer 输出端到端加密文件的解密页面，页面通过 ?raw=1 获取密文并在浏览器中解密
+func serveE2EViewer(w http.ResponseWriter, record FileRecord) {
+	file, err := assets.Templates.ReadFile("templates/e2e.tmpl")
+	if err != nil {
+		http.Error(w, "HTML file not found", http.StatusNotFound)
+		return
+	}
+	tmpl, err := template.New("e2e").Parse(string(file))
+	if err != nil {
+		http.Error(w, "Error parsing HTML template", http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Name        string
+		ContentType string
+		RawUrl      string
+		Meta        *E2EMeta
+	}{
+		Name:        record.Filename,
+		ContentType: getContentTypeFromExtension(record.Filename),
+		RawUrl:      conf.FileRoute + record.FileId + "?raw=1",
+		Meta:        record.Encryption,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; connect-src 'self'; img-src blob:; media-src blob:; frame-ancestors 'none'")
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Error rendering HTML template", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,349 @@
+package control
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"csz.net/tgstate/conf"
+	"csz.net/tgstate/utils"
+)
+
+// 导出与导入：NDJSON 每行一条记录，包含所有表；CSV 每次只包含一张表，导入时按表头识别。
+// 导入是幂等的，已存在的文件（按 file_id）、短链（按短链码）与分片元数据会被跳过
+
+const (
+	exportFiles      = "files"
+	exportShortLinks = "short_links"
+	exportManifests  = "manifests"
+)
+
+// exportLine NDJSON 中的一行
+type exportLine struct {
+	Type      string          `json:"type"`
+	File      *FileRecord     `json:"file,omitempty"`
+	ShortLink *ShortLink      `json:"shortLink,omitempty"`
+	Manifest  *ManifestRecord `json:"manifest,omitempty"`
+}
+
+// csvColumns 各表导出为 CSV 时的表头
+var csvColumns = map[string][]string{
+	exportFiles:      {"fileId", "filename", "ip", "userFingerprint", "shared", "time", "encryption", "encoding", "size", "storedSize", "duration", "width", "height", "thumbFileId", "performer", "title"},
+	exportShortLinks: {"shortCode", "fileId", "createdAt", "accessCount"},
+	exportManifests:  {"fileId", "fileName", "size", "chunkIds"},
+}
+
+// ImportResult 导入统计
+type ImportResult struct {
+	Files      int `json:"files"`
+	ShortLinks int `json:"shortLinks"`
+	Manifests  int `json:"manifests"`
+	Skipped    int `json:"skipped"` // 已存在的记录
+}
+
+// ExportRecords 把元数据写入 w，format 为 ndjson 或 csv；table 为空时 NDJSON 导出所有表，CSV 必须指定表
+func ExportRecords(w io.Writer, format, table string) error {
+	switch format {
+	case "", "ndjson":
+		return exportNDJSON(w, table)
+	case "csv":
+		return exportCSV(w, table)
+	}
+	return fmt.Errorf("unsupported format %s, use ndjson or csv", format)
+}
+
+func exportNDJSON(w io.Writer, table string) error {
+	if table != "" && csvColumns[table] == nil {
+		return fmt.Errorf("unknown table %s", table)
+	}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	if table == "" || table == exportFiles {
+		if err := EachFileRecord(func(record FileRecord) error {
+			return enc.Encode(exportLine{Type: exportFiles, File: &record})
+		}); err != nil {
+			return err
+		}
+	}
+	if table == "" || table == exportShortLinks {
+		if err := EachShortLink(func(link ShortLink) error {
+			return enc.Encode(exportLine{Type: exportShortLinks, ShortLink: &link})
+		}); err != nil {
+			return err
+		}
+	}
+	if table == "" || table == exportManifests {
+		if err := EachManifestRecord(func(manifest ManifestRecord) error {
+			return enc.Encode(exportLine{Type: exportManifests, Manifest: &manifest})
+		}); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func exportCSV(w io.Writer, table string) error {
+	columns, ok := csvColumns[table]
+	if !ok {
+		return fmt.Errorf("csv export needs table=files, short_links or manifests")
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	var err error
+	switch table {
+	case exportFiles:
+		err = EachFileRecord(func(record FileRecord) error {
+			var encryption string
+			if record.Encryption != nil {
+				data, err := json.Marshal(record.Encryption)
+				if err != nil {
+					return err
+				}
+				encryption = string(data)
+			}
+			var media utils.MediaMeta
+			if record.Media != nil {
+				media = *record.Media
+			}
+			return cw.Write([]string{record.FileId, record.Filename, record.Ip, record.UserFingerprint, strconv.FormatBool(record.Shared),
+				record.Time.UTC().Format(time.RFC3339), encryption, record.Encoding,
+				strconv.FormatInt(record.Size, 10), strconv.FormatInt(record.StoredSize, 10),
+				strconv.Itoa(media.Duration), strconv.Itoa(media.Width), strconv.Itoa(media.Height), media.ThumbFileId, media.Performer, media.Title})
+		})
+	case exportShortLinks:
+		err = EachShortLink(func(link ShortLink) error {
+			return cw.Write([]string{link.ShortCode, link.FileId, link.CreatedAt.UTC().Format(time.RFC3339), strconv.Itoa(link.AccessCount)})
+		})
+	case exportManifests:
+		err = EachManifestRecord(func(manifest ManifestRecord) error {
+			return cw.Write([]string{manifest.FileId, manifest.FileName, strconv.FormatInt(manifest.Size, 10), strings.Join(manifest.ChunkIds, " ")})
+		})
+	}
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ImportRecords 从 r 导入元数据，format 为 ndjson 或 csv
+func ImportRecords(r io.Reader, format string) (ImportResult, error) {
+	switch format {
+	case "", "ndjson":
+		return importNDJSON(r)
+	case "csv":
+		return importCSV(r)
+	}
+	return ImportResult{}, fmt.Errorf("unsupported format %s, use ndjson or csv", format)
+}
+
+func importNDJSON(r io.Reader) (ImportResult, error) {
+	var result ImportResult
+	dec := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var item exportLine
+		if err := dec.Decode(&item); err == io.EOF {
+			return result, nil
+		} else if err != nil {
+			return result, fmt.Errorf("line %d: %v", line, err)
+		}
+		var err error
+		switch {
+		case item.Type == exportFiles && item.File != nil:
+			err = result.importFile(*item.File)
+		case item.Type == exportShortLinks && item.ShortLink != nil:
+			err = result.importShortLink(*item.ShortLink)
+		case item.Type == exportManifests && item.Manifest != nil:
+			err = result.importManifest(*item.Manifest)
+		default:
+			err = fmt.Errorf("unknown record type %q", item.Type)
+		}
+		if err != nil {
+			return result, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+}
+
+func importCSV(r io.Reader) (ImportResult, error) {
+	var result ImportResult
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return result, err
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	// 按表头识别是哪张表
+	table := ""
+	for name, columns := range csvColumns {
+		matched := true
+		for _, column := range columns {
+			if _, ok := index[column]; !ok {
+				matched = false
+				break
+			}
+		}
+		if matched && (table == "" || len(columns) > len(csvColumns[table])) {
+			table = name
+		}
+	}
+	if table == "" {
+		return result, fmt.Errorf("unrecognized csv header")
+	}
+
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return result, nil
+		} else if err != nil {
+			return result, err
+		}
+		get := func(column string) string { return row[index[column]] }
+		getInt := func(column string) int64 {
+			n, _ := strconv.ParseInt(get(column), 10, 64)
+			return n
+		}
+		switch table {
+		case exportFiles:
+			record := FileRecord{FileId: get("fileId"), Filename: get("filename"), Ip: get("ip"), UserFingerprint: get("userFingerprint"),
+				Encoding: get("encoding"), Size: getInt("size"), StoredSize: getInt("storedSize")}
+			record.Shared, _ = strconv.ParseBool(get("shared"))
+			record.Time, _ = time.Parse(time.RFC3339, get("time"))
+			if encryption := get("encryption"); encryption != "" {
+				record.Encryption = &E2EMeta{}
+				if err = json.Unmarshal([]byte(encryption), record.Encryption); err != nil {
+					break
+				}
+			}
+			media := utils.MediaMeta{Duration: int(getInt("duration")), Width: int(getInt("width")), Height: int(getInt("height")),
+				ThumbFileId: get("thumbFileId"), Performer: get("performer"), Title: get("title")}
+			record.Media = mediaOrNil(media)
+			err = result.importFile(record)
+		case exportShortLinks:
+			link := ShortLink{ShortCode: get("shortCode"), FileId: get("fileId"), AccessCount: int(getInt("accessCount"))}
+			link.CreatedAt, _ = time.Parse(time.RFC3339, get("createdAt"))
+			err = result.importShortLink(link)
+		case exportManifests:
+			err = result.importManifest(ManifestRecord{FileId: get("fileId"), FileName: get("fileName"), Size: getInt("size"), ChunkIds: strings.Fields(get("chunkIds"))})
+		}
+		if err != nil {
+			return result, fmt.Errorf("line %d: %v", line, err)
+		}
+	}
+}
+
+func (result *ImportResult) importFile(record FileRecord) error {
+	if record.FileId == "" || record.Filename == "" {
+		return fmt.Errorf("missing fileId or filename")
+	}
+	if _, err := GetFileById(record.FileId); err == nil {
+		result.Skipped++
+		return nil
+	}
+	// 账号与 API 密钥不在导出范围内，另一个实例中的同一 id 可能是其他人
+	record.UserId, record.ApiKeyId = 0, 0
+	if err := SaveFileRecord(record); err != nil {
+		return err
+	}
+	result.Files++
+	return nil
+}
+
+func (result *ImportResult) importShortLink(link ShortLink) error {
+	if link.ShortCode == "" || link.FileId == "" {
+		return fmt.Errorf("missing shortCode or fileId")
+	}
+	added, err := ImportShortLink(link)
+	if err != nil {
+		return err
+	}
+	if added {
+		result.ShortLinks++
+	} else {
+		result.Skipped++
+	}
+	return nil
+}
+
+func (result *ImportResult) importManifest(manifest ManifestRecord) error {
+	if manifest.FileId == "" || len(manifest.ChunkIds) == 0 {
+		return fmt.Errorf("missing fileId or chunkIds")
+	}
+	added, err := SaveManifestRecord(manifest)
+	if err != nil {
+		return err
+	}
+	if added {
+		result.Manifests++
+	} else {
+		result.Skipped++
+	}
+	return nil
+}
+
+// ExportAPI 导出元数据：GET /api/export?format=ndjson|csv&table=files|short_links|manifests
+func ExportAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	table := r.URL.Query().Get("table")
+	if format == "csv" && table == "" {
+		table = exportFiles
+	}
+	ext, contentType := "ndjson", "application/x-ndjson"
+	if format == "csv" {
+		ext, contentType = "csv", "text/csv; charset=utf-8"
+	} else if format != "" && format != "ndjson" {
+		errJsonMsg("Unsupported format, use ndjson or csv", w)
+		return
+	}
+	if table != "" && csvColumns[table] == nil {
+		errJsonMsg("Unknown table, use files, short_links or manifests", w)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="tgstate-export-%s.%s"`, time.Now().Format("20060102-150405"), ext))
+	if err := ExportRecords(w, format, table); err != nil {
+		// 已经开始输出，无法再修改状态码
+		log.Printf("导出失败: %v", err)
+	}
+}
+
+// ImportAPI 导入元数据：POST /api/import，请求体为 ExportAPI 导出的 NDJSON 或 CSV
+func ImportAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := requireAdmin(w, r); !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Content-Type"), "csv") {
+		format = "csv"
+	}
+	result, err := ImportRecords(r.Body, format)
+	response := conf.ResponseResult{Code: 0, Message: "ok", Data: result}
+	if err != nil {
+		// 出错前已导入的记录会保留，重新导入时会被跳过
+		response.Code, response.Message = 1, err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
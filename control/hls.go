@@ -0,0 +1,286 @@
+package control
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"csz.net/tgstate/conf"
+	"csz.net/tgstate/utils"
+)
+
+const (
+	// hlsRoute HLS 播放列表路由：/hls/{id}/index.m3u8
+	hlsRoute = "/hls/"
+	// hlsSegmentSeconds 每个分片的目标时长
+	hlsSegmentSeconds = 6
+	// maxHLSSegment getFile 只能下载 20MB 以内的文件，分片不能超过这个大小
+	maxHLSSegment = 20 * 1024 * 1024
+	// hlsTimeout 单个视频的最长处理时间
+	hlsTimeout = 2 * time.Hour
+)
+
+// hlsExts 会生成 HLS 的视频格式
+var hlsExts = map[string]bool{".mp4": true, ".mov": true, ".mkv": true, ".m4v": true, ".webm": true, ".avi": true, ".flv": true}
+
+// hlsQueue 待处理的切片任务，由单个 worker 依次处理
+var hlsQueue = make(chan HLSJob, 256)
+
+// StartHLSWorker 检查 ffmpeg 是否可用，恢复未完成的任务并启动后台 worker
+func StartHLSWorker() {
+	if conf.FFmpeg == "" {
+		return
+	}
+	path, err := exec.LookPath(conf.FFmpeg)
+	if err != nil {
+		log.Printf("未找到 ffmpeg(%s)，HLS 切片已禁用: %v", conf.FFmpeg, err)
+		conf.FFmpeg = ""
+		return
+	}
+	conf.FFmpeg = path
+
+	go func() {
+		for job := range hlsQueue {
+			processHLS(job)
+		}
+	}()
+
+	jobs, err := GetUnfinishedHLSJobs()
+	if err != nil {
+		log.Printf("读取未完成的 HLS 任务失败: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		enqueueHLS(job)
+	}
+}
+
+// queueHLS 为新上传的视频创建切片任务
+func queueHLS(record FileRecord) {
+	if conf.FFmpeg == "" || record.FileId == "" || record.Encryption != nil || record.Encoding != "" ||
+		!hlsExts[strings.ToLower(filepath.Ext(record.Filename))] {
+		return
+	}
+	created, err := CreateHLSJob(record.FileId, record.Filename)
+	if err != nil {
+		log.Printf("创建 HLS 任务失败: %v", err)
+		return
+	}
+	if created {
+		enqueueHLS(HLSJob{FileId: record.FileId, Filename: record.Filename})
+	}
+}
+
+// enqueueHLS 队列已满时任务保持 pending 状态，下次启动时恢复
+func enqueueHLS(job HLSJob) {
+	select {
+	case hlsQueue <- job:
+	default:
+		log.Printf("HLS 队列已满，稍后处理: %s", job.FileId)
+	}
+}
+
+// processHLS 下载原视频，用 ffmpeg 切片并把分片上传到 Telegram
+func processHLS(job HLSJob) {
+	log.Printf("开始生成 HLS: %s (%s)", job.Filename, job.FileId)
+	UpdateHLSJob(job.FileId, HLSRunning, "", "")
+	playlist, err := buildHLS(job)
+	if err != nil {
+		log.Printf("生成 HLS 失败 %s: %v", job.FileId, err)
+		UpdateHLSJob(job.FileId, HLSFailed, "", err.Error())
+		return
+	}
+	UpdateHLSJob(job.FileId, HLSDone, playlist, "")
+	log.Printf("HLS 生成完成: %s", job.FileId)
+}
+
+func buildHLS(job HLSJob) (string, error) {
+	dir, err := os.MkdirTemp("", "tgstate-hls-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "source"+strings.ToLower(filepath.Ext(job.Filename)))
+	if err := downloadTo(job.FileId, src); err != nil {
+		return "", fmt.Errorf("download: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hlsTimeout)
+	defer cancel()
+	out := filepath.Join(dir, "out")
+	// 优先直接封装，编码不兼容或分片过大时转码
+	if err := runFFmpeg(ctx, src, out, false); err != nil || !segmentsFit(out) {
+		if err != nil {
+			log.Printf("HLS 直接封装失败，改为转码: %v", err)
+		}
+		if err := runFFmpeg(ctx, src, out, true); err != nil {
+			return "", err
+		}
+		if !segmentsFit(out) {
+			return "", fmt.Errorf("segments exceed %d bytes", maxHLSSegment)
+		}
+	}
+
+	playlist, err := os.ReadFile(filepath.Join(out, "index.m3u8"))
+	if err != nil {
+		return "", err
+	}
+	base := strings.TrimSuffix(job.Filename, filepath.Ext(job.Filename))
+	for _, name := range playlistSegments(string(playlist)) {
+		segmentFileId, err := uploadSegment(filepath.Join(out, name), base+"."+name)
+		if err != nil {
+			return "", fmt.Errorf("upload %s: %v", name, err)
+		}
+		if err := SaveHLSSegment(job.FileId, name, segmentFileId); err != nil {
+			return "", err
+		}
+	}
+	return string(playlist), nil
+}
+
+// downloadTo 把 Telegram 中的文件（包括分块文件）保存到本地
+func downloadTo(fileId, path string) error {
+	body, err := utils.OpenFile(fileId)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// runFFmpeg 生成 VOD 播放列表，transcode 为 true 时转码为 H.264/AAC 并限制码率
+func runFFmpeg(ctx context.Context, src, out string, transcode bool) error {
+	os.RemoveAll(out)
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return err
+	}
+	args := []string{"-hide_banner", "-loglevel", "error", "-y", "-i", src, "-map", "0:v:0", "-map", "0:a:0?"}
+	if transcode {
+		args = append(args,
+			"-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-maxrate", "8M", "-bufsize", "16M",
+			"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", hlsSegmentSeconds),
+			"-c:a", "aac", "-b:a", "128k")
+	} else {
+		args = append(args, "-c", "copy")
+	}
+	args = append(args,
+		"-f", "hls", "-hls_time", strconv.Itoa(hlsSegmentSeconds), "-hls_playlist_type", "vod",
+		"-hls_segment_filename", filepath.Join(out, "seg%05d.ts"), filepath.Join(out, "index.m3u8"))
+
+	output, err := exec.CommandContext(ctx, conf.FFmpeg, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// segmentsFit 检查所有分片是否都能通过 getFile 下载
+func segmentsFit(out string) bool {
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || info.Size() > maxHLSSegment {
+			return false
+		}
+	}
+	return true
+}
+
+// playlistSegments 返回播放列表中的分片文件名
+func playlistSegments(playlist string) []string {
+	var names []string
+	scanner := bufio.NewScanner(strings.NewReader(playlist))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			names = append(names, line)
+		}
+	}
+	return names
+}
+
+// uploadSegment 上传单个分片，失败时重试
+func uploadSegment(path, name string) (string, error) {
+	for retry := 0; retry < 3; retry++ {
+		if retry > 0 {
+			time.Sleep(5 * time.Second)
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		fileId := utils.UpDocument(utils.TgFileData(name, f))
+		f.Close()
+		if fileId != "" {
+			return fileId, nil
+		}
+	}
+	return "", fmt.Errorf("telegram upload failed")
+}
+
+// HLS 输出播放列表，分片地址改写为 /d/ 代理地址
+func HLS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, hlsRoute), "/index.m3u8")
+	if !ok || id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if record, err := resolveFile(id); err == nil && record.FileId != "" {
+		id = record.FileId
+	}
+	job, err := GetHLSJob(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if job.Status != HLSDone {
+		http.Error(w, "HLS "+job.Status, http.StatusNotFound)
+		return
+	}
+	segments, err := GetHLSSegments(id)
+	if err != nil {
+		http.Error(w, "Failed to load segments", http.StatusInternalServerError)
+		return
+	}
+
+	var b strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(job.Playlist))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name := strings.TrimSpace(line); name != "" && !strings.HasPrefix(name, "#") {
+			segmentFileId, ok := segments[name]
+			if !ok {
+				http.Error(w, "Missing segment "+name, http.StatusInternalServerError)
+				return
+			}
+			line = conf.FileRoute + segmentFileId
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	io.WriteString(w, b.String())
+}
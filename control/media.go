@@ -0,0 +1,40 @@
+package control
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"csz.net/tgstate/utils"
+)
+
+// thumbRoute 缩略图路由：/thumb/{id}，不放在 /d/{id}/ 下，避免与带文件名的规范地址冲突
+const thumbRoute = "/thumb/"
+
+// mediaOrNil 没有媒体信息时不写入记录
+func mediaOrNil(media utils.MediaMeta) *utils.MediaMeta {
+	if media.IsZero() {
+		return nil
+	}
+	return &media
+}
+
+// Thumb 输出 Telegram 为视频/音频生成的缩略图：GET /thumb/{id}
+func Thumb(w http.ResponseWriter, r *http.Request) {
+	record, err := GetFileById(strings.TrimPrefix(r.URL.Path, thumbRoute))
+	if err != nil || record.Media == nil || record.Media.ThumbFileId == "" {
+		http.Error(w, "Thumbnail not found", http.StatusNotFound)
+		return
+	}
+	data, err := readTelegramFile(record.Media.ThumbFileId)
+	if err != nil {
+		log.Printf("获取缩略图失败 %s: %v", record.FileId, err)
+		http.Error(w, "Failed to fetch thumbnail", http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Write(data)
+}
@@ -0,0 +1,243 @@
+package control
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 数据库迁移：migrations/{方言} 目录下的 NNNN_name.sql 按版本号顺序执行，每个迁移在独立事务中完成，
+// 已执行的版本记录在 schema_version 表中。迁移只能追加，已发布的文件不能修改，
+// 各方言的版本号必须一一对应。MySQL 的 DDL 会隐式提交，失败时需要人工检查
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration 一个版本的迁移
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// MigrationState 迁移及其执行状态
+type MigrationState struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// legacyColumns 迁移框架之前通过 ALTER TABLE 追加的字段，旧数据库升级到版本 1 时补齐
+var legacyColumns = map[string][]string{
+	"uploaded_files": {
+		"user_fingerprint TEXT",
+		"shared INTEGER DEFAULT 0",
+		"encryption TEXT",
+		"encoding TEXT",
+		"size INTEGER DEFAULT 0",
+		"stored_size INTEGER DEFAULT 0",
+		"duration INTEGER DEFAULT 0",
+		"width INTEGER DEFAULT 0",
+		"height INTEGER DEFAULT 0",
+		"thumb_file_id TEXT",
+		"performer TEXT",
+		"title TEXT",
+	},
+	"chunk_records": {
+		"user_fingerprint TEXT",
+	},
+}
+
+// loadMigrations 读取指定方言的内置迁移文件，版本号必须从 1 开始连续
+func loadMigrations(dialect Dialect) ([]Migration, error) {
+	dir := path.Join("migrations", string(dialect))
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, rest, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+		data, err := migrationFiles.ReadFile(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: rest, SQL: string(data)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be contiguous, expected %d got %d (%s)", i+1, m.Version, m.Name)
+		}
+	}
+	return migrations, nil
+}
+
+// ensureSchemaVersionTable 创建版本记录表
+func ensureSchemaVersionTable(conn *DB) error {
+	_, err := conn.Exec(`CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`)
+	return err
+}
+
+// MigrationStatus 返回所有迁移及其是否已执行
+func MigrationStatus(conn *DB) ([]MigrationState, error) {
+	migrations, err := loadMigrations(conn.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureSchemaVersionTable(conn); err != nil {
+		return nil, err
+	}
+	rows, err := conn.Query("SELECT version, applied_at FROM schema_version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, m := range migrations {
+		at, ok := applied[m.Version]
+		states = append(states, MigrationState{Migration: m, Applied: ok, AppliedAt: at})
+		delete(applied, m.Version)
+	}
+	for version := range applied {
+		return nil, fmt.Errorf("database is at version %d which this binary does not know, refusing to continue", version)
+	}
+	return states, nil
+}
+
+// ApplyMigrations 按顺序执行未执行的迁移，dryRun 时只返回将要执行的迁移
+func ApplyMigrations(conn *DB, dryRun bool) ([]Migration, error) {
+	states, err := MigrationStatus(conn)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, state := range states {
+		if !state.Applied {
+			pending = append(pending, state.Migration)
+		}
+	}
+	if dryRun {
+		return pending, nil
+	}
+	for _, m := range pending {
+		if err := applyMigration(conn, m); err != nil {
+			return nil, fmt.Errorf("migration %04d_%s: %v", m.Version, m.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+// applyMigration 在事务中执行一个迁移并记录版本
+func applyMigration(conn *DB, m Migration) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if m.Version == 1 && conn.Dialect == DialectSQLite {
+		if err := upgradeLegacySchema(tx); err != nil {
+			return err
+		}
+	}
+	for _, statement := range splitStatements(m.SQL) {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec("INSERT INTO schema_version (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// upgradeLegacySchema 为迁移框架之前创建的数据库补齐缺少的字段，新数据库中这些表还不存在，直接跳过
+func upgradeLegacySchema(tx *Tx) error {
+	for table, columns := range legacyColumns {
+		existing, err := tableColumns(tx, table)
+		if err != nil {
+			return err
+		}
+		if len(existing) == 0 {
+			continue
+		}
+		for _, column := range columns {
+			name, _, _ := strings.Cut(column, " ")
+			if existing[name] {
+				continue
+			}
+			if _, err := tx.Exec("ALTER TABLE " + table + " ADD COLUMN " + column); err != nil {
+				return fmt.Errorf("add column %s.%s: %v", table, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// tableColumns 返回表中已有的字段名，表不存在时返回空
+func tableColumns(tx *Tx, table string) (map[string]bool, error) {
+	rows, err := tx.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// splitStatements 按行尾的分号拆分迁移文件，部分驱动不支持一次执行多条语句
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
@@ -0,0 +1,83 @@
+-- 初始表结构（MySQL），参与索引或唯一约束的字段使用 VARCHAR
+
+CREATE TABLE IF NOT EXISTS uploaded_files (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	fileId VARCHAR(255) NOT NULL,
+	filename TEXT NOT NULL,
+	ip TEXT NOT NULL,
+	user_fingerprint VARCHAR(255),
+	shared INTEGER DEFAULT 0,
+	time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	encryption TEXT,
+	encoding TEXT,
+	size BIGINT DEFAULT 0,
+	stored_size BIGINT DEFAULT 0,
+	duration INTEGER DEFAULT 0,
+	width INTEGER DEFAULT 0,
+	height INTEGER DEFAULT 0,
+	thumb_file_id TEXT,
+	performer TEXT,
+	title TEXT
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS short_links (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	short_code VARCHAR(255) UNIQUE NOT NULL,
+	file_id VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	access_count INTEGER DEFAULT 0
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS chunk_records (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	upload_id VARCHAR(255) NOT NULL,
+	chunk_index INTEGER NOT NULL,
+	chunk_id TEXT NOT NULL,
+	file_name TEXT NOT NULL,
+	ip TEXT NOT NULL,
+	user_fingerprint VARCHAR(255),
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(upload_id, chunk_index)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 上传回 Telegram 的图片变体
+CREATE TABLE IF NOT EXISTS image_variants (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	file_id VARCHAR(255) NOT NULL,
+	variant_key VARCHAR(255) NOT NULL,
+	variant_file_id TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	size BIGINT DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(file_id, variant_key)
+) DEFAULT CHARSET=utf8mb4;
+
+-- sendPhoto 生成的各个尺寸
+CREATE TABLE IF NOT EXISTS photo_sizes (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	file_id VARCHAR(255) NOT NULL,
+	size_file_id VARCHAR(255) NOT NULL,
+	width INTEGER DEFAULT 0,
+	height INTEGER DEFAULT 0,
+	file_size BIGINT DEFAULT 0,
+	UNIQUE(file_id, size_file_id)
+) DEFAULT CHARSET=utf8mb4;
+
+-- HLS 切片任务与分片
+CREATE TABLE IF NOT EXISTS hls_jobs (
+	file_id VARCHAR(255) PRIMARY KEY,
+	filename TEXT NOT NULL,
+	status TEXT NOT NULL,
+	playlist TEXT,
+	error TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS hls_segments (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	file_id VARCHAR(255) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	segment_file_id TEXT NOT NULL,
+	UNIQUE(file_id, name)
+) DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,7 @@
+-- 下载、历史记录、广场与短链查询使用的索引，filename 为 TEXT，只索引前缀
+
+CREATE INDEX idx_uploaded_files_file_id ON uploaded_files (fileId);
+CREATE INDEX idx_uploaded_files_filename ON uploaded_files (filename(191), time);
+CREATE INDEX idx_uploaded_files_fingerprint ON uploaded_files (user_fingerprint, time);
+CREATE INDEX idx_uploaded_files_shared_time ON uploaded_files (shared, time);
+CREATE INDEX idx_short_links_file_id ON short_links (file_id);
@@ -0,0 +1,16 @@
+-- 频道中的文件消息，数据库丢失后用于重建 uploaded_files
+
+CREATE TABLE IF NOT EXISTS channel_messages (
+	chat_id BIGINT NOT NULL,
+	message_id BIGINT NOT NULL,
+	file_id VARCHAR(255) NOT NULL,
+	file_unique_id VARCHAR(255),
+	file_name TEXT,
+	file_size BIGINT DEFAULT 0,
+	mime_type VARCHAR(255),
+	media_type VARCHAR(32),
+	date TIMESTAMP NULL,
+	PRIMARY KEY (chat_id, message_id)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE INDEX idx_channel_messages_unique_id ON channel_messages (file_unique_id);
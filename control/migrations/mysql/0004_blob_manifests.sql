@@ -0,0 +1,9 @@
+-- 分片文件的元数据，与频道中 tgstate-blob 文件的内容一致，便于导出与迁移
+
+CREATE TABLE IF NOT EXISTS blob_manifests (
+	file_id VARCHAR(255) PRIMARY KEY,
+	filename TEXT NOT NULL,
+	size BIGINT DEFAULT 0,
+	chunk_ids MEDIUMTEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) DEFAULT CHARSET=utf8mb4;
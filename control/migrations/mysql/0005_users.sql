@@ -0,0 +1,22 @@
+-- 用户账号与登录会话，上传的文件关联到用户
+
+CREATE TABLE IF NOT EXISTS users (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	username VARCHAR(255) UNIQUE NOT NULL,
+	password_hash VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS sessions (
+	token_hash VARCHAR(64) PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	csrf_token VARCHAR(64) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	expires_at DATETIME NOT NULL
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE INDEX idx_sessions_user_id ON sessions (user_id);
+
+ALTER TABLE uploaded_files ADD COLUMN user_id BIGINT DEFAULT 0;
+
+CREATE INDEX idx_uploaded_files_user_id ON uploaded_files (user_id, time);
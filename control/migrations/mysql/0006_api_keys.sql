@@ -0,0 +1,15 @@
+-- 用户的 API 密钥，只保存哈希
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	label VARCHAR(255) NOT NULL,
+	prefix VARCHAR(32) NOT NULL,
+	key_hash VARCHAR(64) UNIQUE NOT NULL,
+	scopes VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	last_used_at DATETIME NULL,
+	revoked_at DATETIME NULL
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
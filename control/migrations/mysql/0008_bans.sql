@@ -0,0 +1,13 @@
+-- 管理员封禁的 IP、浏览器指纹与账号
+
+CREATE TABLE IF NOT EXISTS bans (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	kind VARCHAR(16) NOT NULL,
+	value VARCHAR(255) NOT NULL,
+	reason VARCHAR(255) NOT NULL DEFAULT '',
+	created_by BIGINT DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (kind, value)
+) DEFAULT CHARSET=utf8mb4;
+
+CREATE INDEX idx_uploaded_files_ip ON uploaded_files (ip(64));
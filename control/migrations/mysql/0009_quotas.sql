@@ -0,0 +1,17 @@
+-- 上传配额：文件与分片记录上传者的账号与 API 密钥，账号与密钥可以单独设置配额
+
+ALTER TABLE uploaded_files ADD COLUMN api_key_id BIGINT DEFAULT 0;
+
+CREATE INDEX idx_uploaded_files_api_key_id ON uploaded_files (api_key_id, time);
+
+ALTER TABLE chunk_records ADD COLUMN size BIGINT DEFAULT 0;
+ALTER TABLE chunk_records ADD COLUMN user_id BIGINT DEFAULT 0;
+ALTER TABLE chunk_records ADD COLUMN api_key_id BIGINT DEFAULT 0;
+
+ALTER TABLE users ADD COLUMN quota_daily_bytes BIGINT DEFAULT 0;
+ALTER TABLE users ADD COLUMN quota_daily_files BIGINT DEFAULT 0;
+ALTER TABLE users ADD COLUMN quota_total_bytes BIGINT DEFAULT 0;
+
+ALTER TABLE api_keys ADD COLUMN quota_daily_bytes BIGINT DEFAULT 0;
+ALTER TABLE api_keys ADD COLUMN quota_daily_files BIGINT DEFAULT 0;
+ALTER TABLE api_keys ADD COLUMN quota_total_bytes BIGINT DEFAULT 0;
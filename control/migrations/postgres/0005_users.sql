@@ -0,0 +1,22 @@
+-- 用户账号与登录会话，上传的文件关联到用户
+
+CREATE TABLE IF NOT EXISTS users (
+	id BIGSERIAL PRIMARY KEY,
+	username TEXT UNIQUE NOT NULL,
+	password_hash TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	token_hash TEXT PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	csrf_token TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+ALTER TABLE uploaded_files ADD COLUMN IF NOT EXISTS user_id BIGINT DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_uploaded_files_user_id ON uploaded_files (user_id, time);
@@ -0,0 +1,15 @@
+-- 用户的 API 密钥，只保存哈希
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	label TEXT NOT NULL,
+	prefix TEXT NOT NULL,
+	key_hash TEXT UNIQUE NOT NULL,
+	scopes TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	last_used_at TIMESTAMP,
+	revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
@@ -0,0 +1,13 @@
+-- 外部登录（OIDC、Telegram）的身份与账号角色
+
+CREATE TABLE IF NOT EXISTS user_identities (
+	provider TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	user_id BIGINT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT DEFAULT '';
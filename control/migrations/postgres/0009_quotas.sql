@@ -0,0 +1,17 @@
+-- 上传配额：文件与分片记录上传者的账号与 API 密钥，账号与密钥可以单独设置配额
+
+ALTER TABLE uploaded_files ADD COLUMN IF NOT EXISTS api_key_id BIGINT DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_uploaded_files_api_key_id ON uploaded_files (api_key_id, time);
+
+ALTER TABLE chunk_records ADD COLUMN IF NOT EXISTS size BIGINT DEFAULT 0;
+ALTER TABLE chunk_records ADD COLUMN IF NOT EXISTS user_id BIGINT DEFAULT 0;
+ALTER TABLE chunk_records ADD COLUMN IF NOT EXISTS api_key_id BIGINT DEFAULT 0;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS quota_daily_bytes BIGINT DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS quota_daily_files BIGINT DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS quota_total_bytes BIGINT DEFAULT 0;
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS quota_daily_bytes BIGINT DEFAULT 0;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS quota_daily_files BIGINT DEFAULT 0;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS quota_total_bytes BIGINT DEFAULT 0;
@@ -0,0 +1,7 @@
+-- 之前记录的是带端口的 RemoteAddr，去掉端口与 IPv6 的方括号，与现在记录的地址一致
+
+UPDATE uploaded_files SET ip = substring(ip FROM 2 FOR position(']' IN ip) - 2) WHERE ip LIKE '[%]:%';
+UPDATE uploaded_files SET ip = split_part(ip, ':', 1) WHERE ip LIKE '%.%:%' AND ip NOT LIKE '%:%:%';
+
+UPDATE chunk_records SET ip = substring(ip FROM 2 FOR position(']' IN ip) - 2) WHERE ip LIKE '[%]:%';
+UPDATE chunk_records SET ip = split_part(ip, ':', 1) WHERE ip LIKE '%.%:%' AND ip NOT LIKE '%:%:%';
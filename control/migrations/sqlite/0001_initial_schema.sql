@@ -0,0 +1,83 @@
+-- 初始表结构，包含迁移框架引入之前通过 ALTER TABLE 追加的所有字段
+
+CREATE TABLE IF NOT EXISTS uploaded_files (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	fileId TEXT NOT NULL,
+	filename TEXT NOT NULL,
+	ip TEXT NOT NULL,
+	user_fingerprint TEXT,
+	shared INTEGER DEFAULT 0,
+	time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	encryption TEXT,
+	encoding TEXT,
+	size INTEGER DEFAULT 0,
+	stored_size INTEGER DEFAULT 0,
+	duration INTEGER DEFAULT 0,
+	width INTEGER DEFAULT 0,
+	height INTEGER DEFAULT 0,
+	thumb_file_id TEXT,
+	performer TEXT,
+	title TEXT
+);
+
+CREATE TABLE IF NOT EXISTS short_links (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	short_code TEXT UNIQUE NOT NULL,
+	file_id TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	access_count INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS chunk_records (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	upload_id TEXT NOT NULL,
+	chunk_index INTEGER NOT NULL,
+	chunk_id TEXT NOT NULL,
+	file_name TEXT NOT NULL,
+	ip TEXT NOT NULL,
+	user_fingerprint TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(upload_id, chunk_index)
+);
+
+-- 上传回 Telegram 的图片变体
+CREATE TABLE IF NOT EXISTS image_variants (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	file_id TEXT NOT NULL,
+	variant_key TEXT NOT NULL,
+	variant_file_id TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	size INTEGER DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(file_id, variant_key)
+);
+
+-- sendPhoto 生成的各个尺寸
+CREATE TABLE IF NOT EXISTS photo_sizes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	file_id TEXT NOT NULL,
+	size_file_id TEXT NOT NULL,
+	width INTEGER DEFAULT 0,
+	height INTEGER DEFAULT 0,
+	file_size INTEGER DEFAULT 0,
+	UNIQUE(file_id, size_file_id)
+);
+
+-- HLS 切片任务与分片
+CREATE TABLE IF NOT EXISTS hls_jobs (
+	file_id TEXT PRIMARY KEY,
+	filename TEXT NOT NULL,
+	status TEXT NOT NULL,
+	playlist TEXT,
+	error TEXT,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS hls_segments (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	file_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	segment_file_id TEXT NOT NULL,
+	UNIQUE(file_id, name)
+);
@@ -0,0 +1,7 @@
+-- 下载、历史记录、广场与短链查询使用的索引
+
+CREATE INDEX IF NOT EXISTS idx_uploaded_files_file_id ON uploaded_files (fileId);
+CREATE INDEX IF NOT EXISTS idx_uploaded_files_filename ON uploaded_files (filename, time);
+CREATE INDEX IF NOT EXISTS idx_uploaded_files_fingerprint ON uploaded_files (user_fingerprint, time);
+CREATE INDEX IF NOT EXISTS idx_uploaded_files_shared_time ON uploaded_files (shared, time);
+CREATE INDEX IF NOT EXISTS idx_short_links_file_id ON short_links (file_id);
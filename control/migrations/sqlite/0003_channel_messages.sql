@@ -0,0 +1,16 @@
+-- 频道中的文件消息，数据库丢失后用于重建 uploaded_files
+
+CREATE TABLE IF NOT EXISTS channel_messages (
+	chat_id INTEGER NOT NULL,
+	message_id INTEGER NOT NULL,
+	file_id TEXT NOT NULL,
+	file_unique_id TEXT,
+	file_name TEXT,
+	file_size INTEGER DEFAULT 0,
+	mime_type TEXT,
+	media_type TEXT,
+	date TIMESTAMP,
+	PRIMARY KEY (chat_id, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_messages_unique_id ON channel_messages (file_unique_id);
@@ -0,0 +1,13 @@
+-- 管理员封禁的 IP、浏览器指纹与账号
+
+CREATE TABLE IF NOT EXISTS bans (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind TEXT NOT NULL,
+	value TEXT NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	created_by INTEGER DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (kind, value)
+);
+
+CREATE INDEX IF NOT EXISTS idx_uploaded_files_ip ON uploaded_files (ip);
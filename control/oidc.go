@@ -0,0 +1,626 @@
+package control
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"csz.net/tgstate/conf"
+)
+
+// OpenID Connect 单点登录：通过 discovery 取得端点，使用授权码 + PKCE 登录，
+// 校验 ID Token 的签名（JWKS）、iss、aud、exp 与 nonce 后按 sub 关联本地账号。
+// 登录成功后同时视为通过访问密码，可以代替 /pwd
+
+const (
+	// oidcProviderName user_identities 中 OIDC 身份的 provider
+	oidcProviderName = "oidc"
+	// oidcCookie 保存登录流程的 state、nonce 与 PKCE verifier，已签名
+	oidcCookie = "tgstate_oidc"
+	// oidcFlowTTL 从跳转到身份提供方到回调的最长时间
+	oidcFlowTTL = 10 * time.Minute
+	// oidcDiscoveryTTL discovery 与 JWKS 的缓存时间
+	oidcDiscoveryTTL = time.Hour
+	// oidcClockSkew 校验 exp、iat 时允许的时钟误差
+	oidcClockSkew = 2 * time.Minute
+)
+
+var oidcClient = &http.Client{Timeout: 10 * time.Second}
+
+// oidcProvider discovery 文档中用到的字段
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+var oidcCache struct {
+	sync.Mutex
+	provider  *oidcProvider
+	fetchedAt time.Time
+	keys      map[string]crypto.PublicKey
+	keysAt    time.Time
+}
+
+// OIDCEnabled 是否配置了 OIDC 登录
+func OIDCEnabled() bool {
+	return conf.OIDCIssuer != "" && conf.OIDCClientId != ""
+}
+
+func oidcGetJSON(endpoint string, v interface{}) error {
+	resp, err := oidcClient.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", endpoint, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// discoverOIDC 读取（并缓存）身份提供方的 discovery 文档
+func discoverOIDC() (*oidcProvider, error) {
+	oidcCache.Lock()
+	defer oidcCache.Unlock()
+	if oidcCache.provider != nil && time.Since(oidcCache.fetchedAt) < oidcDiscoveryTTL {
+		return oidcCache.provider, nil
+	}
+	issuer := strings.TrimSuffix(conf.OIDCIssuer, "/")
+	var p oidcProvider
+	if err := oidcGetJSON(issuer+"/.well-known/openid-configuration", &p); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(p.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery issuer %q does not match %q", p.Issuer, conf.OIDCIssuer)
+	}
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.JwksURI == "" {
+		return nil, errors.New("discovery document is missing endpoints")
+	}
+	oidcCache.provider, oidcCache.fetchedAt = &p, time.Now()
+	return &p, nil
+}
+
+// jsonWebKey JWKS 中的一个公钥
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	decode := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil || !e.IsInt64() {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+// oidcKey 按 kid 查找签名公钥，找不到时重新获取 JWKS（身份提供方可能轮换了密钥）
+func oidcKey(p *oidcProvider, kid string) (crypto.PublicKey, error) {
+	oidcCache.Lock()
+	defer oidcCache.Unlock()
+	if key, ok := oidcCache.keys[kid]; ok && time.Since(oidcCache.keysAt) < oidcDiscoveryTTL {
+		return key, nil
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := oidcGetJSON(p.JwksURI, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			log.Printf("忽略 JWKS 中的密钥 %s: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	oidcCache.keys, oidcCache.keysAt = keys, time.Now()
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	// 只有一个密钥且令牌未指定 kid 时直接使用
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("signing key %q not found", kid)
+}
+
+// verifyJWTSignature 校验 JWS 签名，只接受非对称算法
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported alg %s", alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+	switch alg[:2] {
+	case "RS", "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key type does not match alg")
+		}
+		if alg[:2] == "PS" {
+			return rsa.VerifyPSS(pub, hash, digest, sig, nil)
+		}
+		return rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("key type does not match alg")
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("invalid ECDSA signature length")
+		}
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported alg %s", alg)
+}
+
+// oidcClaims ID Token 与 userinfo 中的声明
+type oidcClaims map[string]interface{}
+
+func (c oidcClaims) str(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+func (c oidcClaims) time(name string) (time.Time, bool) {
+	n, ok := c[name].(float64)
+	return time.Unix(int64(n), 0), ok
+}
+
+// strings 读取字符串数组声明，单个字符串也视为数组
+func (c oidcClaims) strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// verifyIDToken 校验 ID Token 并返回其中的声明
+func verifyIDToken(p *oidcProvider, raw, nonce string) (oidcClaims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed id_token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(headerJSON, &header) != nil || len(header.Alg) != 5 {
+		return nil, errors.New("invalid id_token header")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("invalid id_token signature encoding")
+	}
+	key, err := oidcKey(p, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWTSignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("invalid id_token payload")
+	}
+	var claims oidcClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.New("invalid id_token payload")
+	}
+	if claims.str("iss") != p.Issuer {
+		return nil, fmt.Errorf("unexpected issuer %q", claims.str("iss"))
+	}
+	audience := claims.strings("aud")
+	if !containsString(audience, conf.OIDCClientId) {
+		return nil, errors.New("id_token audience does not include client id")
+	}
+	if azp := claims.str("azp"); len(audience) > 1 && azp != "" && azp != conf.OIDCClientId {
+		return nil, errors.New("id_token azp does not match client id")
+	}
+	now := time.Now()
+	if exp, ok := claims.time("exp"); !ok || now.After(exp.Add(oidcClockSkew)) {
+		return nil, errors.New("id_token expired")
+	}
+	if iat, ok := claims.time("iat"); ok && iat.After(now.Add(oidcClockSkew)) {
+		return nil, errors.New("id_token issued in the future")
+	}
+	if claims.str("nonce") != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+	if claims.str("sub") == "" {
+		return nil, errors.New("id_token has no subject")
+	}
+	return claims, nil
+}
+
+// oidcFlow 登录流程的临时状态，保存在签名 Cookie 中
+type oidcFlow struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Next     string `json:"next"`
+	Expires  int64  `json:"exp"`
+}
+
+func (f oidcFlow) encode() string {
+	data, _ := json.Marshal(f)
+	value := base64.RawURLEncoding.EncodeToString(data)
+	return value + "." + sign("oidc", value)
+}
+
+func decodeOIDCFlow(cookie string) (oidcFlow, bool) {
+	var f oidcFlow
+	value, signature, ok := strings.Cut(cookie, ".")
+	if !ok || !secretEqual(signature, sign("oidc", value)) {
+		return f, false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(data, &f) != nil {
+		return f, false
+	}
+	return f, time.Now().Unix() < f.Expires
+}
+
+// localRedirect 只允许跳转到本站路径
+func localRedirect(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// oidcRedirectURL 回调地址，默认根据 url 参数生成
+func oidcRedirectURL(r *http.Request) string {
+	if conf.OIDCRedirectUrl != "" {
+		return conf.OIDCRedirectUrl
+	}
+	base := strings.TrimSuffix(conf.BaseUrl, "/")
+	if base == "" {
+		scheme := "http"
+		if secureRequest(r) {
+			scheme = "https"
+		}
+		base = scheme + "://" + r.Host
+	}
+	return base + "/auth/oidc/callback"
+}
+
+// OIDCLogin 跳转到身份提供方：GET /auth/oidc/login?next=/
+func OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if !OIDCEnabled() {
+		http.NotFound(w, r)
+		return
+	}
+	p, err := discoverOIDC()
+	if err != nil {
+		log.Printf("OIDC discovery 失败: %v", err)
+		http.Error(w, "Identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+	flow := oidcFlow{State: randomToken(), Nonce: randomToken(), Verifier: randomToken(),
+		Next: localRedirect(r.URL.Query().Get("next")), Expires: time.Now().Add(oidcFlowTTL).Unix()}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookie,
+		Value:    flow.encode(),
+		Path:     "/auth/oidc/",
+		MaxAge:   int(oidcFlowTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   secureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	challenge := sha256.Sum256([]byte(flow.Verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {conf.OIDCClientId},
+		"redirect_uri":          {oidcRedirectURL(r)},
+		"scope":                 {conf.OIDCScopes},
+		"state":                 {flow.State},
+		"nonce":                 {flow.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, p.AuthorizationEndpoint+sep+query.Encode(), http.StatusFound)
+}
+
+// exchangeOIDCCode 用授权码换取令牌
+func exchangeOIDCCode(p *oidcProvider, r *http.Request, code, verifier string) (idToken, accessToken string, err error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {oidcRedirectURL(r)},
+		"code_verifier": {verifier},
+	}
+	if conf.OIDCClientSecret == "" {
+		form.Set("client_id", conf.OIDCClientId)
+	}
+	req, err := http.NewRequest(http.MethodPost, p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if conf.OIDCClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(conf.OIDCClientId), url.QueryEscape(conf.OIDCClientSecret))
+	}
+	resp, err := oidcClient.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	var token struct {
+		IdToken          string `json:"id_token"`
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return "", "", fmt.Errorf("token endpoint: %s", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return "", "", fmt.Errorf("token endpoint: %s %s %s", resp.Status, token.Error, token.ErrorDescription)
+	}
+	if token.IdToken == "" {
+		return "", "", errors.New("token response has no id_token")
+	}
+	return token.IdToken, token.AccessToken, nil
+}
+
+// mergeUserinfo 用 userinfo 补充 ID Token 中没有的声明（例如部分身份提供方不在 ID Token 中返回 groups）
+func mergeUserinfo(p *oidcProvider, accessToken string, claims oidcClaims) {
+	if p.UserinfoEndpoint == "" || accessToken == "" {
+		return
+	}
+	req, err := http.NewRequest(http.MethodGet, p.UserinfoEndpoint, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := oidcClient.Do(req)
+	if err != nil {
+		log.Printf("OIDC userinfo 请求失败: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	var info oidcClaims
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&info) != nil {
+		return
+	}
+	// sub 不一致时忽略，防止被替换成其他用户的信息
+	if info.str("sub") != claims.str("sub") {
+		return
+	}
+	for name, value := range info {
+		if _, ok := claims[name]; !ok {
+			claims[name] = value
+		}
+	}
+}
+
+var invalidUsernameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// usernameFromClaims 根据声明生成符合规则的用户名
+func usernameFromClaims(claims oidcClaims) string {
+	name := claims.str(conf.OIDCUsernameClaim)
+	if name == "" {
+		name = claims.str("preferred_username")
+	}
+	if name == "" {
+		name, _, _ = strings.Cut(claims.str("email"), "@")
+	}
+	return sanitizeUsername(name, claims.str("sub"))
+}
+
+// sanitizeUsername 把外部名称转换为合法用户名，无法使用时根据 subject 生成
+func sanitizeUsername(name, subject string) string {
+	name = strings.Trim(invalidUsernameChars.ReplaceAllString(name, "_"), "_.-")
+	if len(name) > 28 {
+		name = name[:28]
+	}
+	if len(name) < 3 {
+		name = "user_" + hashToken(subject)[:8]
+	}
+	return name
+}
+
+// identityUser 按外部身份查找账号，不存在时创建，用户名冲突时加数字后缀
+func identityUser(provider, subject, username string) (User, error) {
+	user, err := GetUserByIdentity(provider, subject)
+	if err == nil {
+		return user, nil
+	}
+	for i := 1; i <= 100; i++ {
+		candidate := username
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d", username, i)
+		}
+		// 外部登录的账号没有密码，不能通过 /api/login 登录
+		user, err = CreateUser(candidate, "")
+		if errors.Is(err, errUserExists) {
+			continue
+		}
+		if err != nil {
+			return User{}, err
+		}
+		if err := LinkIdentity(user.Id, provider, subject); err != nil {
+			// 并发登录时另一个请求已经关联，使用已关联的账号
+			if existing, lookupErr := GetUserByIdentity(provider, subject); lookupErr == nil {
+				return existing, nil
+			}
+			return User{}, err
+		}
+		return user, nil
+	}
+	return User{}, errors.New("no free username")
+}
+
+// OIDCCallback 身份提供方回调：GET /auth/oidc/callback?code=...&state=...
+func OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if !OIDCEnabled() {
+		http.NotFound(w, r)
+		return
+	}
+	cookie, err := r.Cookie(oidcCookie)
+	if err != nil {
+		http.Error(w, "Login session expired, please try again", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcCookie, Value: "", Path: "/auth/oidc/", MaxAge: -1, HttpOnly: true, Secure: secureRequest(r), SameSite: http.SameSiteLaxMode})
+	flow, ok := decodeOIDCFlow(cookie.Value)
+	query := r.URL.Query()
+	if !ok || !secretEqual(query.Get("state"), flow.State) {
+		http.Error(w, "Invalid login state, please try again", http.StatusBadRequest)
+		return
+	}
+	if e := query.Get("error"); e != "" {
+		http.Error(w, "Login failed: "+e+" "+query.Get("error_description"), http.StatusUnauthorized)
+		return
+	}
+	p, err := discoverOIDC()
+	if err != nil {
+		log.Printf("OIDC discovery 失败: %v", err)
+		http.Error(w, "Identity provider unavailable", http.StatusBadGateway)
+		return
+	}
+	idToken, accessToken, err := exchangeOIDCCode(p, r, query.Get("code"), flow.Verifier)
+	if err != nil {
+		log.Printf("OIDC 换取令牌失败: %v", err)
+		http.Error(w, "Login failed", http.StatusBadGateway)
+		return
+	}
+	claims, err := verifyIDToken(p, idToken, flow.Nonce)
+	if err != nil {
+		log.Printf("OIDC ID Token 校验失败: %v", err)
+		http.Error(w, "Login failed", http.StatusUnauthorized)
+		return
+	}
+	mergeUserinfo(p, accessToken, claims)
+
+	user, err := identityUser(oidcProviderName, p.Issuer+"|"+claims.str("sub"), usernameFromClaims(claims))
+	if err != nil {
+		log.Printf("OIDC 创建账号失败: %v", err)
+		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		return
+	}
+	// 配置了管理员组时，每次登录按组成员关系同步管理员角色，其他角色由管理员设置
+	if conf.OIDCAdminGroup != "" {
+		role := user.Role
+		if containsString(claims.strings(conf.OIDCGroupsClaim), conf.OIDCAdminGroup) {
+			role = roleAdmin
+		} else if role == roleAdmin {
+			role = ""
+		}
+		if role != user.Role {
+			if err := SetUserRole(user.Id, role); err != nil {
+				log.Printf("更新账号角色失败: %v", err)
+			}
+			user.Role = role
+		}
+	}
+	if _, err := startSession(w, r, user); err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+	setAccessCookie(w, r)
+	http.Redirect(w, r, flow.Next, http.StatusFound)
+}
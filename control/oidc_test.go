@@ -0,0 +1,408 @@
+package control
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"csz.net/tgstate/conf"
+)
+
+// stubIdP 测试用的身份提供方：提供 discovery、JWKS、token 与 userinfo 端点，
+// token 端点按 PKCE 校验 code_verifier，ID Token 的声明由测试指定
+type stubIdP struct {
+	*httptest.Server
+	t      *testing.T
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+
+	mu       sync.Mutex
+	codes    map[string]stubGrant
+	userinfo map[string]interface{}
+}
+
+// stubGrant 一次授权：PKCE challenge、ID Token 的签名算法与声明
+type stubGrant struct {
+	challenge string
+	alg       string
+	claims    map[string]interface{}
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &stubIdP{t: t, rsaKey: rsaKey, ecKey: ecKey, codes: make(map[string]stubGrant)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/jwks", idp.jwks)
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/userinfo", idp.userinfoEndpoint)
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func (idp *stubIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 idp.URL,
+		"authorization_endpoint": idp.URL + "/authorize",
+		"token_endpoint":         idp.URL + "/token",
+		"userinfo_endpoint":      idp.URL + "/userinfo",
+		"jwks_uri":               idp.URL + "/jwks",
+	})
+}
+
+func (idp *stubIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+		{"kid": "rsa", "kty": "RSA", "use": "sig", "n": b64(idp.rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(idp.rsaKey.E)).Bytes())},
+		{"kid": "ec", "kty": "EC", "use": "sig", "crv": "P-256", "x": b64(idp.ecKey.X.FillBytes(make([]byte, 32))), "y": b64(idp.ecKey.Y.FillBytes(make([]byte, 32)))},
+		// 用于加密的密钥不能用来校验签名
+		{"kid": "enc", "kty": "RSA", "use": "enc", "n": b64(idp.rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(idp.rsaKey.E)).Bytes())},
+	}})
+}
+
+func (idp *stubIdP) token(w http.ResponseWriter, r *http.Request) {
+	tokenError := func(code string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+	if r.Method != http.MethodPost || r.FormValue("grant_type") != "authorization_code" {
+		tokenError("unsupported_grant_type")
+		return
+	}
+	if r.FormValue("client_id") != conf.OIDCClientId || r.FormValue("redirect_uri") != conf.BaseUrl+"/auth/oidc/callback" {
+		tokenError("invalid_client")
+		return
+	}
+	idp.mu.Lock()
+	grant, ok := idp.codes[r.FormValue("code")]
+	delete(idp.codes, r.FormValue("code"))
+	idp.mu.Unlock()
+	sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		tokenError("invalid_grant")
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{
+		"id_token":     idp.sign(grant.alg, grant.claims),
+		"access_token": "stub-access-token",
+		"token_type":   "Bearer",
+	})
+}
+
+func (idp *stubIdP) userinfoEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer stub-access-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	if idp.userinfo == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(idp.userinfo)
+}
+
+// sign 生成 RS256 或 ES256 签名的 JWT
+func (idp *stubIdP) sign(alg string, claims map[string]interface{}) string {
+	kid := map[string]string{"RS256": "rsa", "ES256": "ec"}[alg]
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var sig []byte
+	var err error
+	switch alg {
+	case "RS256":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, idp.rsaKey, crypto.SHA256, digest[:])
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, idp.ecKey, digest[:])
+		if err == nil {
+			sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	}
+	if err != nil {
+		idp.t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// setOIDCConf 指向 idp 并清空 discovery 与 JWKS 缓存，测试结束后恢复
+func setOIDCConf(t *testing.T, idp *stubIdP) {
+	old := []string{conf.OIDCIssuer, conf.OIDCClientId, conf.OIDCClientSecret, conf.OIDCRedirectUrl, conf.OIDCScopes,
+		conf.OIDCUsernameClaim, conf.OIDCGroupsClaim, conf.OIDCAdminGroup, conf.BaseUrl, conf.BotToken}
+	conf.OIDCIssuer, conf.OIDCClientId, conf.OIDCClientSecret, conf.OIDCRedirectUrl = idp.URL, "tgstate", "", ""
+	conf.OIDCScopes, conf.OIDCUsernameClaim, conf.OIDCGroupsClaim = "openid profile", "preferred_username", "groups"
+	conf.OIDCAdminGroup, conf.BaseUrl, conf.BotToken = "tgstate-admins", "http://tgstate.test", "123456:test-token"
+	resetOIDCCache := func() {
+		oidcCache.Lock()
+		oidcCache.provider, oidcCache.keys = nil, nil
+		oidcCache.Unlock()
+	}
+	resetOIDCCache()
+	t.Cleanup(func() {
+		conf.OIDCIssuer, conf.OIDCClientId, conf.OIDCClientSecret, conf.OIDCRedirectUrl, conf.OIDCScopes = old[0], old[1], old[2], old[3], old[4]
+		conf.OIDCUsernameClaim, conf.OIDCGroupsClaim, conf.OIDCAdminGroup, conf.BaseUrl, conf.BotToken = old[5], old[6], old[7], old[8], old[9]
+		resetOIDCCache()
+	})
+}
+
+// oidcLogin 走一遍登录流程：OIDCLogin 跳转后由 idp 签发授权码，claims 可修改默认的 ID Token 声明，
+// 返回 OIDCCallback 的响应
+func oidcLogin(t *testing.T, idp *stubIdP, alg string, claims func(c map[string]interface{}, nonce string)) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	OIDCLogin(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/login?next=/files", nil))
+	if w.Code != http.StatusFound {
+		t.Fatalf("login status = %d, want 302", w.Code)
+	}
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil || !strings.HasPrefix(location.String(), idp.URL+"/authorize?") {
+		t.Fatalf("login redirected to %q", w.Header().Get("Location"))
+	}
+	q := location.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" || q.Get("client_id") != "tgstate" {
+		t.Fatalf("authorization request missing PKCE or client_id: %v", q)
+	}
+
+	c := map[string]interface{}{
+		"iss":                idp.URL,
+		"aud":                "tgstate",
+		"sub":                "user-1",
+		"nonce":              q.Get("nonce"),
+		"iat":                time.Now().Unix(),
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"preferred_username": "alice",
+	}
+	if claims != nil {
+		claims(c, q.Get("nonce"))
+	}
+	code := randomToken()
+	idp.mu.Lock()
+	idp.codes[code] = stubGrant{challenge: q.Get("code_challenge"), alg: alg, claims: c}
+	idp.mu.Unlock()
+
+	r := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?"+url.Values{"code": {code}, "state": {q.Get("state")}}.Encode(), nil)
+	for _, cookie := range w.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	OIDCCallback(w, r)
+	return w
+}
+
+// oidcUser 返回 idp 中 sub 对应的本地账号
+func oidcUser(t *testing.T, idp *stubIdP, sub string) User {
+	t.Helper()
+	user, err := GetUserByIdentity(oidcProviderName, idp.URL+"|"+sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestOIDCLogin(t *testing.T) {
+	openTestDB(t)
+	idp := newStubIdP(t)
+	setOIDCConf(t, idp)
+
+	for _, alg := range []string{"RS256", "ES256"} {
+		w := oidcLogin(t, idp, alg, func(c map[string]interface{}, nonce string) {
+			c["sub"] = "user-" + alg
+			c["preferred_username"] = "user " + alg
+		})
+		if w.Code != http.StatusFound || w.Header().Get("Location") != "/files" {
+			t.Fatalf("%s: status = %d, location = %q, want 302 to /files", alg, w.Code, w.Header().Get("Location"))
+		}
+		cookies := map[string]bool{}
+		for _, cookie := range w.Result().Cookies() {
+			cookies[cookie.Name] = cookie.Value != ""
+		}
+		if !cookies[sessionCookie] || !cookies[accessCookie] {
+			t.Errorf("%s: session or access cookie not set: %v", alg, cookies)
+		}
+		if user := oidcUser(t, idp, "user-"+alg); user.Username != "user_"+alg {
+			t.Errorf("%s: username = %q, want user_%s", alg, user.Username, alg)
+		}
+	}
+}
+
+func TestOIDCRejectsInvalidTokens(t *testing.T) {
+	openTestDB(t)
+	idp := newStubIdP(t)
+	setOIDCConf(t, idp)
+
+	tests := []struct {
+		name   string
+		claims func(c map[string]interface{}, nonce string)
+	}{
+		{"issuer", func(c map[string]interface{}, nonce string) { c["iss"] = "https://evil.example" }},
+		{"audience", func(c map[string]interface{}, nonce string) { c["aud"] = "other-client" }},
+		{"audience list", func(c map[string]interface{}, nonce string) { c["aud"] = []string{"other-client", "another"} }},
+		{"azp", func(c map[string]interface{}, nonce string) {
+			c["aud"], c["azp"] = []string{"tgstate", "other-client"}, "other-client"
+		}},
+		{"nonce", func(c map[string]interface{}, nonce string) { c["nonce"] = nonce + "x" }},
+		{"missing nonce", func(c map[string]interface{}, nonce string) { delete(c, "nonce") }},
+		{"expired", func(c map[string]interface{}, nonce string) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"missing exp", func(c map[string]interface{}, nonce string) { delete(c, "exp") }},
+		{"issued in the future", func(c map[string]interface{}, nonce string) { c["iat"] = time.Now().Add(time.Hour).Unix() }},
+		{"missing subject", func(c map[string]interface{}, nonce string) { delete(c, "sub") }},
+	}
+	for _, alg := range []string{"RS256", "ES256"} {
+		for _, tt := range tests {
+			if w := oidcLogin(t, idp, alg, tt.claims); w.Code != http.StatusUnauthorized {
+				t.Errorf("%s %s: status = %d, want 401", alg, tt.name, w.Code)
+			}
+		}
+	}
+	if _, err := GetUserByIdentity(oidcProviderName, idp.URL+"|user-1"); err == nil {
+		t.Error("rejected login created an account")
+	}
+}
+
+func TestOIDCRejectsBadSignature(t *testing.T) {
+	openTestDB(t)
+	idp := newStubIdP(t)
+	setOIDCConf(t, idp)
+	p, err := discoverOIDC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]interface{}{"iss": idp.URL, "aud": "tgstate", "sub": "user-1", "nonce": "n", "exp": time.Now().Add(time.Hour).Unix()}
+	for _, alg := range []string{"RS256", "ES256"} {
+		token := idp.sign(alg, claims)
+		if _, err := verifyIDToken(p, token, "n"); err != nil {
+			t.Fatalf("%s: valid token rejected: %v", alg, err)
+		}
+		parts := strings.Split(token, ".")
+		forged, _ := json.Marshal(map[string]interface{}{"iss": idp.URL, "aud": "tgstate", "sub": "admin", "nonce": "n", "exp": time.Now().Add(time.Hour).Unix()})
+		if _, err := verifyIDToken(p, parts[0]+"."+base64.RawURLEncoding.EncodeToString(forged)+"."+parts[2], "n"); err == nil {
+			t.Errorf("%s: token with modified payload accepted", alg)
+		}
+	}
+	for _, header := range []string{`{"alg":"none"}`, `{"alg":"HS256","kid":"rsa"}`, `{"alg":"ES256","kid":"rsa"}`, `{"alg":"RS256","kid":"enc"}`} {
+		token := base64.RawURLEncoding.EncodeToString([]byte(header)) + ".e30.c2ln"
+		if _, err := verifyIDToken(p, token, ""); err == nil {
+			t.Errorf("token with header %s accepted", header)
+		}
+	}
+}
+
+func TestOIDCRequiresPKCEVerifier(t *testing.T) {
+	openTestDB(t)
+	idp := newStubIdP(t)
+	setOIDCConf(t, idp)
+
+	w := httptest.NewRecorder()
+	OIDCLogin(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	location, _ := url.Parse(w.Header().Get("Location"))
+	q := location.Query()
+	// 授权码绑定到另一个 verifier 的 challenge，token 端点拒绝后登录失败
+	other := sha256.Sum256([]byte("another verifier"))
+	code := randomToken()
+	idp.mu.Lock()
+	idp.codes[code] = stubGrant{challenge: base64.RawURLEncoding.EncodeToString(other[:]), alg: "RS256",
+		claims: map[string]interface{}{"iss": idp.URL, "aud": "tgstate", "sub": "user-1", "nonce": q.Get("nonce"), "exp": time.Now().Add(time.Hour).Unix()}}
+	idp.mu.Unlock()
+	r := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?"+url.Values{"code": {code}, "state": {q.Get("state")}}.Encode(), nil)
+	for _, cookie := range w.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	OIDCCallback(w, r)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("mismatched verifier: status = %d, want 502", w.Code)
+	}
+
+	// state 不一致时不会请求 token 端点
+	w = httptest.NewRecorder()
+	OIDCLogin(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	r = httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?code=x&state=wrong", nil)
+	for _, cookie := range w.Result().Cookies() {
+		r.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+	OIDCCallback(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("wrong state: status = %d, want 400", w.Code)
+	}
+}
+
+func TestOIDCAdminGroupSync(t *testing.T) {
+	openTestDB(t)
+	idp := newStubIdP(t)
+	setOIDCConf(t, idp)
+
+	login := func(groups ...string) User {
+		t.Helper()
+		w := oidcLogin(t, idp, "RS256", func(c map[string]interface{}, nonce string) {
+			if groups != nil {
+				c["groups"] = groups
+			}
+		})
+		if w.Code != http.StatusFound {
+			t.Fatalf("login status = %d, want 302", w.Code)
+		}
+		return oidcUser(t, idp, "user-1")
+	}
+	if user := login("staff"); user.role() == roleAdmin {
+		t.Fatalf("non-member got the admin role")
+	}
+	if user := login("staff", "tgstate-admins"); user.Role != roleAdmin {
+		t.Fatalf("member role = %q, want admin", user.Role)
+	}
+	if user := login("staff"); user.Role != "" {
+		t.Fatalf("role after leaving the group = %q, want default", user.Role)
+	}
+
+	// 没有在 ID Token 中返回 groups 时使用 userinfo 中的 groups
+	idp.mu.Lock()
+	idp.userinfo = map[string]interface{}{"sub": "user-1", "groups": []string{"tgstate-admins"}}
+	idp.mu.Unlock()
+	if user := login(); user.Role != roleAdmin {
+		t.Fatalf("role from userinfo groups = %q, want admin", user.Role)
+	}
+	// userinfo 的 sub 与 ID Token 不一致时忽略
+	idp.mu.Lock()
+	idp.userinfo = map[string]interface{}{"sub": "user-2", "groups": []string{"staff"}}
+	idp.mu.Unlock()
+	if user := login(); user.Role != "" {
+		t.Fatalf("role with foreign userinfo = %q, want default", user.Role)
+	}
+
+	// 设置的其他角色不受组同步影响
+	user := oidcUser(t, idp, "user-1")
+	if err := SetUserRole(user.Id, roleViewer); err != nil {
+		t.Fatal(err)
+	}
+	idp.mu.Lock()
+	idp.userinfo = nil
+	idp.mu.Unlock()
+	if user := login("staff"); user.Role != roleViewer {
+		t.Fatalf("viewer role after login = %q, want viewer", user.Role)
+	}
+}
@@ -0,0 +1,51 @@
+package control
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"csz.net/tgstate/conf"
+	"csz.net/tgstate/utils"
+)
+
+// maxPhotoUpload sendPhoto 允许的最大文件大小
+const maxPhotoUpload = 10 * 1024 * 1024
+
+// photoExts 可以通过 sendPhoto 上传的图片格式
+var photoExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".webp": true}
+
+// photoSizeTargets ?size= 对应的最小边长，与 Telegram 的 s/m/x 尺寸大致对应
+var photoSizeTargets = map[string]int{"small": 90, "medium": 320, "large": 800}
+
+// photoUploadFor 根据服务端默认值与上传参数 asPhoto 决定是否以照片形式上传
+func photoUploadFor(r *http.Request, fileName string, size int64) bool {
+	enabled := conf.UploadAsPhoto
+	if v := r.FormValue("asPhoto"); v != "" {
+		enabled = v == "true"
+	}
+	return enabled && size <= maxPhotoUpload && photoExts[strings.ToLower(filepath.Ext(fileName))]
+}
+
+// photoFileName Telegram 会把照片转为 JPEG，文件名随之修改
+func photoFileName(fileName string) string {
+	return strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ".jpg"
+}
+
+// pickPhotoSize 选出 ?size= 对应的尺寸：长边不小于目标值的最小尺寸，都不够大时取最大的
+func pickPhotoSize(sizes []utils.PhotoSize, name string) (utils.PhotoSize, error) {
+	if name == "original" {
+		return sizes[len(sizes)-1], nil
+	}
+	target, ok := photoSizeTargets[name]
+	if !ok {
+		return utils.PhotoSize{}, fmt.Errorf("unsupported size %s", name)
+	}
+	for _, size := range sizes {
+		if size.Width >= target || size.Height >= target {
+			return size, nil
+		}
+	}
+	return sizes[len(sizes)-1], nil
+}
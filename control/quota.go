@@ -0,0 +1,171 @@
+package control
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"csz.net/tgstate/conf"
+)
+
+// 上传配额：按天（UTC）的字节数、文件数与累计字节数，分别统计 API 密钥、账号与匿名上传的 IP。
+// 用量来自数据库中记录的上传大小，尚未合并的分片也计入字节数。管理员不受配额限制
+
+// quotaCheck 一个统计对象的配额
+type quotaCheck struct {
+	Subject string      `json:"subject"` // apiKey、user 或 ip
+	column  string      // GetUsage 的统计字段
+	value   interface{} // 统计字段的值
+	Limit   Quota       `json:"limit"`
+	Used    Quota       `json:"used"`
+}
+
+// globalQuota 参数中设置的默认配额
+func globalQuota() Quota {
+	return Quota{DailyBytes: conf.QuotaDailyBytes, DailyFiles: conf.QuotaDailyFiles, TotalBytes: conf.QuotaTotalBytes}
+}
+
+// override 用 o 中不为 0 的项覆盖 q，负数表示不限制
+func (q Quota) override(o Quota) Quota {
+	pick := func(def, v int64) int64 {
+		switch {
+		case v < 0:
+			return 0
+		case v > 0:
+			return v
+		}
+		return def
+	}
+	return Quota{DailyBytes: pick(q.DailyBytes, o.DailyBytes), DailyFiles: pick(q.DailyFiles, o.DailyFiles), TotalBytes: pick(q.TotalBytes, o.TotalBytes)}
+}
+
+// quotaDay 返回当天（UTC）的开始时间与配额重置时间
+func quotaDay() (start, reset time.Time) {
+	now := time.Now().UTC()
+	start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return start, start.Add(24 * time.Hour)
+}
+
+// uploadOwner 返回请求的上传者，携带 API 密钥时记录密钥 id
+func uploadOwner(r *http.Request, user *User) UploadOwner {
+	owner := UploadOwner{UserId: user.id(), Ip: clientIP(r)}
+	if key, _, ok := requestAPIKey(r); ok && key != nil {
+		owner.ApiKeyId = key.Id
+	}
+	return owner
+}
+
+// ownsChunks 判断上传 ID 已保存的分片是否都属于 owner：账号与 API 密钥相同，匿名上传时 IP 也相同
+func (owner UploadOwner) ownsChunks(records []ChunkRecord) bool {
+	for _, record := range records {
+		if record.UserId != owner.UserId || record.ApiKeyId != owner.ApiKeyId || (owner.UserId == 0 && record.Ip != owner.Ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ownerQuotas 返回上传者需要满足的配额及当天的用量：使用 API 密钥时包括密钥自身的配额，
+// 登录后为账号的配额（管理员单独设置的项覆盖默认配额），匿名上传按 IP 使用默认配额
+func ownerQuotas(owner UploadOwner, user *User, excludeUpload string) ([]quotaCheck, error) {
+	var checks []quotaCheck
+	if owner.ApiKeyId != 0 {
+		key, err := GetAPIKeyById(owner.ApiKeyId)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, quotaCheck{Subject: "apiKey", column: "api_key_id", value: owner.ApiKeyId, Limit: key.Quota})
+	}
+	if user != nil {
+		override, err := GetUserQuota(user.Id)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, quotaCheck{Subject: "user", column: "user_id", value: user.Id, Limit: globalQuota().override(override)})
+	} else {
+		checks = append(checks, quotaCheck{Subject: "ip", column: "ip", value: owner.Ip, Limit: globalQuota()})
+	}
+	if user != nil && user.role() == roleAdmin {
+		for i := range checks {
+			checks[i].Limit = Quota{}
+		}
+	}
+	start, _ := quotaDay()
+	for i := range checks {
+		used, err := GetUsage(checks[i].column, checks[i].value, start, excludeUpload)
+		if err != nil {
+			return nil, err
+		}
+		checks[i].Used = used
+	}
+	return checks, nil
+}
+
+// quotaExceeded 检查再上传 size 字节、files 个文件后是否超出配额，未超出时 status 为 0。
+// 超出累计配额或单个文件大于每天的配额时为 413，超出每天的配额时为 429
+func quotaExceeded(owner UploadOwner, user *User, size, files int64, excludeUpload string) (status int, msg string, err error) {
+	checks, err := ownerQuotas(owner, user, excludeUpload)
+	if err != nil {
+		return 0, "", err
+	}
+	for _, c := range checks {
+		limit, used := c.Limit, c.Used
+		switch {
+		case limit.TotalBytes > 0 && used.TotalBytes+size > limit.TotalBytes:
+			return http.StatusRequestEntityTooLarge, fmt.Sprintf("Storage quota exceeded: %d of %d bytes used", used.TotalBytes, limit.TotalBytes), nil
+		case limit.DailyBytes > 0 && size > limit.DailyBytes:
+			return http.StatusRequestEntityTooLarge, fmt.Sprintf("File is larger than the daily upload quota of %d bytes", limit.DailyBytes), nil
+		case limit.DailyBytes > 0 && used.DailyBytes+size > limit.DailyBytes:
+			return http.StatusTooManyRequests, fmt.Sprintf("Daily upload quota exceeded: %d of %d bytes used", used.DailyBytes, limit.DailyBytes), nil
+		case limit.DailyFiles > 0 && used.DailyFiles+files > limit.DailyFiles:
+			return http.StatusTooManyRequests, fmt.Sprintf("Daily file quota exceeded: %d of %d files uploaded", used.DailyFiles, limit.DailyFiles), nil
+		}
+	}
+	return 0, "", nil
+}
+
+// checkQuota 超出配额时写入 413 或 429 错误并返回 false，429 在 Retry-After 中给出配额重置前的秒数
+func checkQuota(w http.ResponseWriter, owner UploadOwner, user *User, size, files int64, excludeUpload string) bool {
+	status, msg, err := quotaExceeded(owner, user, size, files, excludeUpload)
+	if err != nil {
+		log.Printf("查询上传配额失败: %v", err)
+		jsonError(w, http.StatusInternalServerError, "Failed to check upload quota")
+		return false
+	}
+	if status == 0 {
+		return true
+	}
+	if status == http.StatusTooManyRequests {
+		_, reset := quotaDay()
+		w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(reset).Seconds())+1))
+	}
+	jsonError(w, status, msg)
+	return false
+}
+
+// UsageAPI 当前上传者的配额与当天的用量：GET /api/me/usage
+func UsageAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+	user, ok := authUser(w, r, "")
+	if !ok {
+		return
+	}
+	checks, err := ownerQuotas(uploadOwner(r, user), user, "")
+	if err != nil {
+		log.Printf("查询上传配额失败: %v", err)
+		jsonError(w, http.StatusInternalServerError, "Failed to get usage")
+		return
+	}
+	_, reset := quotaDay()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(conf.ResponseResult{Code: 0, Message: "ok", Data: map[string]interface{}{
+		"quotas":  checks,
+		"resetAt": reset,
+	}})
+}
@@ -0,0 +1,198 @@
+package control
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"csz.net/tgstate/conf"
+)
+
+// setQuotaConf 设置默认配额，测试结束后恢复
+func setQuotaConf(t *testing.T, q Quota) {
+	old := globalQuota()
+	conf.QuotaDailyBytes, conf.QuotaDailyFiles, conf.QuotaTotalBytes = q.DailyBytes, q.DailyFiles, q.TotalBytes
+	t.Cleanup(func() {
+		conf.QuotaDailyBytes, conf.QuotaDailyFiles, conf.QuotaTotalBytes = old.DailyBytes, old.DailyFiles, old.TotalBytes
+	})
+}
+
+func TestQuotaOverride(t *testing.T) {
+	def := Quota{DailyBytes: 100, DailyFiles: 10, TotalBytes: 1000}
+	tests := []struct {
+		name   string
+		def, o Quota
+		want   Quota
+	}{
+		{"zero keeps the default", def, Quota{}, def},
+		{"positive replaces", def, Quota{DailyBytes: 5, DailyFiles: 6, TotalBytes: 7}, Quota{DailyBytes: 5, DailyFiles: 6, TotalBytes: 7}},
+		{"negative is unlimited", def, Quota{DailyBytes: -1, DailyFiles: -1, TotalBytes: -1}, Quota{}},
+		{"mixed", def, Quota{DailyBytes: -1, TotalBytes: 2000}, Quota{DailyFiles: 10, TotalBytes: 2000}},
+		{"no default", Quota{}, Quota{DailyFiles: 3}, Quota{DailyFiles: 3}},
+	}
+	for _, tt := range tests {
+		if got := tt.def.override(tt.o); got != tt.want {
+			t.Errorf("%s: override = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// quotaStatus 调用 checkQuota，返回状态码与 Retry-After
+func quotaStatus(owner UploadOwner, user *User, size, files int64, excludeUpload string) (int, string) {
+	w := httptest.NewRecorder()
+	if checkQuota(w, owner, user, size, files, excludeUpload) {
+		return http.StatusOK, ""
+	}
+	return w.Code, w.Header().Get("Retry-After")
+}
+
+func TestCheckQuota(t *testing.T) {
+	openTestDB(t)
+	setQuotaConf(t, Quota{DailyBytes: 1000, DailyFiles: 3, TotalBytes: 5000})
+	owner := UploadOwner{Ip: "203.0.113.7"}
+
+	// 昨天的文件只计入累计字节数
+	if _, err := db.Exec("INSERT INTO uploaded_files (fileId, filename, ip, size, time) VALUES (?, ?, ?, ?, ?)",
+		"old", "old.bin", owner.Ip, 3500, time.Now().UTC().Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveFileRecord(FileRecord{FileId: "today", Filename: "today.bin", Ip: owner.Ip, Size: 600}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		size, files int64
+		status      int
+	}{
+		{"fits", 300, 1, http.StatusOK},
+		{"over the daily bytes", 500, 1, http.StatusTooManyRequests},
+		{"larger than the daily quota", 1001, 1, http.StatusRequestEntityTooLarge},
+		{"over the total bytes", 950, 0, http.StatusRequestEntityTooLarge},
+		{"over the daily files", 0, 3, http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		status, retryAfter := quotaStatus(owner, nil, tt.size, tt.files, "")
+		if status != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, status, tt.status)
+			continue
+		}
+		// 只有按天的配额在 Retry-After 中给出重置时间
+		if tt.status == http.StatusTooManyRequests {
+			if secs, err := strconv.Atoi(retryAfter); err != nil || secs <= 0 || secs > 24*3600+1 {
+				t.Errorf("%s: Retry-After = %q", tt.name, retryAfter)
+			}
+		} else if retryAfter != "" {
+			t.Errorf("%s: unexpected Retry-After %q", tt.name, retryAfter)
+		}
+	}
+
+	// 其他 IP 的用量互不影响
+	if status, _ := quotaStatus(UploadOwner{Ip: "203.0.113.8"}, nil, 900, 1, ""); status != http.StatusOK {
+		t.Errorf("other IP: status = %d, want 200", status)
+	}
+	// 管理员不受配额限制
+	admin, err := CreateUser("admin", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin.Role = roleAdmin
+	if status, _ := quotaStatus(UploadOwner{UserId: admin.Id, Ip: owner.Ip}, &admin, 1e9, 100, ""); status != http.StatusOK {
+		t.Errorf("admin: status = %d, want 200", status)
+	}
+}
+
+func TestQuotaPendingChunks(t *testing.T) {
+	openTestDB(t)
+	setQuotaConf(t, Quota{DailyBytes: 1000})
+	owner := UploadOwner{Ip: "203.0.113.7"}
+	for i, size := range []int64{400, 400} {
+		if err := SaveChunkRecord("up1", strconv.Itoa(i), "chunk"+strconv.Itoa(i), "big.bin", "", size, owner); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	usage, err := GetUsage("ip", owner.Ip, time.Now().Add(-time.Hour), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.DailyBytes != 800 || usage.TotalBytes != 800 || usage.DailyFiles != 0 {
+		t.Errorf("usage with pending chunks = %+v", usage)
+	}
+	// 尚未合并的分片占用配额
+	if status, _ := quotaStatus(owner, nil, 300, 1, ""); status != http.StatusTooManyRequests {
+		t.Errorf("new upload: status = %d, want 429", status)
+	}
+	// 合并时不重复计算本次上传的分片
+	if status, _ := quotaStatus(owner, nil, 800, 1, "up1"); status != http.StatusOK {
+		t.Errorf("merging up1: status = %d, want 200", status)
+	}
+}
+
+// mergeStatus 以 JSON 调用 MergeChunksAPI，返回状态码
+func mergeStatus(t *testing.T, ip string, body map[string]interface{}) int {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	r := httptest.NewRequest(http.MethodPost, "/api/merge", bytes.NewReader(data))
+	r.RemoteAddr = ip + ":1234"
+	r.Header.Set("Content-Type", "application/json")
+	return serve(MergeChunksAPI, r).Code
+}
+
+func TestMergeChunksUsesChunkRecords(t *testing.T) {
+	openTestDB(t)
+	setQuotaConf(t, Quota{})
+	old := conf.AnonymousUpload
+	conf.AnonymousUpload = true
+	t.Cleanup(func() { conf.AnonymousUpload = old })
+
+	owner := UploadOwner{Ip: "203.0.113.7"}
+	for _, c := range []struct {
+		upload, index string
+		owner         UploadOwner
+	}{
+		{"mine", "0", owner},
+		{"mine", "1", owner},
+		{"gap", "0", owner},
+		{"gap", "2", owner},
+		{"mixed", "0", owner},
+		{"mixed", "1", UploadOwner{Ip: "198.51.100.1"}},
+		{"user", "0", UploadOwner{UserId: 42, Ip: owner.Ip}},
+	} {
+		if err := SaveChunkRecord(c.upload, c.index, "chunk-"+c.upload+c.index, "file.bin", "", 100, c.owner); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		ip     string
+		body   map[string]interface{}
+		status int
+	}{
+		{"no chunks", owner.Ip, map[string]interface{}{"uploadId": "none", "fileName": "f", "fileSize": 0}, http.StatusBadRequest},
+		{"another IP", "198.51.100.1", map[string]interface{}{"uploadId": "mine", "fileName": "f", "fileSize": 200}, http.StatusForbidden},
+		{"another uploader's chunk", owner.Ip, map[string]interface{}{"uploadId": "mixed", "fileName": "f", "fileSize": 200}, http.StatusForbidden},
+		{"an account's upload", owner.Ip, map[string]interface{}{"uploadId": "user", "fileName": "f", "fileSize": 100}, http.StatusForbidden},
+		{"missing chunk", owner.Ip, map[string]interface{}{"uploadId": "gap", "fileName": "f", "fileSize": 200}, http.StatusBadRequest},
+		// 分片记录共 200 字节，请求中的 chunkIds 不影响大小
+		{"size mismatch", owner.Ip, map[string]interface{}{"uploadId": "mine", "fileName": "f", "fileSize": 100, "chunkIds": []string{"chunk-mine0"}}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if status := mergeStatus(t, tt.ip, tt.body); status != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, status, tt.status)
+		}
+	}
+
+	records, err := GetChunkRecords("mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].ChunkId != "chunk-mine0" || records[1].ChunkId != "chunk-mine1" || records[1].Size != 100 || records[1].Ip != owner.Ip {
+		t.Errorf("GetChunkRecords = %+v", records)
+	}
+}
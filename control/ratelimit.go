@@ -0,0 +1,110 @@
+package control
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateSweepInterval 清理空闲令牌桶的间隔
+const rateSweepInterval = time.Minute
+
+// rateLimiter 按客户端 IP 的令牌桶限流，每个 IP 最多积累 burst 个令牌，每秒补充 rate 个
+type rateLimiter struct {
+	rate      float64
+	burst     float64
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// parseRate 解析限流参数，格式为 次数/时长，例如 20/1m 表示每分钟 20 次，突发上限同为 20。
+// 空、0 或 off 表示不限流，返回 nil
+func parseRate(spec string) (*rateLimiter, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "0" || spec == "off" {
+		return nil, nil
+	}
+	count, period, ok := strings.Cut(spec, "/")
+	if !ok {
+		return nil, fmt.Errorf("expected count/duration, e.g. 20/1m")
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid count %q", count)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return nil, fmt.Errorf("invalid duration %q", period)
+	}
+	return &rateLimiter{rate: float64(n) / d.Seconds(), burst: float64(n), buckets: make(map[string]*tokenBucket)}, nil
+}
+
+// allow 消耗 key 的一个令牌，令牌不足时返回需要等待的时间
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) > rateSweepInterval {
+		// 补满的桶与新建的桶相同，可以删除
+		for k, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// RateLimit 按客户端 IP 限制请求频率，超出时返回 429 并在 Retry-After 中给出需要等待的秒数。
+// spec 为 parseRate 的格式，不限流时直接返回 next
+func RateLimit(name, spec string, next http.HandlerFunc) http.HandlerFunc {
+	return RateLimiter(name, spec)(next)
+}
+
+// RateLimiter 返回共用同一组令牌桶的限流中间件，用于多个接口合计限流（如登录与注册）
+func RateLimiter(name, spec string) func(http.HandlerFunc) http.HandlerFunc {
+	limiter, err := parseRate(spec)
+	if err != nil {
+		log.Fatalf("限流参数 %s 无效: %v", name, err)
+	}
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		if limiter == nil {
+			return next
+		}
+		return func(w http.ResponseWriter, r *http.Request) {
+			ok, wait := limiter.allow(clientIP(r), time.Now())
+			if ok {
+				next(w, r)
+				return
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			if strings.HasPrefix(r.URL.Path, "/api") {
+				jsonError(w, http.StatusTooManyRequests, "Too many requests, please retry later")
+			} else {
+				http.Error(w, "Too many requests, please retry later", http.StatusTooManyRequests)
+			}
+		}
+	}
+}
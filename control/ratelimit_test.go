@@ -0,0 +1,124 @@
+package control
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	for _, spec := range []string{"", "0", "off", " off "} {
+		if l, err := parseRate(spec); l != nil || err != nil {
+			t.Errorf("parseRate(%q) = %v, %v, want disabled", spec, l, err)
+		}
+	}
+	for _, spec := range []string{"20", "x/1m", "0/1m", "-1/1m", "20/", "20/abc", "20/0s", "20/-1m"} {
+		if _, err := parseRate(spec); err == nil {
+			t.Errorf("parseRate(%q): expected error", spec)
+		}
+	}
+	l, err := parseRate(" 30 / 1m ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.burst != 30 || l.rate != 0.5 {
+		t.Errorf("parseRate(30/1m): burst %v, rate %v", l.burst, l.rate)
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	l, err := parseRate("3/3s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	// 突发上限内全部放行
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.allow("a", now); !ok {
+			t.Fatalf("request %d within burst rejected", i)
+		}
+	}
+	ok, wait := l.allow("a", now)
+	if ok || wait != time.Second {
+		t.Fatalf("over burst: ok %v, wait %v, want 1s", ok, wait)
+	}
+	// 其他 key 使用自己的令牌桶
+	if ok, _ := l.allow("b", now); !ok {
+		t.Fatal("another key rejected")
+	}
+	// 补充的令牌不足一个时给出剩余等待时间
+	if ok, wait := l.allow("a", now.Add(400*time.Millisecond)); ok || wait != 600*time.Millisecond {
+		t.Fatalf("partial refill: ok %v, wait %v, want 600ms", ok, wait)
+	}
+	if ok, _ := l.allow("a", now.Add(time.Second)); !ok {
+		t.Fatal("refilled token rejected")
+	}
+	// 长时间空闲后最多积累 burst 个令牌
+	later := now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.allow("a", later); !ok {
+			t.Fatalf("request %d after idle rejected", i)
+		}
+	}
+	if ok, _ := l.allow("a", later); ok {
+		t.Fatal("burst exceeded after idle")
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	l, err := parseRate("2/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	l.allow("idle", now)
+	l.allow("busy", now)
+	l.allow("busy", now)
+
+	// 超过清理间隔后，已补满的桶被删除，仍在补充的桶保留
+	l.allow("new", now.Add(rateSweepInterval/2))
+	l.allow("busy", now.Add(rateSweepInterval/2))
+	at := now.Add(rateSweepInterval + time.Second)
+	l.allow("other", at)
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("refilled bucket was not swept")
+	}
+	if _, ok := l.buckets["busy"]; !ok {
+		t.Error("bucket still refilling was swept")
+	}
+	// new 在 30 秒前用掉一个令牌，到清理时已补满
+	if _, ok := l.buckets["new"]; ok {
+		t.Error("bucket refilled since its last use was not swept")
+	}
+	if len(l.buckets) != 2 {
+		t.Errorf("buckets = %d, want 2 (busy, other)", len(l.buckets))
+	}
+}
+
+func TestRateLimiterSharedBucket(t *testing.T) {
+	limit := RateLimiter("rateAuth", "2/1m")
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	login, register := limit(ok), limit(ok)
+
+	status := func(h http.HandlerFunc, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, nil)
+		r.RemoteAddr = "203.0.113.9:1234"
+		return serve(h, r)
+	}
+	if w := status(login, "/api/login"); w.Code != http.StatusOK {
+		t.Fatalf("first login: status %d", w.Code)
+	}
+	if w := status(register, "/api/register"); w.Code != http.StatusOK {
+		t.Fatalf("first register: status %d", w.Code)
+	}
+	// 登录与注册共用令牌桶
+	w := status(login, "/api/login")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Errorf("third request: status %d, Retry-After %q, want 429 and 30", w.Code, w.Header().Get("Retry-After"))
+	}
+	if RateLimiter("rateAuth", "off")(ok) == nil {
+		t.Error("disabled limiter returned nil handler")
+	}
+}
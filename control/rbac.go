@@ -0,0 +1,100 @@
+package control
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"csz.net/tgstate/conf"
+)
+
+// 角色与权限：权限与 API 密钥的 scope 相同（upload、read、admin）。
+// 账号的权限由角色决定，API 密钥的权限为其 scope 与所属账号权限的交集。
+// 接口统一通过 authUser（上传、查看）与 requireAdmin（管理）校验，不在各处单独判断
+
+const (
+	roleAdmin    = "admin"
+	roleUploader = "uploader"
+	roleViewer   = "viewer"
+)
+
+// rolePermissions 各角色拥有的权限
+var rolePermissions = map[string][]string{
+	roleAdmin:    {scopeUpload, scopeRead, scopeAdmin},
+	roleUploader: {scopeUpload, scopeRead},
+	roleViewer:   {scopeRead},
+}
+
+// 封禁的类型
+const (
+	banIP          = "ip"
+	banFingerprint = "fingerprint"
+	banUser        = "user"
+)
+
+// role 返回账号实际的角色：admins 参数中的账号 id 总是管理员，未设置角色时为 defaultRole。
+// admins 按 id 而不是用户名匹配，用户名可以被注册、OIDC 或 Telegram 登录抢先占用
+func (u *User) role() string {
+	id := strconv.FormatInt(u.Id, 10)
+	for _, admin := range strings.Split(conf.Admins, ",") {
+		if strings.TrimSpace(admin) == id {
+			return roleAdmin
+		}
+	}
+	if _, ok := rolePermissions[u.Role]; ok {
+		return u.Role
+	}
+	if _, ok := rolePermissions[conf.DefaultRole]; ok {
+		return conf.DefaultRole
+	}
+	return roleUploader
+}
+
+// can 判断账号的角色是否有指定权限
+func (u *User) can(perm string) bool {
+	return containsString(rolePermissions[u.role()], perm)
+}
+
+// requestCan 判断请求是否有指定权限：携带 API 密钥时同时要求密钥的 scope
+func requestCan(r *http.Request, user *User, perm string) bool {
+	if user == nil || !user.can(perm) {
+		return false
+	}
+	if bearerToken(r) != "" {
+		key, _, valid := requestAPIKey(r)
+		return valid && key != nil && key.HasScope(perm)
+	}
+	return true
+}
+
+// uploadBanned 判断上传请求的 IP、浏览器指纹或账号是否被封禁
+func uploadBanned(r *http.Request, user *User, fingerprint string) bool {
+	return IsBanned(banIP, clientIP(r)) || IsBanned(banFingerprint, fingerprint) ||
+		(user != nil && IsBanned(banUser, strconv.FormatInt(user.Id, 10)))
+}
+
+// apiPassGiven 请求中是否提交了管理密码
+func apiPassGiven(r *http.Request) bool {
+	return conf.ApiPass != "" && bearerToken(r) == "" && requestSecret(r, apiPassHeader, "password") != ""
+}
+
+// requireAdmin 校验管理接口的权限：管理密码（apiPass）、管理员账号的会话或带 admin 权限的 API 密钥。
+// 管理密码通过时返回的账号为 nil
+func requireAdmin(w http.ResponseWriter, r *http.Request) (*User, bool) {
+	if apiPassGiven(r) {
+		if secretEqual(requestSecret(r, apiPassHeader, "password"), conf.ApiPass) {
+			return nil, true
+		}
+		jsonError(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, false
+	}
+	user, ok := authUser(w, r, scopeAdmin)
+	if !ok {
+		return nil, false
+	}
+	if user == nil {
+		jsonError(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, false
+	}
+	return user, true
+}
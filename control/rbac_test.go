@@ -0,0 +1,28 @@
+package control
+
+import (
+	"testing"
+
+	"csz.net/tgstate/conf"
+)
+
+func TestAdminsMatchAccountId(t *testing.T) {
+	oldAdmins, oldRole := conf.Admins, conf.DefaultRole
+	conf.Admins, conf.DefaultRole = " 3, 7", roleUploader
+	t.Cleanup(func() { conf.Admins, conf.DefaultRole = oldAdmins, oldRole })
+
+	for _, tt := range []struct {
+		user User
+		want string
+	}{
+		{User{Id: 3, Username: "alice"}, roleAdmin},
+		{User{Id: 7, Username: "bob", Role: roleViewer}, roleAdmin},
+		// 用户名与 admins 中的 id 相同也不是管理员
+		{User{Id: 4, Username: "3"}, roleUploader},
+		{User{Id: 5, Username: "carol", Role: roleViewer}, roleViewer},
+	} {
+		if got := tt.user.role(); got != tt.want {
+			t.Errorf("user %d (%q) role = %q, want %q", tt.user.Id, tt.user.Username, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package control
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"csz.net/tgstate/utils"
+)
+
+// 重建索引：数据库丢失后，频道中的文件仍在但无法按名称访问。
+// 运行期间上传或直接发到频道的文件会记录到 channel_messages；
+// 更早的消息通过 Telegram Desktop 导出的 result.json 逐条转发取得 file_id。
+// RebuildIndex 再根据 channel_messages 补齐 uploaded_files，分片文件的元数据会被识别，其分片不会作为单独的文件出现
+
+var (
+	// chunkNamePattern ChunkUploadAPI 上传的分片：{文件名}.chunk.{序号}
+	chunkNamePattern = regexp.MustCompile(`\.chunk\.\d+$`)
+	// segmentNamePattern HLS 分片：{文件名}.seg00001.ts
+	segmentNamePattern = regexp.MustCompile(`\.seg\d{5}\.ts$`)
+
+	// storedMagics compressUpload 压缩后的内容开头，用于恢复 Encoding
+	storedMagics = map[string][]byte{"gzip": {0x1f, 0x8b}, "zstd": {0x28, 0xb5, 0x2f, 0xfd}}
+	// compressedExts 本身就是压缩包的扩展名，这类文件开头的魔数不代表存储时压缩过
+	compressedExts = map[string]string{".gz": "gzip", ".tgz": "gzip", ".zst": "zstd", ".tzst": "zstd"}
+)
+
+// CaptureChannelFiles 记录之后上传或发到频道的文件消息
+func CaptureChannelFiles() {
+	utils.OnChannelFile = func(file utils.ChannelFile) {
+		if err := SaveChannelMessage(file); err != nil {
+			log.Printf("记录频道消息失败: %v", err)
+		}
+	}
+}
+
+// channelExport Telegram Desktop 导出的频道记录（result.json）
+type channelExport struct {
+	Id       int64           `json:"id"`
+	Messages []exportMessage `json:"messages"`
+}
+
+type exportMessage struct {
+	Id           int    `json:"id"`
+	Type         string `json:"type"`
+	Date         string `json:"date"`
+	DateUnixtime string `json:"date_unixtime"`
+	File         string `json:"file"`
+	FileName     string `json:"file_name"`
+	Photo        string `json:"photo"`
+	MediaType    string `json:"media_type"`
+	MimeType     string `json:"mime_type"`
+}
+
+// exportChatId 导出文件中的频道 ID 不带 -100 前缀，转换为 Bot API 使用的 ID
+func exportChatId(id int64) int64 {
+	if id > 0 {
+		return -1000000000000 - id
+	}
+	return id
+}
+
+// name 导出文件中记录的文件名，未导出文件时路径为提示文字
+func (m exportMessage) name() string {
+	if m.FileName != "" {
+		return m.FileName
+	}
+	for _, p := range []string{m.File, m.Photo} {
+		if p != "" && !strings.HasPrefix(p, "(") {
+			return path.Base(p)
+		}
+	}
+	return ""
+}
+
+func (m exportMessage) time() time.Time {
+	if unix, err := strconv.ParseInt(m.DateUnixtime, 10, 64); err == nil {
+		return time.Unix(unix, 0)
+	}
+	t, _ := time.ParseInLocation("2006-01-02T15:04:05", m.Date, time.Local)
+	return t
+}
+
+// ImportChannelExport 读取 Telegram Desktop 导出的 result.json，把其中尚未记录的文件消息转发到 scratch 会话取得 file_id 并记录。
+// delay 为两次转发的间隔，避免触发限流
+func ImportChannelExport(exportPath, scratch string, delay time.Duration) (imported, failed int, err error) {
+	data, err := os.ReadFile(exportPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	var export channelExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return 0, 0, fmt.Errorf("parse %s: %v", exportPath, err)
+	}
+	chatId := exportChatId(export.Id)
+	for _, msg := range export.Messages {
+		if msg.Type != "message" || (msg.File == "" && msg.Photo == "") || ChannelMessageExists(chatId, msg.Id) {
+			continue
+		}
+		file, err := utils.ForwardChannelFile(msg.Id, scratch)
+		if err != nil {
+			log.Printf("转发消息 %d 失败: %v", msg.Id, err)
+			failed++
+		} else {
+			file.ChatId = chatId
+			if file.FileName == "" {
+				file.FileName = msg.name()
+			}
+			if file.MimeType == "" {
+				file.MimeType = msg.MimeType
+			}
+			if t := msg.time(); !t.IsZero() {
+				file.Date = t
+			}
+			if err := SaveChannelMessage(file); err != nil {
+				return imported, failed, err
+			}
+			imported++
+		}
+		time.Sleep(delay)
+	}
+	return imported, failed, nil
+}
+
+// RebuildResult 重建索引的统计
+type RebuildResult struct {
+	Added     int      // 新增的文件记录
+	Existing  int      // 已有记录的文件
+	Manifests int      // 识别出的分片文件元数据
+	Chunks    int      // 属于分片文件的分片
+	Skipped   int      // HLS 分片、备份、疑似加密等没有补齐的文件
+	Encrypted []string // 疑似端到端加密的文件（文件名与 file_id），解密参数只在数据库中，无法恢复
+}
+
+// inspectStored 按文件开头的内容判断存储方式：encoding 为上传时压缩使用的算法；
+// 扩展名对应可识别的类型、内容却无法识别时，encrypted 为 true，通常是端到端加密的密文
+func inspectStored(fileName string, head []byte) (encoding string, encrypted bool) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	for name, magic := range storedMagics {
+		if bytes.HasPrefix(head, magic) && compressedExts[ext] != name {
+			return name, false
+		}
+	}
+	expected := mime.TypeByExtension(ext)
+	if expected == "" || len(head) == 0 {
+		return "", false
+	}
+	sniffed := http.DetectContentType(head)
+	// 密文看起来是随机字节，http.DetectContentType 只能给出 application/octet-stream
+	return "", strings.HasPrefix(sniffed, "application/octet-stream") && sniffable(expected)
+}
+
+// sniffable 判断 http.DetectContentType 能否从内容识别出该 MIME 类型，识别不了的类型无法据此判断是否加密
+func sniffable(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasPrefix(mediaType, "text/"), compressibleType(mediaType):
+		return true
+	case strings.HasPrefix(mediaType, "image/"):
+		return mediaType != "image/svg+xml"
+	}
+	switch mediaType {
+	case "application/pdf", "application/zip", "application/gzip", "application/x-gzip", "application/x-rar-compressed",
+		"application/vnd.rar", "audio/mpeg", "audio/wave", "audio/wav", "audio/ogg", "video/mp4", "video/webm", "video/avi":
+		return true
+	}
+	return false
+}
+
+// readHead 读取文件开头用于嗅探的字节
+func readHead(fileId string) ([]byte, error) {
+	body, err := utils.OpenFile(fileId)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(body, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// decompressedSize 解压存储的文件，返回原始大小
+func decompressedSize(fileId, encoding string) (int64, error) {
+	body, err := utils.OpenFile(fileId)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+	zr, err := decompressors[encoding](body)
+	if err != nil {
+		return 0, err
+	}
+	defer zr.Close()
+	return io.Copy(io.Discard, zr)
+}
+
+// RebuildIndex 根据 channel_messages 补齐 uploaded_files，dryRun 时只统计不写入
+func RebuildIndex(dryRun bool) (RebuildResult, error) {
+	var result RebuildResult
+	files, err := GetChannelMessages()
+	if err != nil {
+		return result, err
+	}
+
+	// 先识别分片文件的元数据，记下所有分片的 file_unique_id。
+	// 元数据中保存的是 file_id，同一文件的 file_id 可能变化，因此按 file_unique_id 匹配
+	uniqueIds := make(map[string]string, len(files))
+	for _, file := range files {
+		uniqueIds[file.FileId] = file.FileUniqueId
+	}
+	manifests := make(map[int]utils.BlobManifest)
+	chunks := make(map[string]bool)
+	for i, file := range files {
+		if file.FileName != "blob" || file.FileSize > 1<<20 {
+			continue
+		}
+		manifest, ok, err := utils.ReadBlobManifest(file.FileId)
+		if err != nil {
+			log.Printf("读取消息 %d 失败: %v", file.MessageId, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		manifests[i] = manifest
+		for _, chunkId := range manifest.ChunkIds {
+			uniqueId, ok := uniqueIds[chunkId]
+			if !ok || uniqueId == "" {
+				if uniqueId, err = utils.GetFileUniqueId(chunkId); err != nil {
+					log.Printf("查询分片 %s 失败: %v", chunkId, err)
+					continue
+				}
+			}
+			chunks[uniqueId] = true
+		}
+	}
+
+	for i, file := range files {
+		record := FileRecord{FileId: file.FileId, Filename: file.FileName, Size: file.FileSize, StoredSize: file.FileSize, Time: file.Date}
+		if manifest, ok := manifests[i]; ok {
+			result.Manifests++
+			record.Filename, record.Size, record.StoredSize = manifest.FileName, manifest.Size, manifest.Size
+		} else if chunks[file.FileUniqueId] || chunkNamePattern.MatchString(file.FileName) {
+			result.Chunks++
+			continue
+		} else if file.FileName == "blob" {
+			result.Skipped++
+			continue
+		}
+		if segmentNamePattern.MatchString(record.Filename) || strings.HasPrefix(record.Filename, backupPrefix) {
+			result.Skipped++
+			continue
+		}
+		if record.Filename == "" {
+			record.Filename = fallbackFileName(file)
+		}
+		if _, err := GetFileById(file.FileId); err == nil {
+			result.Existing++
+			continue
+		}
+		// 按内容识别压缩存储与疑似端到端加密的文件，分片文件只检查第一个分片
+		manifest, isManifest := manifests[i]
+		probe := file.FileId
+		if isManifest && len(manifest.ChunkIds) > 0 {
+			probe = manifest.ChunkIds[0]
+		}
+		head, err := readHead(probe)
+		if err != nil {
+			log.Printf("读取消息 %d 失败: %v", file.MessageId, err)
+		}
+		encoding, encrypted := inspectStored(record.Filename, head)
+		if encrypted {
+			result.Skipped++
+			result.Encrypted = append(result.Encrypted, fmt.Sprintf("%s (%s)", record.Filename, file.FileId))
+			continue
+		}
+		if encoding != "" && !isManifest {
+			size, err := decompressedSize(file.FileId, encoding)
+			if err != nil {
+				log.Printf("解压消息 %d 失败: %v", file.MessageId, err)
+			} else {
+				record.Encoding, record.Size = encoding, size
+			}
+		}
+		result.Added++
+		if dryRun {
+			continue
+		}
+		if err := SaveFileRecord(record); err != nil {
+			return result, err
+		}
+		if isManifest {
+			if _, err := SaveManifestRecord(ManifestRecord{FileId: file.FileId, FileName: manifest.FileName, Size: manifest.Size, ChunkIds: manifest.ChunkIds}); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// fallbackFileName 照片等没有文件名的消息按类型和消息 ID 命名
+func fallbackFileName(file utils.ChannelFile) string {
+	ext := ""
+	if file.MediaType == "photo" {
+		ext = ".jpg"
+	} else if exts, _ := mime.ExtensionsByType(file.MimeType); len(exts) > 0 {
+		ext = exts[0]
+	}
+	return fmt.Sprintf("%s_%d%s", file.MediaType, file.MessageId, ext)
+}
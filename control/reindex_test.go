@@ -0,0 +1,49 @@
+package control
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestInspectStored(t *testing.T) {
+	var gz bytes.Buffer
+	zw, _ := compressors["gzip"](&gz)
+	zw.Write([]byte("hello"))
+	zw.Close()
+	var zst bytes.Buffer
+	zw, _ = compressors["zstd"](&zst)
+	zw.Write([]byte("hello"))
+	zw.Close()
+	cipher := make([]byte, 512)
+	rand.Read(cipher)
+
+	tests := []struct {
+		name      string
+		head      []byte
+		encoding  string
+		encrypted bool
+	}{
+		{"app.log", gz.Bytes(), "gzip", false},
+		{"data.json", zst.Bytes(), "zstd", false},
+		// 压缩包本身不是上传时压缩的
+		{"backup.tar.gz", gz.Bytes(), "", false},
+		{"dump.zst", zst.Bytes(), "", false},
+		{"notes.txt", []byte("hello world"), "", false},
+		{"photo.png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), "", false},
+		// 扩展名对应可识别的格式，内容却是随机字节
+		{"notes.txt", cipher, "", true},
+		{"photo.jpg", cipher, "", true},
+		{"report.pdf", cipher, "", true},
+		// 无法从内容识别的类型不判断为加密
+		{"blob.bin", cipher, "", false},
+		{"noext", cipher, "", false},
+		{"empty.txt", nil, "", false},
+	}
+	for _, tt := range tests {
+		encoding, encrypted := inspectStored(tt.name, tt.head)
+		if encoding != tt.encoding || encrypted != tt.encrypted {
+			t.Errorf("inspectStored(%q) = %q, %v, want %q, %v", tt.name, encoding, encrypted, tt.encoding, tt.encrypted)
+		}
+	}
+}
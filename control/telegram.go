@@ -0,0 +1,292 @@
+package control
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"csz.net/tgstate/assets"
+	"csz.net/tgstate/conf"
+	"csz.net/tgstate/utils"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// 使用 Telegram 登录：网页中的 Login Widget 与 Web App 的 initData 都由 Telegram 用 Bot Token 签名，
+// 校验通过后按 Telegram 用户 ID 关联账号。私聊发给 Bot 的文件也记录到同一账号
+
+const (
+	// telegramProvider user_identities 中 Telegram 身份的 provider，subject 为 Telegram 用户 ID
+	telegramProvider = "telegram"
+	// telegramAuthMaxAge 登录数据的有效期，超过后需要重新授权
+	telegramAuthMaxAge = 24 * time.Hour
+)
+
+var errTelegramRegistration = errors.New("Registration is disabled, log in on the website and link your Telegram account first")
+
+// telegramUser 登录数据中的 Telegram 用户
+type telegramUser struct {
+	Id        int64  `json:"id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+}
+
+// checkTelegramHash 按 Telegram 的规则校验签名：除 hash 外的字段按键排序后以 key=value 换行拼接，再用 secret 计算 HMAC-SHA256
+func checkTelegramHash(values url.Values, secret []byte) error {
+	hash := values.Get("hash")
+	if hash == "" {
+		return errors.New("missing hash")
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if key != "hash" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = key + "=" + values.Get(key)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(strings.ToLower(hash)), []byte(expected)) {
+		return errors.New("invalid hash")
+	}
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return errors.New("invalid auth_date")
+	}
+	if time.Since(time.Unix(authDate, 0)) > telegramAuthMaxAge {
+		return errors.New("login data expired")
+	}
+	return nil
+}
+
+// verifyLoginWidget 校验 Login Widget 的数据，密钥为 SHA256(Bot Token)
+func verifyLoginWidget(values url.Values) (telegramUser, error) {
+	secret := sha256.Sum256([]byte(conf.BotToken))
+	if err := checkTelegramHash(values, secret[:]); err != nil {
+		return telegramUser{}, err
+	}
+	id, err := strconv.ParseInt(values.Get("id"), 10, 64)
+	if err != nil {
+		return telegramUser{}, errors.New("invalid id")
+	}
+	return telegramUser{Id: id, Username: values.Get("username"), FirstName: values.Get("first_name")}, nil
+}
+
+// verifyWebAppInitData 校验 Web App 的 initData，密钥为 HMAC-SHA256("WebAppData", Bot Token)
+func verifyWebAppInitData(initData string) (telegramUser, error) {
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return telegramUser{}, errors.New("invalid initData")
+	}
+	mac := hmac.New(sha256.New, []byte("WebAppData"))
+	mac.Write([]byte(conf.BotToken))
+	if err := checkTelegramHash(values, mac.Sum(nil)); err != nil {
+		return telegramUser{}, err
+	}
+	var user telegramUser
+	if err := json.Unmarshal([]byte(values.Get("user")), &user); err != nil || user.Id == 0 {
+		return telegramUser{}, errors.New("initData has no user")
+	}
+	return user, nil
+}
+
+// telegramAccount 返回 Telegram 用户关联的账号。没有关联账号且 current 不为空时把 Telegram 身份关联到 current；
+// 没有关联账号时按 create 决定是否创建
+func telegramAccount(tg telegramUser, current *User, create bool) (User, error) {
+	subject := strconv.FormatInt(tg.Id, 10)
+	user, err := GetUserByIdentity(telegramProvider, subject)
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return user, err
+	}
+	if current != nil {
+		if err := LinkIdentity(current.Id, telegramProvider, subject); err != nil {
+			return User{}, err
+		}
+		return *current, nil
+	}
+	if !create {
+		return User{}, errTelegramRegistration
+	}
+	name := tg.Username
+	if name == "" {
+		name = "tg_" + subject
+	}
+	return identityUser(telegramProvider, subject, sanitizeUsername(name, subject))
+}
+
+// TelegramLogin Login Widget 登录页：GET /auth/telegram，
+// Widget 授权后带着签名数据跳转回本地址，校验通过后登录并跳转到首页
+func TelegramLogin(w http.ResponseWriter, r *http.Request) {
+	if !conf.TelegramLogin {
+		http.NotFound(w, r)
+		return
+	}
+	if r.URL.Query().Get("hash") == "" {
+		serveTelegramLogin(w)
+		return
+	}
+	tg, err := verifyLoginWidget(r.URL.Query())
+	if err != nil {
+		http.Error(w, "Telegram login failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+	// 跳转登录可能由其他站点发起，不关联到当前账号，关联只能通过带 CSRF 令牌的 TelegramLoginAPI
+	user, err := telegramAccount(tg, nil, conf.Registration)
+	if errors.Is(err, errTelegramRegistration) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	} else if err != nil {
+		log.Printf("Telegram 登录失败: %v", err)
+		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		return
+	}
+	if _, err := startSession(w, r, user); err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// serveTelegramLogin 输出包含 Login Widget 的页面，在 Telegram Web App 中打开时自动提交 initData
+func serveTelegramLogin(w http.ResponseWriter) {
+	file, err := assets.Templates.ReadFile("templates/telegram.tmpl")
+	if err != nil {
+		http.Error(w, "HTML file not found", http.StatusNotFound)
+		return
+	}
+	headerFile, err := assets.Templates.ReadFile("templates/header.tmpl")
+	if err != nil {
+		http.Error(w, "Header template not found", http.StatusNotFound)
+		return
+	}
+	tmpl := template.New("html")
+	if tmpl, err = tmpl.Parse(string(headerFile)); err != nil {
+		http.Error(w, "Error parsing Header template", http.StatusInternalServerError)
+		return
+	}
+	if tmpl, err = tmpl.Parse(string(file)); err != nil {
+		http.Error(w, "Error parsing File template", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	if err := tmpl.Execute(w, map[string]interface{}{"Bot": utils.BotUsername()}); err != nil {
+		http.Error(w, "Error rendering HTML template", http.StatusInternalServerError)
+	}
+}
+
+// TelegramLoginAPI 使用 Telegram 登录：POST /api/auth/telegram，
+// 请求体为 {"initData":"..."}（Web App）或 Login Widget 回调的字段 {"id":...,"hash":"..."}
+func TelegramLoginAPI(w http.ResponseWriter, r *http.Request) {
+	if !conf.TelegramLogin {
+		jsonError(w, http.StatusNotFound, "Telegram login is disabled")
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+	// 已登录时关联到当前账号，需要 CSRF 令牌
+	current, ok := authUser(w, r, "")
+	if !ok {
+		return
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	var tg telegramUser
+	var err error
+	if initData, ok := body["initData"].(string); ok {
+		tg, err = verifyWebAppInitData(initData)
+	} else {
+		// Widget 的字段值有数字也有字符串，统一转换为字符串后校验
+		values := url.Values{}
+		for key, value := range body {
+			switch v := value.(type) {
+			case string:
+				values.Set(key, v)
+			case float64:
+				values.Set(key, strconv.FormatFloat(v, 'f', -1, 64))
+			}
+		}
+		tg, err = verifyLoginWidget(values)
+	}
+	if err != nil {
+		jsonError(w, http.StatusUnauthorized, "Telegram login failed: "+err.Error())
+		return
+	}
+	user, err := telegramAccount(tg, current, conf.Registration)
+	if errors.Is(err, errTelegramRegistration) {
+		jsonError(w, http.StatusForbidden, err.Error())
+		return
+	} else if err != nil {
+		log.Printf("Telegram 登录失败: %v", err)
+		jsonError(w, http.StatusInternalServerError, "Failed to create user")
+		return
+	}
+	csrfToken, err := startSession(w, r, user)
+	if err != nil {
+		jsonError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+	writeLogin(w, user, csrfToken)
+}
+
+// TelegramBotUpload 处理私聊发给 Bot 的文件：转存到频道并记录到发送者关联的账号，返回下载链接
+func TelegramBotUpload(from *tgbotapi.User, file utils.ChannelFile) (string, error) {
+	// 设置了访问密码时不自动创建账号，只接受已在网页上关联的 Telegram 用户
+	user, err := telegramAccount(telegramUser{Id: from.ID, Username: from.UserName, FirstName: from.FirstName}, nil, conf.Registration && !passRequired())
+	if errors.Is(err, errTelegramRegistration) {
+		return "", err
+	} else if err != nil {
+		log.Printf("查询 Telegram 用户失败: %v", err)
+		return "", errors.New("Upload failed, please try again later")
+	}
+	if !user.can(scopeUpload) || IsBanned(banUser, strconv.FormatInt(user.Id, 10)) {
+		return "", errors.New("Your account is not allowed to upload")
+	}
+	if status, msg, err := quotaExceeded(UploadOwner{UserId: user.Id}, &user, file.FileSize, 1, ""); err != nil {
+		log.Printf("查询上传配额失败: %v", err)
+		return "", errors.New("Upload failed, please try again later")
+	} else if status != 0 {
+		return "", errors.New(msg)
+	}
+	sent, err := utils.ResendToChannel(file)
+	if err != nil {
+		log.Printf("转存 Telegram 文件失败: %v", err)
+		return "", errors.New("Upload failed, please try again later")
+	}
+	if sent.FileName == "" {
+		sent.FileName = fallbackFileName(sent)
+	}
+	record := FileRecord{FileId: sent.FileId, Filename: sent.FileName, Ip: "", Size: sent.FileSize, StoredSize: sent.FileSize, UserId: user.Id}
+	if err := SaveFileRecord(record); err != nil {
+		log.Printf("保存文件记录失败: %v", err)
+		return "", errors.New("Upload failed, please try again later")
+	}
+	queueHLS(record)
+	return strings.TrimSuffix(conf.BaseUrl, "/") + conf.FileRoute + sent.FileId, nil
+}
+
+// CaptureBotUploads 开启 Telegram 登录时处理私聊发给 Bot 的文件
+func CaptureBotUploads() {
+	if conf.TelegramLogin {
+		utils.OnBotUpload = TelegramBotUpload
+	}
+}
@@ -0,0 +1,187 @@
+package control
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"csz.net/tgstate/conf"
+)
+
+const testBotToken = "123456:test-token"
+
+// setTelegramConf 启用 Telegram 登录并设置 Bot Token，测试结束后恢复
+func setTelegramConf(t *testing.T) {
+	oldToken, oldLogin, oldRegistration := conf.BotToken, conf.TelegramLogin, conf.Registration
+	conf.BotToken, conf.TelegramLogin, conf.Registration = testBotToken, true, true
+	t.Cleanup(func() { conf.BotToken, conf.TelegramLogin, conf.Registration = oldToken, oldLogin, oldRegistration })
+}
+
+// signTelegram 按 Telegram 的规则用 secret 给 values 签名，返回带 hash 的副本
+func signTelegram(values url.Values, secret []byte) url.Values {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = key + "=" + values.Get(key)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	signed := url.Values{}
+	for key, v := range values {
+		signed[key] = v
+	}
+	signed.Set("hash", hex.EncodeToString(mac.Sum(nil)))
+	return signed
+}
+
+func widgetSecret() []byte {
+	sum := sha256.Sum256([]byte(testBotToken))
+	return sum[:]
+}
+
+func webAppSecret() []byte {
+	mac := hmac.New(sha256.New, []byte("WebAppData"))
+	mac.Write([]byte(testBotToken))
+	return mac.Sum(nil)
+}
+
+func authDate(ago time.Duration) string {
+	return strconv.FormatInt(time.Now().Add(-ago).Unix(), 10)
+}
+
+func widgetValues(ago time.Duration) url.Values {
+	return url.Values{"id": {"123456789"}, "username": {"alice"}, "first_name": {"Alice"}, "auth_date": {authDate(ago)}}
+}
+
+func TestVerifyLoginWidget(t *testing.T) {
+	setTelegramConf(t)
+	valid := signTelegram(widgetValues(time.Minute), widgetSecret())
+	tampered := signTelegram(widgetValues(time.Minute), widgetSecret())
+	tampered.Set("username", "mallory")
+	missingHash := signTelegram(widgetValues(time.Minute), widgetSecret())
+	missingHash.Del("hash")
+	upper := signTelegram(widgetValues(time.Minute), widgetSecret())
+	upper.Set("hash", strings.ToUpper(upper.Get("hash")))
+
+	tests := []struct {
+		name   string
+		values url.Values
+		ok     bool
+	}{
+		{"valid", valid, true},
+		{"uppercase hash", upper, true},
+		{"tampered field", tampered, false},
+		{"missing hash", missingHash, false},
+		{"expired auth_date", signTelegram(widgetValues(telegramAuthMaxAge+time.Minute), widgetSecret()), false},
+		{"web app secret", signTelegram(widgetValues(time.Minute), webAppSecret()), false},
+		{"invalid auth_date", signTelegram(url.Values{"id": {"1"}, "auth_date": {"yesterday"}}, widgetSecret()), false},
+		{"invalid id", signTelegram(url.Values{"id": {"abc"}, "auth_date": {authDate(0)}}, widgetSecret()), false},
+	}
+	for _, tt := range tests {
+		user, err := verifyLoginWidget(tt.values)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok %v", tt.name, err, tt.ok)
+			continue
+		}
+		if tt.ok && (user.Id != 123456789 || user.Username != "alice" || user.FirstName != "Alice") {
+			t.Errorf("%s: user = %+v", tt.name, user)
+		}
+	}
+}
+
+func TestVerifyWebAppInitData(t *testing.T) {
+	setTelegramConf(t)
+	initData := func(user string, ago time.Duration, secret []byte) url.Values {
+		return signTelegram(url.Values{"user": {user}, "auth_date": {authDate(ago)}, "query_id": {"AAH"}}, secret)
+	}
+	aliceJSON := `{"id":123456789,"username":"alice","first_name":"Alice"}`
+	tampered := initData(aliceJSON, time.Minute, webAppSecret())
+	tampered.Set("user", `{"id":1,"username":"mallory"}`)
+	missingHash := initData(aliceJSON, time.Minute, webAppSecret())
+	missingHash.Del("hash")
+
+	tests := []struct {
+		name     string
+		initData string
+		ok       bool
+	}{
+		{"valid", initData(aliceJSON, time.Minute, webAppSecret()).Encode(), true},
+		{"tampered field", tampered.Encode(), false},
+		{"missing hash", missingHash.Encode(), false},
+		{"expired auth_date", initData(aliceJSON, telegramAuthMaxAge+time.Minute, webAppSecret()).Encode(), false},
+		{"login widget secret", initData(aliceJSON, time.Minute, widgetSecret()).Encode(), false},
+		{"no user", initData(`{}`, time.Minute, webAppSecret()).Encode(), false},
+		{"malformed", "%zz", false},
+	}
+	for _, tt := range tests {
+		user, err := verifyWebAppInitData(tt.initData)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok %v", tt.name, err, tt.ok)
+			continue
+		}
+		if tt.ok && (user.Id != 123456789 || user.Username != "alice") {
+			t.Errorf("%s: user = %+v", tt.name, user)
+		}
+	}
+}
+
+// telegramLogin 以 JSON 调用 TelegramLoginAPI
+func telegramLogin(body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	r := httptest.NewRequest(http.MethodPost, "/api/auth/telegram", strings.NewReader(string(data)))
+	r.Header.Set("Content-Type", "application/json")
+	return serve(TelegramLoginAPI, r)
+}
+
+func TestTelegramLoginAPI(t *testing.T) {
+	openTestDB(t)
+	setTelegramConf(t)
+
+	// Login Widget 回调中 id 与 auth_date 是 JSON 数字，需要转换为与签名时相同的字符串
+	signed := signTelegram(widgetValues(time.Minute), widgetSecret())
+	authDateNum, _ := strconv.ParseInt(signed.Get("auth_date"), 10, 64)
+	body := map[string]interface{}{
+		"id":         123456789,
+		"username":   "alice",
+		"first_name": "Alice",
+		"auth_date":  authDateNum,
+		"hash":       signed.Get("hash"),
+	}
+	cookie, _ := loginResult(t, telegramLogin(body))
+	r := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	r.AddCookie(cookie)
+	if w := serve(MeAPI, r); !strings.Contains(w.Body.String(), `"username":"alice"`) {
+		t.Errorf("/api/me after widget login: %s", w.Body)
+	}
+
+	body["username"] = "mallory"
+	if w := telegramLogin(body); w.Code != http.StatusUnauthorized {
+		t.Errorf("tampered widget login: status = %d, want 401", w.Code)
+	}
+
+	// Web App 登录到同一个 Telegram 用户关联的账号
+	initData := signTelegram(url.Values{"user": {`{"id":123456789,"username":"alice"}`}, "auth_date": {authDate(0)}}, webAppSecret())
+	loginResult(t, telegramLogin(map[string]interface{}{"initData": initData.Encode()}))
+	var users int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&users); err != nil || users != 1 {
+		t.Errorf("users = %d (%v), want 1", users, err)
+	}
+
+	conf.TelegramLogin = false
+	if w := telegramLogin(body); w.Code != http.StatusNotFound {
+		t.Errorf("disabled: status = %d, want 404", w.Code)
+	}
+}
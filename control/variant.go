@@ -0,0 +1,274 @@
+package control
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"csz.net/tgstate/conf"
+	"csz.net/tgstate/utils"
+)
+
+// maxVariantSource 生成变体时允许读取的原图大小上限
+const maxVariantSource = 50 * 1024 * 1024
+
+// variantLock 同一变体的生成锁，refs 为持有或等待该锁的请求数
+type variantLock struct {
+	sync.Mutex
+	refs int
+}
+
+var (
+	// variantLocks 同一变体同时只生成一次，没有请求使用时删除
+	variantLocks   = make(map[string]*variantLock)
+	variantLocksMu sync.Mutex
+
+	// variantSlots 同时读取或生成变体的请求数上限，解码大图占用大量内存，没有空位时返回 503
+	variantSlots = make(chan struct{}, runtime.GOMAXPROCS(0))
+
+	// cacheMu 保护磁盘缓存的大小统计，cacheSize 在第一次写入时扫描缓存目录得到
+	cacheMu     sync.Mutex
+	cacheSize   int64
+	cacheLoaded bool
+)
+
+// resizableExts 支持生成变体的图片格式
+var resizableExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true}
+
+// parseVariant 从 ?w= ?h= ?fit= ?format= ?q= 参数中解析图片变体，没有相关参数时返回 nil
+func parseVariant(r *http.Request) (*utils.ImageVariant, error) {
+	q := r.URL.Query()
+	if q.Get("w") == "" && q.Get("h") == "" && q.Get("format") == "" {
+		return nil, nil
+	}
+	v := &utils.ImageVariant{Fit: q.Get("fit"), Format: q.Get("format")}
+	for name, dst := range map[string]*int{"w": &v.Width, "h": &v.Height, "q": &v.Quality} {
+		if s := q.Get(name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s", name)
+			}
+			*dst = n
+		}
+	}
+	if err := v.Normalize(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// canResize 检查文件是否可以生成图片变体
+func canResize(record FileRecord) bool {
+	return record.FileId != "" && record.Encryption == nil && record.Encoding == "" &&
+		resizableExts[strings.ToLower(filepath.Ext(record.Filename))]
+}
+
+// serveVariant 输出图片变体：依次尝试磁盘缓存、已上传到 Telegram 的变体，最后现场生成
+func serveVariant(w http.ResponseWriter, record FileRecord, v utils.ImageVariant) {
+	key := v.Key()
+	sum := sha1.Sum([]byte(record.FileId + "|" + key))
+	cachePath := ""
+	if conf.CacheDir != "" {
+		cachePath = filepath.Join(conf.CacheDir, "variants", hex.EncodeToString(sum[:]))
+	}
+
+	defer lockVariant(record.FileId + "|" + key)()
+
+	// 等待同一变体的请求不占用空位，生成后直接读取缓存
+	select {
+	case variantSlots <- struct{}{}:
+		defer func() { <-variantSlots }()
+	default:
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "Too many image requests, please retry later", http.StatusServiceUnavailable)
+		return
+	}
+
+	data, contentType, err := loadVariant(record, v, key, cachePath)
+	if err != nil {
+		log.Printf("生成图片变体失败 %s %s: %v", record.FileId, key, err)
+		http.Error(w, "Failed to process image", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("Content-Disposition", "inline")
+	w.Write(data)
+}
+
+// lockVariant 获取变体的生成锁，返回的函数释放锁，最后一个请求释放时删除锁
+func lockVariant(name string) func() {
+	variantLocksMu.Lock()
+	lock, ok := variantLocks[name]
+	if !ok {
+		lock = &variantLock{}
+		variantLocks[name] = lock
+	}
+	lock.refs++
+	variantLocksMu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		variantLocksMu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(variantLocks, name)
+		}
+		variantLocksMu.Unlock()
+	}
+}
+
+// loadVariant 获取变体数据与 Content-Type
+func loadVariant(record FileRecord, v utils.ImageVariant, key, cachePath string) ([]byte, string, error) {
+	if cachePath != "" {
+		if data, err := os.ReadFile(cachePath); err == nil {
+			// 更新修改时间，清理缓存时优先删除最久未访问的变体
+			now := time.Now()
+			os.Chtimes(cachePath, now, now)
+			return data, http.DetectContentType(data), nil
+		}
+	}
+
+	if variant, err := GetImageVariant(record.FileId, key); err == nil {
+		data, err := readTelegramFile(variant.VariantFileId)
+		if err == nil {
+			cacheVariant(cachePath, data)
+			return data, variant.ContentType, nil
+		}
+		log.Printf("读取已上传的图片变体失败，重新生成: %v", err)
+	}
+
+	src, err := readTelegramFile(record.FileId)
+	if err != nil {
+		return nil, "", err
+	}
+	data, format, err := utils.MakeImageVariant(src, v)
+	if err != nil {
+		return nil, "", err
+	}
+	contentType := utils.ImageContentType(format)
+	cacheVariant(cachePath, data)
+
+	if conf.VariantUpload {
+		go uploadVariant(record, key, format, contentType, data)
+	}
+	return data, contentType, nil
+}
+
+// readTelegramFile 读取 Telegram 中的完整文件
+func readTelegramFile(fileId string) ([]byte, error) {
+	body, err := utils.OpenFile(fileId)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, maxVariantSource+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxVariantSource {
+		return nil, fmt.Errorf("file exceeds %d bytes", maxVariantSource)
+	}
+	return data, nil
+}
+
+// cacheVariant 将变体写入磁盘缓存，先写临时文件再重命名
+func cacheVariant(cachePath string, data []byte) {
+	if cachePath == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		log.Printf("创建缓存目录失败: %v", err)
+		return
+	}
+	tmp := cachePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		log.Printf("写入缓存失败: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, cachePath); err != nil {
+		log.Printf("写入缓存失败: %v", err)
+		return
+	}
+	trimCache(filepath.Dir(cachePath), int64(len(data)))
+}
+
+// trimCache 记录新写入的 added 字节，缓存超过 cacheMaxSize 时按修改时间从旧到新删除，直到降到上限的 90%
+func trimCache(dir string, added int64) {
+	if conf.CacheMaxSize <= 0 {
+		return
+	}
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if cacheLoaded {
+		cacheSize += added
+		if cacheSize <= conf.CacheMaxSize {
+			return
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("读取缓存目录失败: %v", err)
+		return
+	}
+	files := make([]os.FileInfo, 0, len(entries))
+	var total int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+	cacheSize, cacheLoaded = total, true
+	if total <= conf.CacheMaxSize {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime().Before(files[j].ModTime()) })
+	target := conf.CacheMaxSize / 10 * 9
+	for _, info := range files {
+		if cacheSize <= target {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, info.Name())); err != nil && !os.IsNotExist(err) {
+			log.Printf("清理缓存失败: %v", err)
+			continue
+		}
+		cacheSize -= info.Size()
+	}
+}
+
+// uploadVariant 将变体上传回 Telegram 并记录 file_id，缓存丢失后可直接复用
+func uploadVariant(record FileRecord, key, format, contentType string, data []byte) {
+	name := strings.TrimSuffix(record.Filename, filepath.Ext(record.Filename)) + "." + key + "." + format
+	variantFileId := utils.UpDocument(utils.TgFileData(name, bytes.NewReader(data)))
+	if variantFileId == "" {
+		return
+	}
+	err := SaveImageVariant(ImageVariant{
+		FileId:        record.FileId,
+		VariantKey:    key,
+		VariantFileId: variantFileId,
+		ContentType:   contentType,
+		Size:          int64(len(data)),
+	})
+	if err != nil {
+		log.Printf("保存图片变体记录失败: %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package control
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"csz.net/tgstate/utils"
+)
+
+func TestServeVariantSaturated(t *testing.T) {
+	// 占满所有空位后新的变体请求直接返回 503，不读取原图
+	for i := 0; i < cap(variantSlots); i++ {
+		variantSlots <- struct{}{}
+	}
+	t.Cleanup(func() {
+		for i := 0; i < cap(variantSlots); i++ {
+			<-variantSlots
+		}
+	})
+	w := httptest.NewRecorder()
+	serveVariant(w, FileRecord{FileId: "file", Filename: "a.png"}, utils.ImageVariant{Width: 100, Fit: "contain"})
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("status = %d, Retry-After %q, want 503 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"csz.net/tgstate/control"
+)
+
+// runExport 处理 export 子命令：
+//
+//	tgstate export [-format ndjson|csv] [-table files|short_links|manifests] [-o file]
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "ndjson", "Output format, ndjson or csv")
+	table := fs.String("table", "", "Table to export, required for csv (files, short_links or manifests)")
+	output := fs.String("o", "", "Output file, defaults to stdout")
+	fs.Parse(args)
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "csv" && *table == "" {
+		*table = "files"
+	}
+	if err := control.ExportRecords(w, *format, *table); err != nil {
+		fmt.Fprintln(os.Stderr, "导出失败:", err)
+		return 1
+	}
+	return 0
+}
+
+// runImport 处理 import 子命令，导入 export 生成的文件，已存在的记录会被跳过：
+//
+//	tgstate import [-format ndjson|csv] <file>
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "", "Input format, ndjson or csv (detected from the file extension by default)")
+	fs.Parse(args)
+
+	path := fs.Arg(0)
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "用法: import [-format ndjson|csv] <file>")
+		return 2
+	}
+	if *format == "" && strings.HasSuffix(strings.ToLower(path), ".csv") {
+		*format = "csv"
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer f.Close()
+	result, err := control.ImportRecords(f, *format)
+	fmt.Printf("导入文件 %d，短链 %d，分片元数据 %d，跳过 %d\n", result.Files, result.ShortLinks, result.Manifests, result.Skipped)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "导入失败:", err)
+		return 1
+	}
+	return 0
+}
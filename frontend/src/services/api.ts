@@ -48,6 +48,31 @@ const api = axios.create({
   timeout: 60000 * 60,
 });
 
+// 已登录时从 /api/me 取得 CSRF 令牌，未登录时下次请求再取
+let csrfToken: Promise<string | undefined> | undefined;
+
+const getCsrfToken = () => {
+  if (!csrfToken) {
+    csrfToken = axios
+      .get("/api/me")
+      .then((res) => res.data?.data?.csrfToken as string | undefined)
+      .catch(() => undefined);
+    csrfToken.then((token) => {
+      if (!token) csrfToken = undefined;
+    });
+  }
+  return csrfToken;
+};
+
+// 登录会话的 POST 请求需要带上 X-CSRF-Token
+api.interceptors.request.use(async (config) => {
+  if (config.method?.toLowerCase() === "post") {
+    const token = await getCsrfToken();
+    if (token) config.headers["X-CSRF-Token"] = token;
+  }
+  return config;
+});
+
 export const uploadFile = async (
   file: File,
   userFingerprint?: string,
@@ -7,4 +7,14 @@ require (
 	github.com/joho/godotenv v1.5.1
 )
 
-require github.com/mattn/go-sqlite3 v1.14.24 // indirect
+require github.com/mattn/go-sqlite3 v1.14.24
+
+require (
+	github.com/go-sql-driver/mysql v1.7.1
+	github.com/lib/pq v1.10.9
+	golang.org/x/image v0.18.0
+)
+
+require golang.org/x/crypto v0.24.0
+
+require github.com/klauspost/compress v1.17.9
@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 
@@ -19,6 +20,21 @@ var webPort string
 var OptApi = true
 
 func main() {
+	switch flag.Arg(0) {
+	case "migrate":
+		os.Exit(runMigrate(flag.Args()[1:]))
+	case "backup":
+		os.Exit(runBackup(flag.Args()[1:]))
+	case "restore":
+		os.Exit(runRestore(flag.Args()[1:]))
+	case "reindex":
+		os.Exit(runReindex(flag.Args()[1:]))
+	case "export":
+		os.Exit(runExport(flag.Args()[1:]))
+	case "import":
+		os.Exit(runImport(flag.Args()[1:]))
+	}
+
 	//判断是否设置参数
 	if conf.BotToken == "" || conf.ChannelName == "" {
 		fmt.Println("请先设置Bot Token和对象")
@@ -39,6 +55,10 @@ func main() {
 		return
 	}
 
+	control.CaptureChannelFiles()
+	control.StartHLSWorker()
+	control.StartBackupScheduler()
+	control.CaptureBotUploads()
 	go utils.BotDo()
 	web()
 }
@@ -52,19 +72,38 @@ func maskToken(token string) string {
 }
 
 func web() {
-	http.HandleFunc(conf.FileRoute, control.D)
-	http.HandleFunc("/s/", control.S) // 短链路由
+	http.HandleFunc(conf.FileRoute, control.RateLimit("rateDownload", conf.RateDownload, control.D))
+	http.HandleFunc("/s/", control.RateLimit("rateShort", conf.RateShort, control.S)) // 短链路由
+	http.HandleFunc("/thumb/", control.RateLimit("rateDownload", conf.RateDownload, control.Thumb))
+	http.HandleFunc("/hls/", control.RateLimit("rateDownload", conf.RateDownload, control.HLS))
+	// 登录、注册与 Telegram 登录共用一组更严格的令牌桶，限制撞库与批量注册
+	authLimit := control.RateLimiter("rateAuth", conf.RateAuth)
 	if OptApi {
 		if conf.Pass != "" && conf.Pass != "none" {
 			http.HandleFunc("/pwd", control.Pwd)
 		}
-		http.HandleFunc("/api", control.Middleware(control.UploadAPI))
-		http.HandleFunc("/api/chunk", control.Middleware(control.ChunkUploadAPI))
-		http.HandleFunc("/api/merge", control.Middleware(control.MergeChunksAPI))
+		http.HandleFunc("/api", control.RateLimit("rateApi", conf.RateApi, control.Middleware(control.UploadAPI)))
+		http.HandleFunc("/api/chunk", control.RateLimit("rateChunk", conf.RateChunk, control.Middleware(control.ChunkUploadAPI)))
+		http.HandleFunc("/api/merge", control.RateLimit("rateApi", conf.RateApi, control.Middleware(control.MergeChunksAPI)))
 		http.HandleFunc("/api/history", control.HistoryAPI)
 		http.HandleFunc("/api/plaza", control.PlazaAPI)
 		http.HandleFunc("/files", control.Middleware(control.FilesAPI))
 		http.HandleFunc("/shortlinks", control.Middleware(control.ShortLinksAPI))
+		http.HandleFunc("/api/export", control.Middleware(control.ExportAPI))
+		http.HandleFunc("/api/register", authLimit(control.RegisterAPI))
+		http.HandleFunc("/api/login", authLimit(control.LoginAPI))
+		http.HandleFunc("/api/logout", control.LogoutAPI)
+		http.HandleFunc("/api/me", control.MeAPI)
+		http.HandleFunc("/api/me/usage", control.UsageAPI)
+		http.HandleFunc("/api/files/", control.DeleteFileAPI)
+		http.HandleFunc("/auth/oidc/login", control.OIDCLogin)
+		http.HandleFunc("/auth/oidc/callback", control.OIDCCallback)
+		http.HandleFunc("/auth/telegram", authLimit(control.Middleware(control.TelegramLogin)))
+		http.HandleFunc("/api/auth/telegram", authLimit(control.Middleware(control.TelegramLoginAPI)))
+		http.HandleFunc("/api/admin/", control.AdminAPI)
+		http.HandleFunc("/api/keys", control.APIKeysAPI)
+		http.HandleFunc("/api/keys/", control.APIKeysAPI)
+		http.HandleFunc("/api/import", control.Middleware(control.ImportAPI))
 
 		// 静态文件服务
 		http.HandleFunc("/assets/", control.ServeDistFiles)
@@ -77,12 +116,20 @@ func web() {
 	} else {
 		defer listener.Close()
 		log.Printf("Http server start at %s\n", webPort)
-		if err := http.Serve(listener, nil); err != nil {
+		if err := http.Serve(listener, control.ResolveAPIKey(http.DefaultServeMux)); err != nil {
 			log.Fatal(err)
 		}
 	}
 }
 
+// envOr 读取环境变量，未设置时使用默认值
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
 func init() {
 	_ = godotenv.Load()
 
@@ -95,6 +142,53 @@ func init() {
 	flag.StringVar(&conf.BaseUrl, "url", os.Getenv("url"), "Base Url")
 	flag.StringVar(&conf.AllowedExts, "exts", os.Getenv("exts"), "Allowed Exts")
 	flag.StringVar(&conf.ProxyUrl, "proxyUrl", os.Getenv("proxyUrl"), "proxy url")
+	flag.StringVar(&conf.Compress, "compress", os.Getenv("compress"), "Default compression for text uploads (gzip, zstd or none)")
+	flag.StringVar(&conf.CacheDir, "cacheDir", envOr("cacheDir", "./cache"), "Directory for cached image variants")
+	cacheMaxSize, _ := strconv.ParseInt(envOr("cacheMaxSize", "1073741824"), 10, 64)
+	flag.Int64Var(&conf.CacheMaxSize, "cacheMaxSize", cacheMaxSize, "Maximum bytes of cached image variants, the least recently used are removed first (0 for no limit)")
+	flag.BoolVar(&conf.VariantUpload, "variantUpload", os.Getenv("variantUpload") == "true", "Upload generated image variants to Telegram")
+	flag.BoolVar(&conf.StripMetadata, "stripMeta", os.Getenv("stripMeta") != "false", "Strip EXIF/XMP/IPTC metadata from uploaded photos")
+	flag.StringVar(&conf.Convert, "convert", os.Getenv("convert"), "Default format for converting PNG uploads (webp, jpeg or none)")
+	convertQuality, _ := strconv.Atoi(os.Getenv("convertQuality"))
+	flag.IntVar(&conf.ConvertQuality, "convertQuality", convertQuality, "JPEG quality used when converting uploads")
+	flag.BoolVar(&conf.UploadAsPhoto, "asPhoto", os.Getenv("asPhoto") == "true", "Upload images with sendPhoto to get Telegram-generated sizes")
+	flag.StringVar(&conf.FFmpeg, "ffmpeg", os.Getenv("ffmpeg"), "ffmpeg binary used to generate HLS for uploaded videos")
+	flag.StringVar(&conf.DSN, "dsn", os.Getenv("dsn"), "Database DSN (sqlite://./files.db, postgres://..., mysql://...)")
+	flag.StringVar(&conf.DBPath, "dbPath", envOr("dbPath", "./files.db"), "SQLite database path, used when dsn is empty")
+	busyTimeout, _ := strconv.Atoi(envOr("dbBusyTimeout", "5000"))
+	flag.IntVar(&conf.DBBusyTimeout, "dbBusyTimeout", busyTimeout, "SQLite busy timeout in milliseconds")
+	flag.BoolVar(&conf.NameLookup, "nameLookup", os.Getenv("nameLookup") == "true", "Allow /d/{filename} to resolve the newest file with that name (anyone can then fetch files by guessing their names)")
+	flag.StringVar(&conf.BackupDir, "backupDir", envOr("backupDir", "./backups"), "Directory for database backups")
+	flag.StringVar(&conf.BackupInterval, "backupInterval", os.Getenv("backupInterval"), "Interval of scheduled backups, e.g. 24h (empty to disable)")
+	backupKeep, _ := strconv.Atoi(envOr("backupKeep", "7"))
+	flag.IntVar(&conf.BackupKeep, "backupKeep", backupKeep, "Number of scheduled backups to keep (0 keeps all)")
+	flag.BoolVar(&conf.BackupUpload, "backupUpload", os.Getenv("backupUpload") == "true", "Upload backups to the channel")
+	flag.BoolVar(&conf.AnonymousUpload, "anonymousUpload", os.Getenv("anonymousUpload") != "false", "Allow uploads without logging in")
+	flag.BoolVar(&conf.Registration, "registration", os.Getenv("registration") != "false", "Allow new accounts to register")
+	flag.StringVar(&conf.Admins, "admins", os.Getenv("admins"), "Comma-separated account ids that always have the admin role")
+	flag.StringVar(&conf.OIDCIssuer, "oidcIssuer", os.Getenv("oidcIssuer"), "OpenID Connect issuer URL")
+	flag.StringVar(&conf.OIDCClientId, "oidcClientId", os.Getenv("oidcClientId"), "OpenID Connect client ID")
+	flag.StringVar(&conf.OIDCClientSecret, "oidcClientSecret", os.Getenv("oidcClientSecret"), "OpenID Connect client secret, empty for public clients")
+	flag.StringVar(&conf.OIDCRedirectUrl, "oidcRedirectUrl", os.Getenv("oidcRedirectUrl"), "OpenID Connect redirect URL, defaults to {url}/auth/oidc/callback")
+	flag.StringVar(&conf.OIDCScopes, "oidcScopes", envOr("oidcScopes", "openid profile email"), "OpenID Connect scopes")
+	flag.StringVar(&conf.OIDCUsernameClaim, "oidcUsernameClaim", envOr("oidcUsernameClaim", "preferred_username"), "Claim used as the local username")
+	flag.StringVar(&conf.OIDCGroupsClaim, "oidcGroupsClaim", envOr("oidcGroupsClaim", "groups"), "Claim that lists the user's groups")
+	flag.StringVar(&conf.OIDCAdminGroup, "oidcAdminGroup", os.Getenv("oidcAdminGroup"), "Members of this group get the admin role")
+	flag.BoolVar(&conf.TelegramLogin, "telegramLogin", os.Getenv("telegramLogin") == "true", "Allow logging in with Telegram and uploading by messaging the bot")
+	flag.StringVar(&conf.DefaultRole, "defaultRole", envOr("defaultRole", "uploader"), "Role of new accounts: admin, uploader or viewer")
+	quotaDailyBytes, _ := strconv.ParseInt(os.Getenv("quotaDailyBytes"), 10, 64)
+	flag.Int64Var(&conf.QuotaDailyBytes, "quotaDailyBytes", quotaDailyBytes, "Bytes each user, API key or anonymous IP may upload per day (0 for no limit)")
+	quotaDailyFiles, _ := strconv.ParseInt(os.Getenv("quotaDailyFiles"), 10, 64)
+	flag.Int64Var(&conf.QuotaDailyFiles, "quotaDailyFiles", quotaDailyFiles, "Files each user, API key or anonymous IP may upload per day (0 for no limit)")
+	quotaTotalBytes, _ := strconv.ParseInt(os.Getenv("quotaTotalBytes"), 10, 64)
+	flag.Int64Var(&conf.QuotaTotalBytes, "quotaTotalBytes", quotaTotalBytes, "Total bytes each user, API key or anonymous IP may upload (0 for no limit)")
+	flag.StringVar(&conf.TrustedProxies, "trustedProxies", envOr("trustedProxies", "127.0.0.1,::1"), "Comma-separated proxy IPs or CIDRs allowed to set the client IP header")
+	flag.StringVar(&conf.ClientIPHeader, "clientIPHeader", envOr("clientIPHeader", "X-Forwarded-For"), "Header trusted proxies put the client IP in: X-Forwarded-For, Forwarded, X-Real-IP or CF-Connecting-IP (empty to ignore headers)")
+	flag.StringVar(&conf.RateApi, "rateApi", envOr("rateApi", "20/1m"), "Upload rate limit per client IP, e.g. 20/1m (off to disable)")
+	flag.StringVar(&conf.RateChunk, "rateChunk", envOr("rateChunk", "600/1m"), "Chunk upload rate limit per client IP (off to disable)")
+	flag.StringVar(&conf.RateDownload, "rateDownload", envOr("rateDownload", "600/1m"), "Download rate limit for /d/ per client IP (off to disable)")
+	flag.StringVar(&conf.RateShort, "rateShort", envOr("rateShort", "120/1m"), "Short link rate limit for /s/ per client IP (off to disable)")
+	flag.StringVar(&conf.RateAuth, "rateAuth", envOr("rateAuth", "10/1m"), "Combined rate limit for login, registration and Telegram login per client IP (off to disable)")
 	flag.Parse()
 	if conf.Mode == "m" {
 		OptApi = false
@@ -102,6 +196,10 @@ func init() {
 	if conf.Mode != "p" && conf.Mode != "m" {
 		conf.Mode = "p"
 	}
+	// migrate 子命令自行处理迁移，restore 会覆盖数据库文件，都不在这里打开数据库
+	if flag.Arg(0) == "migrate" || flag.Arg(0) == "restore" {
+		return
+	}
 	_, err := control.InitDB()
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"csz.net/tgstate/control"
+)
+
+// runMigrate 处理 migrate 子命令：
+//
+//	tgstate migrate [status]      查看迁移状态
+//	tgstate migrate up [-dry-run] 执行未完成的迁移
+func runMigrate(args []string) int {
+	action := "status"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		action, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "Only print pending migrations")
+	fs.Parse(args)
+
+	conn, err := control.OpenDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "打开数据库失败:", err)
+		return 1
+	}
+	defer conn.Close()
+
+	switch action {
+	case "status":
+		states, err := control.MigrationStatus(conn)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, state := range states {
+			status := "pending"
+			if state.Applied {
+				status = "applied " + state.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", state.Version, state.Name, status)
+		}
+	case "up":
+		migrations, err := control.ApplyMigrations(conn, *dryRun)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(migrations) == 0 {
+			fmt.Println("数据库已是最新版本")
+		}
+		for _, m := range migrations {
+			if *dryRun {
+				fmt.Printf("-- %04d_%s\n%s\n", m.Version, m.Name, m.SQL)
+			} else {
+				fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+			}
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "未知的 migrate 操作 %s，可用: status、up\n", action)
+		return 2
+	}
+	return 0
+}
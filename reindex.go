@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"csz.net/tgstate/conf"
+	"csz.net/tgstate/control"
+)
+
+// runReindex 处理 reindex 子命令，根据频道中的消息重建文件索引：
+//
+//	tgstate reindex [-export result.json] [-scratch chat] [-delay 1s] [-dry-run]
+//
+// -export 为 Telegram Desktop 导出的频道记录，其中的文件会被转发到 -scratch 会话（默认为频道本身）
+// 以取得 file_id，转发的消息随后删除
+func runReindex(args []string) int {
+	fs := flag.NewFlagSet("reindex", flag.ExitOnError)
+	export := fs.String("export", "", "Telegram Desktop JSON export (result.json) of the channel")
+	scratch := fs.String("scratch", "", "Chat to forward old messages to, defaults to the channel")
+	delay := fs.Duration("delay", time.Second, "Delay between forwarded messages")
+	dryRun := fs.Bool("dry-run", false, "Only print what would be added")
+	fs.Parse(args)
+
+	if conf.BotToken == "" || conf.ChannelName == "" {
+		fmt.Fprintln(os.Stderr, "请先设置Bot Token和对象")
+		return 1
+	}
+	if *export != "" {
+		imported, failed, err := control.ImportChannelExport(*export, *scratch, *delay)
+		fmt.Printf("从导出记录中读取 %d 个文件，失败 %d 个\n", imported, failed)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	result, err := control.RebuildIndex(*dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "重建索引失败:", err)
+		return 1
+	}
+	fmt.Printf("新增 %d，已存在 %d，分片文件 %d，分片 %d，跳过 %d\n", result.Added, result.Existing, result.Manifests, result.Chunks, result.Skipped)
+	if len(result.Encrypted) > 0 {
+		fmt.Printf("以下 %d 个文件疑似端到端加密，解密参数无法从频道恢复，已跳过:\n", len(result.Encrypted))
+		for _, name := range result.Encrypted {
+			fmt.Println("  " + name)
+		}
+	}
+	return 0
+}
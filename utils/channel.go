@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"csz.net/tgstate/conf"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ChannelFile 频道中一条带文件的消息
+type ChannelFile struct {
+	ChatId       int64
+	MessageId    int
+	FileId       string
+	FileUniqueId string
+	FileName     string
+	FileSize     int64
+	MimeType     string
+	MediaType    string // document、video、audio、photo、sticker…
+	Date         time.Time
+}
+
+// OnChannelFile 上传到频道或在频道中看到新文件时调用，用于记录频道消息以便重建索引
+var OnChannelFile func(ChannelFile)
+
+// notifyChannelFile 把 sendDocument/sendPhoto 等接口返回的消息交给 OnChannelFile
+func notifyChannelFile(result json.RawMessage) {
+	if OnChannelFile == nil {
+		return
+	}
+	var msg tgbotapi.Message
+	if err := json.Unmarshal(result, &msg); err != nil {
+		return
+	}
+	if file, ok := ChannelFileFromMessage(&msg); ok {
+		OnChannelFile(file)
+	}
+}
+
+// ChannelFileFromMessage 提取消息中的文件，没有文件时返回 false
+func ChannelFileFromMessage(msg *tgbotapi.Message) (ChannelFile, bool) {
+	file := ChannelFile{MessageId: msg.MessageID, Date: time.Unix(int64(msg.Date), 0)}
+	if msg.Chat != nil {
+		file.ChatId = msg.Chat.ID
+	}
+	switch {
+	case msg.Document != nil:
+		file.MediaType = "document"
+		file.FileId, file.FileUniqueId, file.FileName, file.MimeType, file.FileSize = msg.Document.FileID, msg.Document.FileUniqueID, msg.Document.FileName, msg.Document.MimeType, int64(msg.Document.FileSize)
+	case msg.Video != nil:
+		file.MediaType = "video"
+		file.FileId, file.FileUniqueId, file.FileName, file.MimeType, file.FileSize = msg.Video.FileID, msg.Video.FileUniqueID, msg.Video.FileName, msg.Video.MimeType, int64(msg.Video.FileSize)
+	case msg.Audio != nil:
+		file.MediaType = "audio"
+		file.FileId, file.FileUniqueId, file.FileName, file.MimeType, file.FileSize = msg.Audio.FileID, msg.Audio.FileUniqueID, msg.Audio.FileName, msg.Audio.MimeType, int64(msg.Audio.FileSize)
+	case msg.Animation != nil:
+		file.MediaType = "animation"
+		file.FileId, file.FileUniqueId, file.FileName, file.MimeType, file.FileSize = msg.Animation.FileID, msg.Animation.FileUniqueID, msg.Animation.FileName, msg.Animation.MimeType, int64(msg.Animation.FileSize)
+	case msg.Voice != nil:
+		file.MediaType = "voice"
+		file.FileId, file.FileUniqueId, file.MimeType, file.FileSize = msg.Voice.FileID, msg.Voice.FileUniqueID, msg.Voice.MimeType, int64(msg.Voice.FileSize)
+	case msg.Sticker != nil:
+		file.MediaType = "sticker"
+		file.FileId, file.FileUniqueId, file.FileSize = msg.Sticker.FileID, msg.Sticker.FileUniqueID, int64(msg.Sticker.FileSize)
+	case len(msg.Photo) > 0:
+		// 照片有多个尺寸，取最大的一张
+		photo := msg.Photo[len(msg.Photo)-1]
+		file.MediaType = "photo"
+		file.FileId, file.FileUniqueId, file.MimeType, file.FileSize = photo.FileID, photo.FileUniqueID, "image/jpeg", int64(photo.FileSize)
+	default:
+		return ChannelFile{}, false
+	}
+	return file, file.FileId != ""
+}
+
+// OnBotUpload 用户在私聊中发给 Bot 文件时调用，返回回复给用户的文字
+var OnBotUpload func(from *tgbotapi.User, file ChannelFile) (string, error)
+
+// resendMethods 按媒体类型重新发送文件的接口与参数名
+var resendMethods = map[string][2]string{
+	"document":  {"sendDocument", "document"},
+	"video":     {"sendVideo", "video"},
+	"audio":     {"sendAudio", "audio"},
+	"animation": {"sendAnimation", "animation"},
+	"voice":     {"sendVoice", "voice"},
+	"sticker":   {"sendSticker", "sticker"},
+	"photo":     {"sendPhoto", "photo"},
+}
+
+// ResendToChannel 按 file_id 把私聊中收到的文件发送到频道，不需要重新上传，返回频道中的文件
+func ResendToChannel(file ChannelFile) (ChannelFile, error) {
+	method, ok := resendMethods[file.MediaType]
+	if !ok {
+		return ChannelFile{}, fmt.Errorf("unsupported media type %s", file.MediaType)
+	}
+	bot, err := tgbotapi.NewBotAPI(conf.BotToken)
+	if err != nil {
+		return ChannelFile{}, err
+	}
+	response, err := bot.MakeRequest(method[0], tgbotapi.Params{"chat_id": conf.ChannelName, method[1]: file.FileId})
+	if err != nil {
+		return ChannelFile{}, err
+	}
+	notifyChannelFile(response.Result)
+	var msg tgbotapi.Message
+	if err := json.Unmarshal(response.Result, &msg); err != nil {
+		return ChannelFile{}, err
+	}
+	sent, ok := ChannelFileFromMessage(&msg)
+	if !ok {
+		return ChannelFile{}, errors.New("telegram returned no file")
+	}
+	if sent.FileName == "" {
+		sent.FileName = file.FileName
+	}
+	return sent, nil
+}
+
+// IsTargetChat 判断消息是否来自配置的频道
+func IsTargetChat(chat *tgbotapi.Chat) bool {
+	if chat == nil {
+		return false
+	}
+	if name, ok := strings.CutPrefix(conf.ChannelName, "@"); ok {
+		return strings.EqualFold(chat.UserName, name)
+	}
+	id, err := strconv.ParseInt(conf.ChannelName, 10, 64)
+	return err == nil && chat.ID == id
+}
+
+// ForwardChannelFile 把频道中的旧消息转发到 scratch 会话（为空时为频道本身）以取得 file_id，随后删除转发的消息。
+// 遇到 Telegram 限流时按 retry_after 等待后重试
+func ForwardChannelFile(messageId int, scratch string) (ChannelFile, error) {
+	bot, err := tgbotapi.NewBotAPI(conf.BotToken)
+	if err != nil {
+		return ChannelFile{}, err
+	}
+	if scratch == "" {
+		scratch = conf.ChannelName
+	}
+	params := tgbotapi.Params{
+		"chat_id":              scratch,
+		"from_chat_id":         conf.ChannelName,
+		"message_id":           strconv.Itoa(messageId),
+		"disable_notification": "true",
+	}
+	var response *tgbotapi.APIResponse
+	for retry := 0; ; retry++ {
+		response, err = bot.MakeRequest("forwardMessage", params)
+		var tgErr *tgbotapi.Error
+		if err != nil && errors.As(err, &tgErr) && tgErr.RetryAfter > 0 && retry < 5 {
+			time.Sleep(time.Duration(tgErr.RetryAfter) * time.Second)
+			continue
+		}
+		break
+	}
+	if err != nil {
+		return ChannelFile{}, err
+	}
+	var msg tgbotapi.Message
+	if err := json.Unmarshal(response.Result, &msg); err != nil {
+		return ChannelFile{}, err
+	}
+	if _, err := bot.MakeRequest("deleteMessage", tgbotapi.Params{"chat_id": scratch, "message_id": strconv.Itoa(msg.MessageID)}); err != nil {
+		log.Printf("删除转发的消息 %d 失败: %v", msg.MessageID, err)
+	}
+	file, ok := ChannelFileFromMessage(&msg)
+	if !ok {
+		return ChannelFile{}, fmt.Errorf("message %d has no file", messageId)
+	}
+	file.MessageId = messageId
+	if msg.ForwardDate != 0 {
+		file.Date = time.Unix(int64(msg.ForwardDate), 0)
+	}
+	return file, nil
+}
+
+// GetFileUniqueId 通过 getFile 查询文件的 file_unique_id，同一文件的 file_id 可能不同，但 file_unique_id 不变
+func GetFileUniqueId(fileId string) (string, error) {
+	bot, err := tgbotapi.NewBotAPI(conf.BotToken)
+	if err != nil {
+		return "", err
+	}
+	file, err := bot.GetFile(tgbotapi.FileConfig{FileID: fileId})
+	if err != nil {
+		return "", err
+	}
+	return file.FileUniqueID, nil
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// blobMagic 分片合并文件元数据的文件头
+const blobMagic = "tgstate-blob"
+
+// BlobManifest 分片文件的元数据
+type BlobManifest struct {
+	FileName string
+	Size     int64 // 0 表示未记录
+	ChunkIds []string
+}
+
+// ParseBlobManifest 解析 CreateMergedFile 生成的元数据，不是元数据时返回 false
+func ParseBlobManifest(data []byte) (BlobManifest, bool) {
+	if !bytes.HasPrefix(data, []byte(blobMagic)) {
+		return BlobManifest{}, false
+	}
+	lines := strings.Split(string(data), "\n")
+	if len(lines) < 2 {
+		return BlobManifest{}, false
+	}
+	manifest := BlobManifest{FileName: lines[1]}
+	start := 2
+	if len(lines) > 2 && strings.HasPrefix(lines[2], "size") {
+		manifest.Size, _ = strconv.ParseInt(lines[2][len("size"):], 10, 64)
+		start++
+	}
+	for _, line := range lines[start:] {
+		if id := strings.ReplaceAll(strings.TrimSpace(line), " ", ""); id != "" {
+			manifest.ChunkIds = append(manifest.ChunkIds, id)
+		}
+	}
+	return manifest, true
+}
+
+// OpenFile 读取 Telegram 中的文件内容，分片文件会按顺序拼接所有分片
+func OpenFile(fileID string) (io.ReadCloser, error) {
+	body, err := openTelegramFile(fileID)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(body)
+	head, _ := br.Peek(len(blobMagic))
+	if string(head) != blobMagic {
+		return struct {
+			io.Reader
+			io.Closer
+		}{br, body}, nil
+	}
+
+	// 元数据文件很小，直接读完
+	data, err := io.ReadAll(io.LimitReader(br, 1<<20))
+	body.Close()
+	if err != nil {
+		return nil, err
+	}
+	manifest, _ := ParseBlobManifest(data)
+	return &chunkReader{chunkIds: manifest.ChunkIds}, nil
+}
+
+// ReadBlobManifest 读取并解析分片文件的元数据，不是元数据时返回 false
+func ReadBlobManifest(fileID string) (BlobManifest, bool, error) {
+	body, err := openTelegramFile(fileID)
+	if err != nil {
+		return BlobManifest{}, false, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, 1<<20))
+	if err != nil {
+		return BlobManifest{}, false, err
+	}
+	manifest, ok := ParseBlobManifest(data)
+	return manifest, ok, nil
+}
+
+// openTelegramFile 通过 getFile 获取下载地址并发起请求
+func openTelegramFile(fileID string) (io.ReadCloser, error) {
+	fileUrl, ok := GetDownloadUrl(fileID)
+	if !ok {
+		return nil, fmt.Errorf("failed to get download url for %s", fileID)
+	}
+	resp, err := http.Get(fileUrl)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("telegram returned %s for %s", resp.Status, fileID)
+	}
+	return resp.Body, nil
+}
+
+// chunkReader 依次读取各个分片
+type chunkReader struct {
+	chunkIds []string
+	current  io.ReadCloser
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if c.current == nil {
+			if len(c.chunkIds) == 0 {
+				return 0, io.EOF
+			}
+			body, err := c.openChunk(c.chunkIds[0])
+			if err != nil {
+				return 0, err
+			}
+			c.current = body
+			c.chunkIds = c.chunkIds[1:]
+		}
+		n, err := c.current.Read(p)
+		if err == io.EOF {
+			c.current.Close()
+			c.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// openChunk 获取分片，getFile 偶发失败时重试
+func (c *chunkReader) openChunk(chunkID string) (io.ReadCloser, error) {
+	var lastErr error
+	for retry := 0; retry < 3; retry++ {
+		if retry > 0 {
+			time.Sleep(5 * time.Second)
+		}
+		body, err := openTelegramFile(chunkID)
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (c *chunkReader) Close() error {
+	if c.current != nil {
+		return c.current.Close()
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// MaxImagePixels 允许解码的最大像素数，防止解压炸弹
+	MaxImagePixels = 50 * 1000 * 1000
+	// MaxVariantSize 生成变体时允许的最大宽高
+	MaxVariantSize = 4096
+	// variantQualityStep JPEG 质量取整的步长
+	variantQualityStep = 5
+)
+
+// variantSizes 允许生成的宽高，其他取值向上取到最近的一档，限制同一张图片可以生成的变体数量
+var variantSizes = []int{16, 32, 48, 64, 96, 128, 160, 192, 256, 320, 384, 480, 512, 640, 720, 768, 800, 960, 1024, 1280, 1440, 1600, 1920, 2048, 2560, 3072, 3840, MaxVariantSize}
+
+// snapSize 把宽高向上取到 variantSizes 中最近的一档，0 保持不变
+func snapSize(n int) int {
+	if n == 0 {
+		return 0
+	}
+	for _, size := range variantSizes {
+		if n <= size {
+			return size
+		}
+	}
+	return MaxVariantSize
+}
+
+// ImageVariant 图片变体参数
+type ImageVariant struct {
+	Width   int    // 目标宽度，0 表示按比例
+	Height  int    // 目标高度，0 表示按比例
+	Fit     string // contain（默认，完整放入）、cover（裁剪填满）、fill（拉伸）
+	Format  string // webp、jpeg、png，空表示沿用原格式
+	Quality int    // JPEG 质量，0 表示默认
+}
+
+// Key 变体的唯一标识，用于缓存与数据库记录
+func (v ImageVariant) Key() string {
+	key := fmt.Sprintf("w%d_h%d_%s_%s", v.Width, v.Height, v.Fit, v.Format)
+	if v.Quality > 0 {
+		key += fmt.Sprintf("_q%d", v.Quality)
+	}
+	return key
+}
+
+// Normalize 校验参数并补全默认值，宽高与质量取整到固定的档位
+func (v *ImageVariant) Normalize() error {
+	if v.Width < 0 || v.Height < 0 || v.Width > MaxVariantSize || v.Height > MaxVariantSize {
+		return fmt.Errorf("image size must be between 0 and %d", MaxVariantSize)
+	}
+	v.Fit = strings.ToLower(v.Fit)
+	switch v.Fit {
+	case "":
+		v.Fit = "contain"
+	case "contain", "cover", "fill":
+	default:
+		return fmt.Errorf("unsupported fit %s", v.Fit)
+	}
+	v.Format = strings.ToLower(v.Format)
+	switch v.Format {
+	case "jpg":
+		v.Format = "jpeg"
+	case "", "webp", "jpeg", "png":
+	default:
+		return fmt.Errorf("unsupported format %s", v.Format)
+	}
+	if v.Quality < 0 || v.Quality > 100 {
+		return errors.New("quality must be between 1 and 100, or 0 for the default")
+	}
+	v.Width, v.Height = snapSize(v.Width), snapSize(v.Height)
+	// 质量向上取整到 5 的倍数
+	v.Quality = (v.Quality + variantQualityStep - 1) / variantQualityStep * variantQualityStep
+	return nil
+}
+
+// ImageContentType 返回编码格式对应的 MIME 类型
+func ImageContentType(format string) string {
+	switch format {
+	case "jpeg":
+		return "image/jpeg"
+	case "png":
+		return "image/png"
+	case "gif":
+		return "image/gif"
+	case "webp":
+		return "image/webp"
+	}
+	return "application/octet-stream"
+}
+
+// DecodeImage 解码 JPEG/PNG/GIF/WebP（GIF 取第一帧），超过像素上限时拒绝
+func DecodeImage(data []byte) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg.Width*cfg.Height > MaxImagePixels {
+		return nil, "", fmt.Errorf("image too large: %dx%d", cfg.Width, cfg.Height)
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	return img, format, nil
+}
+
+// EncodeImage 按指定格式编码图片
+func EncodeImage(img image.Image, format string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "webp":
+		err = EncodeWebP(&buf, img)
+	case "jpeg":
+		if quality <= 0 {
+			quality = 85
+		}
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	case "png":
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	default:
+		err = fmt.Errorf("unsupported format %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MakeImageVariant 生成图片变体，返回编码后的数据与实际使用的格式
+func MakeImageVariant(data []byte, v ImageVariant) ([]byte, string, error) {
+	src, srcFormat, err := DecodeImage(data)
+	if err != nil {
+		return nil, "", err
+	}
+	format := v.Format
+	if format == "" {
+		format = srcFormat
+		if format == "gif" {
+			// 只输出第一帧，改用 PNG 保留透明度
+			format = "png"
+		}
+	}
+	out, err := EncodeImage(ResizeImage(src, v.Width, v.Height, v.Fit), format, v.Quality)
+	if err != nil {
+		return nil, "", err
+	}
+	return out, format, nil
+}
+
+// ResizeImage 按 fit 模式缩放图片，不会放大超过原图尺寸（fill 除外）
+func ResizeImage(src image.Image, width, height int, fit string) image.Image {
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	if width == 0 && height == 0 {
+		return src
+	}
+
+	srcRect := sb
+	var dw, dh int
+	switch {
+	case fit == "fill" && width > 0 && height > 0:
+		dw, dh = width, height
+	case fit == "cover" && width > 0 && height > 0:
+		// 按较大的缩放比例填满目标区域，居中裁剪多余部分
+		scale := maxFloat(float64(width)/float64(sw), float64(height)/float64(sh))
+		if scale > 1 {
+			scale = 1
+		}
+		dw, dh = minInt(width, sw), minInt(height, sh)
+		cw, ch := int(float64(dw)/scale+0.5), int(float64(dh)/scale+0.5)
+		cw, ch = minInt(cw, sw), minInt(ch, sh)
+		x0 := sb.Min.X + (sw-cw)/2
+		y0 := sb.Min.Y + (sh-ch)/2
+		srcRect = image.Rect(x0, y0, x0+cw, y0+ch)
+	default:
+		scale := 1.0
+		if width > 0 {
+			scale = float64(width) / float64(sw)
+		}
+		if height > 0 && (width == 0 || float64(height)/float64(sh) < scale) {
+			scale = float64(height) / float64(sh)
+		}
+		if scale >= 1 {
+			return src
+		}
+		dw, dh = maxInt(1, int(float64(sw)*scale+0.5)), maxInt(1, int(float64(sh)*scale+0.5))
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, srcRect, draw.Src, nil)
+	return dst
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// ConvertImage 将图片转换为指定格式，转为 JPEG 时透明区域以白色填充
+func ConvertImage(data []byte, format string, quality int) ([]byte, error) {
+	src, _, err := DecodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	if format == "jpeg" {
+		flat := image.NewRGBA(src.Bounds())
+		draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Bounds(), src, src.Bounds().Min, draw.Over)
+		src = flat
+	}
+	return EncodeImage(src, format, quality)
+}
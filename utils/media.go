@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"encoding/json"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MediaMeta Telegram 在上传后返回的媒体信息
+type MediaMeta struct {
+	Duration    int    `json:"duration,omitempty"` // 时长（秒）
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	ThumbFileId string `json:"thumbFileId,omitempty"` // Telegram 生成的缩略图
+	Performer   string `json:"performer,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+// IsZero 是否没有任何媒体信息
+func (m MediaMeta) IsZero() bool {
+	return m == MediaMeta{}
+}
+
+// tgMedia 兼容 Video/Audio/Animation/Document 的公共字段，缩略图字段在新版 Bot API 中改名为 thumbnail
+type tgMedia struct {
+	Duration  int                 `json:"duration"`
+	Width     int                 `json:"width"`
+	Height    int                 `json:"height"`
+	Performer string              `json:"performer"`
+	Title     string              `json:"title"`
+	Thumb     *tgbotapi.PhotoSize `json:"thumb"`
+	Thumbnail *tgbotapi.PhotoSize `json:"thumbnail"`
+}
+
+// parseMediaMeta 从 sendDocument 等接口返回的消息中提取媒体信息
+func parseMediaMeta(result json.RawMessage) MediaMeta {
+	var msg struct {
+		Video     *tgMedia `json:"video"`
+		Audio     *tgMedia `json:"audio"`
+		Animation *tgMedia `json:"animation"`
+		Document  *tgMedia `json:"document"`
+	}
+	if err := json.Unmarshal(result, &msg); err != nil {
+		return MediaMeta{}
+	}
+	media := msg.Video
+	for _, m := range []*tgMedia{msg.Audio, msg.Animation, msg.Document} {
+		if media == nil {
+			media = m
+		}
+	}
+	if media == nil {
+		return MediaMeta{}
+	}
+	meta := MediaMeta{
+		Duration:  media.Duration,
+		Width:     media.Width,
+		Height:    media.Height,
+		Performer: media.Performer,
+		Title:     media.Title,
+	}
+	if thumb := media.Thumbnail; thumb != nil {
+		meta.ThumbFileId = thumb.FileID
+	} else if media.Thumb != nil {
+		meta.ThumbFileId = media.Thumb.FileID
+	}
+	return meta
+}
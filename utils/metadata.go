@@ -0,0 +1,279 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// 图片元数据清理：移除 JPEG/PNG/WebP 中的 EXIF（含 GPS）、XMP 与 IPTC，只保留方向信息，
+// 直接在容器层面删除数据块，不重新编码像素
+
+var (
+	jpegExifHeader = []byte("Exif\x00\x00")
+	jpegXMPHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	jpegXMPExt     = []byte("http://ns.adobe.com/xmp/extension/\x00")
+	jpegIPTCHeader = []byte("Photoshop 3.0\x00")
+	pngSignature   = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// errBadImage 图片结构无法解析
+var errBadImage = errors.New("malformed image")
+
+// StripImageMetadata 移除图片中的隐私元数据，返回清理后的数据与是否有改动；不支持的格式原样返回
+func StripImageMetadata(data []byte) ([]byte, bool, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8}):
+		return stripJPEG(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return stripPNG(data)
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return stripWebP(data)
+	}
+	return data, false, nil
+}
+
+// stripJPEG 删除 APP1(EXIF/XMP) 与 APP13(IPTC) 段
+func stripJPEG(data []byte) ([]byte, bool, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xff, 0xd8)
+	orientation := 0
+	changed := false
+	// 方向段写在 SOI 与开头的 APP0（JFIF）之后，即原 EXIF 通常所在的位置
+	insertAt, leading := len(out), true
+	p := 2
+	for {
+		// 跳过段之间的填充字节
+		for p < len(data) && data[p] == 0xff && p+1 < len(data) && data[p+1] == 0xff {
+			p++
+		}
+		if p+4 > len(data) || data[p] != 0xff {
+			return nil, false, errBadImage
+		}
+		marker := data[p+1]
+		if marker == 0xda || marker == 0xd9 {
+			// 扫描数据开始，之后不再有元数据段
+			if orientation > 1 {
+				segment := jpegOrientationSegment(orientation)
+				out = append(out[:insertAt], append(segment, out[insertAt:]...)...)
+			}
+			out = append(out, data[p:]...)
+			return out, changed, nil
+		}
+		if marker >= 0xd0 && marker <= 0xd7 || marker == 0x01 {
+			out = append(out, data[p:p+2]...)
+			p += 2
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(data[p+2:]))
+		if length < 2 || p+2+length > len(data) {
+			return nil, false, errBadImage
+		}
+		segment := data[p : p+2+length]
+		payload := segment[4:]
+		drop := false
+		switch marker {
+		case 0xe1:
+			if bytes.HasPrefix(payload, jpegExifHeader) {
+				if o := exifOrientation(payload[len(jpegExifHeader):]); o > 0 && orientation == 0 {
+					orientation = o
+				}
+				drop = true
+			} else if bytes.HasPrefix(payload, jpegXMPHeader) || bytes.HasPrefix(payload, jpegXMPExt) {
+				drop = true
+			}
+		case 0xed:
+			drop = bytes.HasPrefix(payload, jpegIPTCHeader)
+		}
+		if drop {
+			changed = true
+		} else {
+			out = append(out, segment...)
+			if leading && marker == 0xe0 {
+				insertAt = len(out)
+			} else {
+				leading = false
+			}
+		}
+		p += 2 + length
+	}
+}
+
+// jpegOrientationSegment 生成只包含方向标签的 EXIF APP1 段
+func jpegOrientationSegment(orientation int) []byte {
+	tiff := orientationTIFF(orientation)
+	seg := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(2+len(jpegExifHeader)+len(tiff)))
+	seg = append(seg, jpegExifHeader...)
+	return append(seg, tiff...)
+}
+
+// orientationTIFF 生成只有 IFD0 Orientation 一项的 TIFF 结构
+func orientationTIFF(orientation int) []byte {
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1}
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], uint16(orientation))
+	tiff = append(tiff, entry...)
+	return append(tiff, 0, 0, 0, 0)
+}
+
+// exifOrientation 从 TIFF 结构的 IFD0 中读取 Orientation，读取失败返回 0
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	offset := int(order.Uint32(tiff[4:]))
+	if offset+2 > len(tiff) || offset < 8 {
+		return 0
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			o := int(order.Uint16(tiff[entry+8:]))
+			if o >= 1 && o <= 8 {
+				return o
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// stripPNG 删除 eXIf 与文本块（XMP 存放在 iTXt 中），没有 IEND 的截断文件视为无法解析
+func stripPNG(data []byte) ([]byte, bool, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	orientation := 0
+	changed := false
+	ended := false
+	p := len(pngSignature)
+	for p < len(data) && !ended {
+		if p+12 > len(data) {
+			return nil, false, errBadImage
+		}
+		length := int(binary.BigEndian.Uint32(data[p:]))
+		if length < 0 || p+12+length > len(data) {
+			return nil, false, errBadImage
+		}
+		typ := string(data[p+4 : p+8])
+		chunk := data[p : p+12+length]
+		switch typ {
+		case "eXIf":
+			orientation = exifOrientation(chunk[8 : 8+length])
+			changed = true
+		case "tEXt", "zTXt", "iTXt", "tIME":
+			changed = true
+		case "IDAT":
+			if orientation > 1 {
+				out = append(out, pngChunk("eXIf", orientationTIFF(orientation))...)
+				orientation = 0
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, chunk...)
+		}
+		p += 12 + length
+		ended = typ == "IEND"
+	}
+	if !ended {
+		return nil, false, errBadImage
+	}
+	return out, changed, nil
+}
+
+// pngChunk 生成带 CRC 的 PNG 数据块
+func pngChunk(typ string, payload []byte) []byte {
+	chunk := make([]byte, 8, 12+len(payload))
+	binary.BigEndian.PutUint32(chunk[0:], uint32(len(payload)))
+	copy(chunk[4:], typ)
+	chunk = append(chunk, payload...)
+	crc := crc32.ChecksumIEEE(chunk[4:])
+	return binary.BigEndian.AppendUint32(chunk, crc)
+}
+
+// stripWebP 删除 EXIF 与 XMP 数据块并同步 VP8X 标志位，RIFF 头中的大小超出数据长度时视为截断
+func stripWebP(data []byte) ([]byte, bool, error) {
+	const (
+		flagXMP  = 0x04
+		flagEXIF = 0x08
+	)
+	riffSize := int64(binary.LittleEndian.Uint32(data[4:]))
+	if riffSize < 4 || riffSize+8 > int64(len(data)) {
+		return nil, false, errBadImage
+	}
+	// 忽略 RIFF 之后多余的数据
+	data = data[:8+riffSize]
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	vp8x := -1
+	orientation := 0
+	changed := false
+	p := 12
+	for p+8 <= len(data) {
+		typ := string(data[p : p+4])
+		size := int(binary.LittleEndian.Uint32(data[p+4:]))
+		end := p + 8 + size + size&1
+		if size < 0 || p+8+size > len(data) {
+			return nil, false, errBadImage
+		}
+		if end > len(data) {
+			end = len(data)
+		}
+		switch typ {
+		case "EXIF":
+			payload := data[p+8 : p+8+size]
+			payload = bytes.TrimPrefix(payload, jpegExifHeader)
+			orientation = exifOrientation(payload)
+			changed = true
+		case "XMP ":
+			changed = true
+		default:
+			if typ == "VP8X" && size >= 10 {
+				vp8x = len(out)
+			}
+			out = append(out, data[p:end]...)
+		}
+		p = end
+	}
+	if !changed {
+		return data, false, nil
+	}
+	if vp8x >= 0 {
+		flags := out[vp8x+8] &^ (flagXMP | flagEXIF)
+		if orientation > 1 {
+			flags |= flagEXIF
+		}
+		out[vp8x+8] = flags
+	}
+	if orientation > 1 && vp8x >= 0 {
+		// EXIF 块只有在 VP8X 扩展格式下才有效
+		tiff := orientationTIFF(orientation)
+		chunk := make([]byte, 8, 8+len(tiff)+1)
+		copy(chunk, "EXIF")
+		binary.LittleEndian.PutUint32(chunk[4:], uint32(len(tiff)))
+		chunk = append(chunk, tiff...)
+		if len(tiff)%2 == 1 {
+			chunk = append(chunk, 0)
+		}
+		out = append(out, chunk...)
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, true, nil
+}
@@ -0,0 +1,474 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// 测试用的元数据，清理后都不应出现在输出中
+var metadataSecrets = []string{"SECRET-CAMERA", "SECRET-GPS", "SECRET-XMP", "SECRET-IPTC", "SECRET-TEXT"}
+
+// testImage 生成带渐变的小图
+func testImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x * 16), uint8(y * 16), 128, 255})
+		}
+	}
+	return img
+}
+
+// testEXIF 生成 TIFF 结构：IFD0 中有 Orientation（为 0 时省略）、相机型号与 GPS IFD 指针，GPS IFD 中有纬度
+func testEXIF(order binary.ByteOrder, orientation int) []byte {
+	entries := 2
+	if orientation > 0 {
+		entries++
+	}
+	ifd0 := 8
+	makeOffset := ifd0 + 2 + entries*12 + 4
+	gpsOffset := makeOffset + 14
+	latOffset := gpsOffset + 2 + 2*12 + 4
+
+	tiff := make([]byte, latOffset+24+len("SECRET-GPS"))
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], uint32(ifd0))
+	entry := func(at, tag, typ, count int, value uint32) {
+		order.PutUint16(tiff[at:], uint16(tag))
+		order.PutUint16(tiff[at+2:], uint16(typ))
+		order.PutUint32(tiff[at+4:], uint32(count))
+		if typ == 3 && count == 1 {
+			order.PutUint16(tiff[at+8:], uint16(value))
+		} else {
+			order.PutUint32(tiff[at+8:], value)
+		}
+	}
+	order.PutUint16(tiff[ifd0:], uint16(entries))
+	at := ifd0 + 2
+	entry(at, 0x0110, 2, 14, uint32(makeOffset)) // Model
+	at += 12
+	if orientation > 0 {
+		entry(at, 0x0112, 3, 1, uint32(orientation))
+		at += 12
+	}
+	entry(at, 0x8825, 4, 1, uint32(gpsOffset)) // GPSInfo
+	copy(tiff[makeOffset:], "SECRET-CAMERA\x00")
+
+	order.PutUint16(tiff[gpsOffset:], 2)
+	entry(gpsOffset+2, 0x0001, 2, 2, 0) // GPSLatitudeRef
+	copy(tiff[gpsOffset+2+8:], "N\x00")
+	entry(gpsOffset+14, 0x0002, 5, 3, uint32(latOffset)) // GPSLatitude
+	for i, v := range []uint32{48, 1, 51, 1, 2995, 100} {
+		order.PutUint32(tiff[latOffset+i*4:], v)
+	}
+	copy(tiff[latOffset+24:], "SECRET-GPS")
+	return tiff
+}
+
+// jpegSegment 生成 JPEG 标记段
+func jpegSegment(marker byte, payload []byte) []byte {
+	seg := []byte{0xff, marker, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(2+len(payload)))
+	return append(seg, payload...)
+}
+
+// testJPEG 在编码好的 JPEG 的 SOI 之后插入 segments
+func testJPEG(t *testing.T, segments ...[]byte) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(16, 16), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := append([]byte{}, buf.Bytes()[:2]...)
+	for _, seg := range segments {
+		data = append(data, seg...)
+	}
+	return append(data, buf.Bytes()[2:]...)
+}
+
+// jpegMetadataSegments 返回 SOS 之前的所有标记段
+func jpegMetadataSegments(t *testing.T, data []byte) map[byte][][]byte {
+	segments := make(map[byte][][]byte)
+	for p := 2; p+4 <= len(data); {
+		marker := data[p+1]
+		if marker == 0xda {
+			return segments
+		}
+		length := int(binary.BigEndian.Uint16(data[p+2:]))
+		segments[marker] = append(segments[marker], data[p+4:p+2+length])
+		p += 2 + length
+	}
+	t.Fatal("no SOS marker")
+	return nil
+}
+
+func assertNoSecrets(t *testing.T, name string, data []byte) {
+	t.Helper()
+	for _, secret := range metadataSecrets {
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("%s: %s survived stripping", name, secret)
+		}
+	}
+}
+
+func TestStripJPEG(t *testing.T) {
+	xmp := jpegSegment(0xe1, append(append([]byte{}, jpegXMPHeader...), "<x:xmpmeta>SECRET-XMP</x:xmpmeta>"...))
+	xmpExt := jpegSegment(0xe1, append(append([]byte{}, jpegXMPExt...), "0123456789ABCDEF SECRET-XMP"...))
+	iptc := jpegSegment(0xed, append(append([]byte{}, jpegIPTCHeader...), "8BIM\x04\x04\x00\x00\x00\x00\x00\x0bSECRET-IPTC"...))
+	icc := jpegSegment(0xe2, []byte("ICC_PROFILE\x00\x01\x01KEEP-ICC"))
+	comment := jpegSegment(0xfe, []byte("KEEP-COMMENT"))
+	jfif := jpegSegment(0xe0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))
+
+	tests := []struct {
+		name        string
+		order       binary.ByteOrder
+		orientation int
+		jfif        bool
+		want        int // 输出中的方向，0 表示不应有 EXIF
+	}{
+		{"big endian rotated", binary.BigEndian, 6, false, 6},
+		{"little endian rotated", binary.LittleEndian, 8, false, 8},
+		{"rotated after JFIF", binary.BigEndian, 3, true, 3},
+		{"upright", binary.BigEndian, 1, false, 0},
+		{"no orientation", binary.LittleEndian, 0, true, 0},
+	}
+	for _, tt := range tests {
+		exif := jpegSegment(0xe1, append(append([]byte{}, jpegExifHeader...), testEXIF(tt.order, tt.orientation)...))
+		// EXIF 放在 ICC 等段之后，输出时应移到开头
+		segments := [][]byte{icc, xmp, exif, xmpExt, iptc, comment}
+		prefix := 2
+		if tt.jfif {
+			segments = append([][]byte{jfif}, segments...)
+			prefix += len(jfif)
+		}
+		data := testJPEG(t, segments...)
+		out, changed, err := StripImageMetadata(data)
+		if err != nil || !changed {
+			t.Fatalf("%s: changed = %v, err = %v", tt.name, changed, err)
+		}
+		assertNoSecrets(t, tt.name, out)
+		for _, keep := range []string{"KEEP-ICC", "KEEP-COMMENT"} {
+			if !bytes.Contains(out, []byte(keep)) {
+				t.Errorf("%s: %s was removed", tt.name, keep)
+			}
+		}
+		app1 := jpegMetadataSegments(t, out)[0xe1]
+		switch {
+		case tt.want == 0 && len(app1) != 0:
+			t.Errorf("%s: unexpected APP1 segments %q", tt.name, app1)
+		case tt.want != 0 && (len(app1) != 1 || exifOrientation(bytes.TrimPrefix(app1[0], jpegExifHeader)) != tt.want):
+			t.Errorf("%s: APP1 segments %q, want one with orientation %d", tt.name, app1, tt.want)
+		case tt.want != 0 && !bytes.HasPrefix(out[prefix:], []byte{0xff, 0xe1}):
+			t.Errorf("%s: APP1 is not right after SOI/APP0: % x", tt.name, out[:prefix+4])
+		}
+		if tt.jfif && !bytes.HasPrefix(out[2:], jfif) {
+			t.Errorf("%s: APP0 is no longer first", tt.name)
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(out)); err != nil {
+			t.Errorf("%s: stripped JPEG does not decode: %v", tt.name, err)
+		}
+	}
+
+	clean := testJPEG(t, icc)
+	out, changed, err := StripImageMetadata(clean)
+	if err != nil || changed || !bytes.Equal(out, clean) {
+		t.Errorf("JPEG without metadata: changed = %v, err = %v", changed, err)
+	}
+}
+
+// pngChunks 拆分 PNG 数据块，同时校验 CRC
+func pngChunks(t *testing.T, data []byte) (types []string, payloads map[string][]byte) {
+	payloads = make(map[string][]byte)
+	for p := len(pngSignature); p < len(data); {
+		length := int(binary.BigEndian.Uint32(data[p:]))
+		typ := string(data[p+4 : p+8])
+		if crc := binary.BigEndian.Uint32(data[p+8+length:]); crc != crc32.ChecksumIEEE(data[p+4:p+8+length]) {
+			t.Errorf("chunk %s has a bad CRC", typ)
+		}
+		types = append(types, typ)
+		payloads[typ] = data[p+8 : p+8+length]
+		p += 12 + length
+	}
+	return types, payloads
+}
+
+// testPNG 在编码好的 PNG 的 IHDR 之后插入 chunks
+func testPNG(t *testing.T, chunks ...[]byte) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(8, 8)); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.Bytes()
+	ihdrEnd := len(pngSignature) + 12 + int(binary.BigEndian.Uint32(src[len(pngSignature):]))
+	data := append([]byte{}, src[:ihdrEnd]...)
+	for _, chunk := range chunks {
+		data = append(data, chunk...)
+	}
+	return append(data, src[ihdrEnd:]...)
+}
+
+func TestStripPNG(t *testing.T) {
+	text := [][]byte{
+		pngChunk("pHYs", []byte{0, 0, 0x0b, 0x13, 0, 0, 0x0b, 0x13, 1}),
+		pngChunk("iTXt", []byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00<x:xmpmeta>SECRET-XMP</x:xmpmeta>")),
+		pngChunk("tEXt", []byte("Comment\x00SECRET-TEXT")),
+		pngChunk("zTXt", []byte("Author\x00\x00SECRET-TEXT")),
+		pngChunk("tIME", []byte{0x07, 0xe8, 1, 2, 3, 4, 5}),
+	}
+	tests := []struct {
+		name        string
+		orientation int
+		wantTypes   []string
+	}{
+		{"rotated", 3, []string{"IHDR", "pHYs", "eXIf", "IDAT", "IEND"}},
+		{"upright", 1, []string{"IHDR", "pHYs", "IDAT", "IEND"}},
+	}
+	for _, tt := range tests {
+		chunks := append([][]byte{pngChunk("eXIf", testEXIF(binary.LittleEndian, tt.orientation))}, text...)
+		out, changed, err := StripImageMetadata(testPNG(t, chunks...))
+		if err != nil || !changed {
+			t.Fatalf("%s: changed = %v, err = %v", tt.name, changed, err)
+		}
+		assertNoSecrets(t, tt.name, out)
+		types, payloads := pngChunks(t, out)
+		if len(types) != len(tt.wantTypes) {
+			t.Fatalf("%s: chunks = %v, want %v", tt.name, types, tt.wantTypes)
+		}
+		for i := range types {
+			if types[i] != tt.wantTypes[i] {
+				t.Fatalf("%s: chunks = %v, want %v", tt.name, types, tt.wantTypes)
+			}
+		}
+		if exif, ok := payloads["eXIf"]; ok && exifOrientation(exif) != tt.orientation {
+			t.Errorf("%s: orientation = %d, want %d", tt.name, exifOrientation(exif), tt.orientation)
+		}
+		if _, err := png.Decode(bytes.NewReader(out)); err != nil {
+			t.Errorf("%s: stripped PNG does not decode: %v", tt.name, err)
+		}
+	}
+
+	clean := testPNG(t, text[0])
+	out, changed, err := StripImageMetadata(clean)
+	if err != nil || changed || !bytes.Equal(out, clean) {
+		t.Errorf("PNG without metadata: changed = %v, err = %v", changed, err)
+	}
+}
+
+// webpChunk 生成 RIFF 数据块，奇数长度补齐一个字节
+func webpChunk(typ string, payload []byte) []byte {
+	chunk := make([]byte, 8, 9+len(payload))
+	copy(chunk, typ)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(payload)))
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// testWebP 用 VP8X 扩展格式包装编码好的无损 WebP，flags 为 VP8X 标志位
+func testWebP(t *testing.T, flags byte, chunks ...[]byte) []byte {
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, testImage(8, 8)); err != nil {
+		t.Fatal(err)
+	}
+	vp8x := make([]byte, 10)
+	vp8x[0] = flags
+	vp8x[4], vp8x[7] = 7, 7 // 画布宽高减一，24 位小端
+	data := append([]byte("RIFF\x00\x00\x00\x00WEBP"), webpChunk("VP8X", vp8x)...)
+	data = append(data, buf.Bytes()[12:]...)
+	for _, chunk := range chunks {
+		data = append(data, chunk...)
+	}
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+	return data
+}
+
+// webpChunks 拆分 RIFF 数据块
+func webpChunks(t *testing.T, data []byte) (types []string, payloads map[string][]byte) {
+	if size := int(binary.LittleEndian.Uint32(data[4:])); size != len(data)-8 {
+		t.Errorf("RIFF size = %d, want %d", size, len(data)-8)
+	}
+	payloads = make(map[string][]byte)
+	for p := 12; p+8 <= len(data); {
+		typ := string(data[p : p+4])
+		size := int(binary.LittleEndian.Uint32(data[p+4:]))
+		types = append(types, typ)
+		payloads[typ] = data[p+8 : p+8+size]
+		p += 8 + size + size&1
+	}
+	return types, payloads
+}
+
+func TestStripWebP(t *testing.T) {
+	const (
+		flagICC  = 0x20
+		flagEXIF = 0x08
+		flagXMP  = 0x04
+	)
+	icc := webpChunk("ICCP", []byte("KEEP-ICC"))
+	xmp := webpChunk("XMP ", []byte("<x:xmpmeta>SECRET-XMP</x:xmpmeta>")) // 奇数长度
+	tests := []struct {
+		name        string
+		exif        []byte
+		wantFlags   byte
+		orientation int
+	}{
+		{"rotated", testEXIF(binary.LittleEndian, 8), flagICC | flagEXIF, 8},
+		{"rotated with Exif header", append(append([]byte{}, jpegExifHeader...), testEXIF(binary.BigEndian, 5)...), flagICC | flagEXIF, 5},
+		{"upright", testEXIF(binary.BigEndian, 1), flagICC, 0},
+	}
+	for _, tt := range tests {
+		data := testWebP(t, flagICC|flagEXIF|flagXMP, icc, webpChunk("EXIF", tt.exif), xmp)
+		out, changed, err := StripImageMetadata(data)
+		if err != nil || !changed {
+			t.Fatalf("%s: changed = %v, err = %v", tt.name, changed, err)
+		}
+		assertNoSecrets(t, tt.name, out)
+		types, payloads := webpChunks(t, out)
+		if flags := payloads["VP8X"][0]; flags != tt.wantFlags {
+			t.Errorf("%s: VP8X flags = %#x, want %#x", tt.name, flags, tt.wantFlags)
+		}
+		if _, ok := payloads["XMP "]; ok {
+			t.Errorf("%s: XMP chunk kept: %v", tt.name, types)
+		}
+		if !bytes.Equal(payloads["ICCP"], []byte("KEEP-ICC")) {
+			t.Errorf("%s: ICC profile removed: %v", tt.name, types)
+		}
+		exif, ok := payloads["EXIF"]
+		if ok != (tt.orientation > 0) || ok && exifOrientation(exif) != tt.orientation {
+			t.Errorf("%s: EXIF chunk %q, want orientation %d", tt.name, exif, tt.orientation)
+		}
+		if _, err := webp.Decode(bytes.NewReader(out)); err != nil {
+			t.Errorf("%s: stripped WebP does not decode: %v", tt.name, err)
+		}
+	}
+
+	// 简单格式（没有 VP8X）与不含元数据的扩展格式原样返回
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, testImage(8, 8)); err != nil {
+		t.Fatal(err)
+	}
+	for name, clean := range map[string][]byte{"simple": buf.Bytes(), "extended": testWebP(t, flagICC, icc)} {
+		out, changed, err := StripImageMetadata(clean)
+		if err != nil || changed || !bytes.Equal(out, clean) {
+			t.Errorf("%s WebP without metadata: changed = %v, err = %v", name, changed, err)
+		}
+	}
+}
+
+// metadataFixtures 每种格式各一个带元数据的样本
+func metadataFixtures(t *testing.T) map[string][]byte {
+	exif := testEXIF(binary.BigEndian, 6)
+	return map[string][]byte{
+		"jpeg": testJPEG(t,
+			jpegSegment(0xe1, append(append([]byte{}, jpegExifHeader...), exif...)),
+			jpegSegment(0xed, append(append([]byte{}, jpegIPTCHeader...), "SECRET-IPTC"...))),
+		"png":  testPNG(t, pngChunk("eXIf", exif), pngChunk("tEXt", []byte("Comment\x00SECRET-TEXT"))),
+		"webp": testWebP(t, 0x08, webpChunk("EXIF", exif)),
+	}
+}
+
+// stripNoPanic 调用 StripImageMetadata，panic 时报告错误
+func stripNoPanic(t *testing.T, name string, data []byte) (out []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("%s: panic: %v", name, r)
+			err = errBadImage
+		}
+	}()
+	out, _, err = StripImageMetadata(data)
+	return out, err
+}
+
+func TestStripTruncated(t *testing.T) {
+	for name, data := range metadataFixtures(t) {
+		// JPEG 只能在扫描数据之前发现截断，PNG 需要 IEND，WebP 的 RIFF 头记录了完整长度
+		detectable := len(data)
+		if name == "jpeg" {
+			detectable = bytes.Index(data, []byte{0xff, 0xda}) + 4
+		}
+		for n := 0; n < len(data); n++ {
+			_, err := stripNoPanic(t, name, data[:n])
+			magic := map[string]int{"jpeg": 2, "png": len(pngSignature), "webp": 12}[name]
+			if n >= magic && n < detectable && !errors.Is(err, errBadImage) {
+				t.Errorf("%s truncated to %d of %d bytes: err = %v, want errBadImage", name, n, len(data), err)
+			}
+		}
+	}
+}
+
+func TestStripMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"jpeg segment length below 2", []byte{0xff, 0xd8, 0xff, 0xe1, 0x00, 0x01, 0xff, 0xda}},
+		{"jpeg segment past end", []byte{0xff, 0xd8, 0xff, 0xe1, 0xff, 0xff, 'E', 'x'}},
+		{"jpeg missing marker", []byte{0xff, 0xd8, 0x00, 0xe1, 0x00, 0x04, 0x00, 0x00}},
+		{"png huge chunk", append(append([]byte{}, pngSignature...), 0xff, 0xff, 0xff, 0xff, 'I', 'H', 'D', 'R', 0, 0, 0, 0)},
+		{"png without IEND", append(append([]byte{}, pngSignature...), pngChunk("IHDR", make([]byte, 13))...)},
+		{"webp huge chunk", []byte("RIFF\x10\x00\x00\x00WEBPEXIF\xff\xff\xff\xff")},
+		{"webp RIFF size past end", []byte("RIFF\xff\xff\xff\x00WEBPVP8L\x00\x00\x00\x00")},
+		{"webp RIFF size too small", []byte("RIFF\x00\x00\x00\x00WEBP")},
+	}
+	for _, tt := range tests {
+		if _, err := stripNoPanic(t, tt.name, tt.data); !errors.Is(err, errBadImage) {
+			t.Errorf("%s: err = %v, want errBadImage", tt.name, err)
+		}
+	}
+
+	// 损坏的 EXIF 不影响清理，只是无法保留方向
+	badEXIF := [][]byte{
+		{},
+		[]byte("XX\x00\x2a\x00\x00\x00\x08"),
+		[]byte("MM\x00\x2a\xff\xff\xff\xff"),
+		[]byte("MM\x00\x2a\x00\x00\x00\x04"),
+		[]byte("MM\x00\x2a\x00\x00\x00\x08\xff\xff\x01\x12"),
+		[]byte("II\x2a\x00\x08\x00\x00\x00\x01\x00\x12\x01\x03\x00\x01\x00\x00\x00\x09\x00"),
+	}
+	for i, exif := range badEXIF {
+		if o := exifOrientation(exif); o != 0 {
+			t.Errorf("bad EXIF %d: orientation = %d, want 0", i, o)
+		}
+		data := testJPEG(t, jpegSegment(0xe1, append(append([]byte{}, jpegExifHeader...), exif...)))
+		out, err := stripNoPanic(t, "jpeg with bad EXIF", data)
+		if err != nil || bytes.Contains(out, jpegExifHeader) {
+			t.Errorf("bad EXIF %d: err = %v, EXIF kept = %v", i, err, bytes.Contains(out, jpegExifHeader))
+		}
+	}
+
+	// 随机改写字节不会 panic
+	rng := rand.New(rand.NewSource(1))
+	for name, data := range metadataFixtures(t) {
+		for i := 0; i < 2000; i++ {
+			corrupt := append([]byte{}, data...)
+			for j := 0; j < 1+rng.Intn(8); j++ {
+				corrupt[rng.Intn(len(corrupt))] = byte(rng.Intn(256))
+			}
+			stripNoPanic(t, name, corrupt)
+		}
+	}
+
+	// 其他格式原样返回
+	for _, data := range [][]byte{nil, []byte("GIF89a"), []byte("plain text")} {
+		out, changed, err := StripImageMetadata(data)
+		if err != nil || changed || !bytes.Equal(out, data) {
+			t.Errorf("%q: changed = %v, err = %v", data, changed, err)
+		}
+	}
+}
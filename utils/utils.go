@@ -7,8 +7,10 @@ import (
 	"io"
 	"log"
 	"math/big"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"csz.net/tgstate/conf"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -40,6 +42,24 @@ func ValidateBotConfig() error {
 	return nil
 }
 
+var botUsername struct {
+	sync.Once
+	name string
+}
+
+// BotUsername 返回 Bot 的用户名（不带 @），用于 Telegram 登录组件，获取失败时为空
+func BotUsername() string {
+	botUsername.Do(func() {
+		bot, err := tgbotapi.NewBotAPI(conf.BotToken)
+		if err != nil {
+			log.Printf("获取 Bot 信息失败: %v", err)
+			return
+		}
+		botUsername.name = bot.Self.UserName
+	})
+	return botUsername.name
+}
+
 func TgFileData(fileName string, fileData io.Reader) tgbotapi.FileReader {
 	return tgbotapi.FileReader{
 		Name:   fileName,
@@ -48,41 +68,21 @@ func TgFileData(fileName string, fileData io.Reader) tgbotapi.FileReader {
 }
 
 func UpDocument(fileData tgbotapi.FileReader) string {
-	bot, err := tgbotapi.NewBotAPI(conf.BotToken)
-	if err != nil {
-		log.Printf("创建 Bot API 实例失败: %v", err)
-		return ""
-	}
+	fileID, _ := UpDocumentWithMeta(fileData)
+	return fileID
+}
 
-	// 验证配置
-	if conf.ChannelName == "" {
-		log.Println("错误: 频道名称未配置")
-		return ""
-	}
-
-	log.Printf("正在上传文件 '%s' 到频道 '%s'", fileData.Name, conf.ChannelName)
-
-	// Upload the file to Telegram
-	params := tgbotapi.Params{
-		"chat_id": conf.ChannelName, // Replace with the chat ID where you want to send the file
-	}
-	files := []tgbotapi.RequestFile{
-		{
-			Name: "document",
-			Data: fileData,
-		},
-	}
-	response, err := bot.UploadFiles("sendDocument", params, files)
-	if err != nil {
-		log.Printf("上传文件到 Telegram 失败: %v", err)
-		log.Printf("请检查: 1) Bot Token 是否正确 2) 频道名称 '%s' 是否正确 3) Bot 是否已添加到频道并有发送权限", conf.ChannelName)
-		return ""
+// UpDocumentWithMeta 上传文件并返回 Telegram 识别出的媒体信息（时长、分辨率、缩略图等）
+func UpDocumentWithMeta(fileData tgbotapi.FileReader) (string, MediaMeta) {
+	response, ok := uploadToChannel("sendDocument", "document", fileData)
+	if !ok {
+		return "", MediaMeta{}
 	}
 	var msg tgbotapi.Message
 	if err := json.Unmarshal([]byte(response.Result), &msg); err != nil {
 		log.Printf("解析 Telegram 响应失败: %v", err)
 		log.Printf("响应内容: %s", response.Result)
-		return ""
+		return "", MediaMeta{}
 	}
 
 	var resp string
@@ -105,9 +105,76 @@ func UpDocument(fileData tgbotapi.FileReader) string {
 
 	if resp == "" {
 		log.Println("错误: 未能获取文件ID")
+		return "", MediaMeta{}
 	}
 
-	return resp
+	return resp, parseMediaMeta(response.Result)
+}
+
+// uploadToChannel 调用 sendDocument/sendPhoto 等接口把文件发送到频道
+func uploadToChannel(method, field string, fileData tgbotapi.FileReader) (*tgbotapi.APIResponse, bool) {
+	bot, err := tgbotapi.NewBotAPI(conf.BotToken)
+	if err != nil {
+		log.Printf("创建 Bot API 实例失败: %v", err)
+		return nil, false
+	}
+
+	// 验证配置
+	if conf.ChannelName == "" {
+		log.Println("错误: 频道名称未配置")
+		return nil, false
+	}
+
+	log.Printf("正在上传文件 '%s' 到频道 '%s'", fileData.Name, conf.ChannelName)
+
+	// Upload the file to Telegram
+	params := tgbotapi.Params{
+		"chat_id": conf.ChannelName, // Replace with the chat ID where you want to send the file
+	}
+	files := []tgbotapi.RequestFile{
+		{
+			Name: field,
+			Data: fileData,
+		},
+	}
+	response, err := bot.UploadFiles(method, params, files)
+	if err != nil {
+		log.Printf("上传文件到 Telegram 失败: %v", err)
+		log.Printf("请检查: 1) Bot Token 是否正确 2) 频道名称 '%s' 是否正确 3) Bot 是否已添加到频道并有发送权限", conf.ChannelName)
+		return nil, false
+	}
+	notifyChannelFile(response.Result)
+	return response, true
+}
+
+// PhotoSize sendPhoto 生成的一个图片尺寸
+type PhotoSize struct {
+	FileId   string `json:"fileId"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	FileSize int    `json:"fileSize"`
+}
+
+// UpPhoto 通过 sendPhoto 上传图片，Telegram 会重新压缩为 JPEG 并生成多个尺寸，按从小到大返回
+func UpPhoto(fileData tgbotapi.FileReader) ([]PhotoSize, error) {
+	response, ok := uploadToChannel("sendPhoto", "photo", fileData)
+	if !ok {
+		return nil, fmt.Errorf("sendPhoto failed for %s", fileData.Name)
+	}
+	var msg tgbotapi.Message
+	if err := json.Unmarshal(response.Result, &msg); err != nil {
+		return nil, fmt.Errorf("解析 Telegram 响应失败: %v", err)
+	}
+	if len(msg.Photo) == 0 {
+		return nil, fmt.Errorf("telegram returned no photo sizes for %s", fileData.Name)
+	}
+	sizes := make([]PhotoSize, 0, len(msg.Photo))
+	for _, p := range msg.Photo {
+		sizes = append(sizes, PhotoSize{FileId: p.FileID, Width: p.Width, Height: p.Height, FileSize: p.FileSize})
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i].Width*sizes[i].Height < sizes[j].Width*sizes[j].Height })
+	log.Printf("图片上传成功，共 %d 个尺寸，FileID: %s", len(sizes), sizes[len(sizes)-1].FileId)
+	return sizes, nil
 }
 
 func GetDownloadUrl(fileID string) (string, bool) {
@@ -144,6 +211,23 @@ func BotDo() {
 		}
 		if update.ChannelPost != nil {
 			msg = update.ChannelPost
+			// 记录直接发到频道中的文件，Bot 自己上传的文件在 uploadToChannel 中记录
+			if file, ok := ChannelFileFromMessage(msg); ok && OnChannelFile != nil && IsTargetChat(msg.Chat) {
+				OnChannelFile(file)
+			}
+		}
+		// 私聊发给 Bot 的文件转存到频道并记录到发送者的账号
+		if msg != nil && msg.Chat != nil && msg.Chat.IsPrivate() && msg.From != nil && OnBotUpload != nil {
+			if file, ok := ChannelFileFromMessage(msg); ok {
+				text, err := OnBotUpload(msg.From, file)
+				if err != nil {
+					text = err.Error()
+				}
+				reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+				reply.ReplyToMessageID = msg.MessageID
+				bot.Send(reply)
+				continue
+			}
 		}
 		if msg != nil && msg.Text == "get" && msg.ReplyToMessage != nil {
 			var fileID string
@@ -154,6 +238,9 @@ func BotDo() {
 				fileID = msg.ReplyToMessage.Video.FileID
 			case msg.ReplyToMessage.Sticker != nil && msg.ReplyToMessage.Sticker.FileID != "":
 				fileID = msg.ReplyToMessage.Sticker.FileID
+			case len(msg.ReplyToMessage.Photo) > 0:
+				// 照片有多个尺寸，取最大的一张
+				fileID = msg.ReplyToMessage.Photo[len(msg.ReplyToMessage.Photo)-1].FileID
 			}
 			if fileID != "" {
 				newMsg := tgbotapi.NewMessage(msg.Chat.ID, strings.TrimSuffix(conf.BaseUrl, "/")+"/d/"+fileID)
@@ -0,0 +1,681 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+)
+
+// 纯 Go 实现的无损 WebP（VP8L）编码器，使用 subtract-green、预测变换与 LZ77 反向引用，
+// 压缩率不及 libwebp，但不依赖 cgo，足以满足缩略图与截图转码
+
+const (
+	vp8lMaxSize        = 1 << 14
+	vp8lLiteralCodes   = 256
+	vp8lLengthCodes    = 24
+	vp8lDistanceCodes  = 40
+	vp8lMaxCodeLength  = 15
+	vp8lMaxCopyLength  = 4096
+	vp8lMinCopyLength  = 3
+	vp8lMaxDistance    = 1<<20 - 120
+	vp8lHashBits       = 16
+	vp8lMaxChainLength = 32
+	vp8lPredictorBits  = 4 // 预测模式按 16x16 分块选择
+)
+
+// vp8lCodeLengthOrder 码长编码的写入顺序
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// vp8lDistanceMap 与解码端一致的二维距离映射表
+var vp8lDistanceMap = [120]uint8{
+	0x18, 0x07, 0x17, 0x19, 0x28, 0x06, 0x27, 0x29, 0x16, 0x1a,
+	0x26, 0x2a, 0x38, 0x05, 0x37, 0x39, 0x15, 0x1b, 0x36, 0x3a,
+	0x25, 0x2b, 0x48, 0x04, 0x47, 0x49, 0x14, 0x1c, 0x35, 0x3b,
+	0x46, 0x4a, 0x24, 0x2c, 0x58, 0x45, 0x4b, 0x34, 0x3c, 0x03,
+	0x57, 0x59, 0x13, 0x1d, 0x56, 0x5a, 0x23, 0x2d, 0x44, 0x4c,
+	0x55, 0x5b, 0x33, 0x3d, 0x68, 0x02, 0x67, 0x69, 0x12, 0x1e,
+	0x66, 0x6a, 0x22, 0x2e, 0x54, 0x5c, 0x43, 0x4d, 0x65, 0x6b,
+	0x32, 0x3e, 0x78, 0x01, 0x77, 0x79, 0x53, 0x5d, 0x11, 0x1f,
+	0x64, 0x6c, 0x42, 0x4e, 0x76, 0x7a, 0x21, 0x2f, 0x75, 0x7b,
+	0x31, 0x3f, 0x63, 0x6d, 0x52, 0x5e, 0x00, 0x74, 0x7c, 0x41,
+	0x4f, 0x10, 0x20, 0x62, 0x6e, 0x30, 0x73, 0x7d, 0x51, 0x5f,
+	0x40, 0x72, 0x7e, 0x61, 0x6f, 0x50, 0x71, 0x7f, 0x60, 0x70,
+}
+
+// EncodeWebP 将图片编码为无损 WebP
+func EncodeWebP(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width <= 0 || height <= 0 || width > vp8lMaxSize || height > vp8lMaxSize {
+		return errors.New("webp: invalid image size")
+	}
+
+	argb, hasAlpha := vp8lPixels(img)
+
+	var bw vp8lBitWriter
+	bw.write(0x2f, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if hasAlpha {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	bw.write(0, 3)
+
+	// subtract-green 变换：红蓝通道减去绿色通道，降低颜色相关性
+	bw.write(1, 1)
+	bw.write(2, 2)
+	for i, p := range argb {
+		g := (p >> 8) & 0xff
+		r := ((p >> 16) - g) & 0xff
+		bl := (p - g) & 0xff
+		argb[i] = p&0xff00ff00 | r<<16 | bl
+	}
+
+	// 预测变换：每个分块选择残差最小的预测模式，模式图作为子图像编码
+	residual, modes := vp8lPredict(argb, width, height)
+	bw.write(1, 1)
+	bw.write(0, 2)
+	bw.write(vp8lPredictorBits-2, 3)
+	bw.write(0, 1) // 子图像不使用颜色缓存
+	vp8lWriteImageData(&bw, modes, vp8lTiles(width))
+	bw.write(0, 1)
+
+	// 不使用颜色缓存与元前缀码
+	bw.write(0, 1)
+	bw.write(0, 1)
+	vp8lWriteImageData(&bw, residual, width)
+
+	data := bw.bytes()
+	size := len(data)
+	pad := size & 1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(4+8+size+pad))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(size))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if pad == 1 {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+// vp8lTiles 返回覆盖 size 个像素所需的分块数
+func vp8lTiles(size int) int {
+	return (size + 1<<vp8lPredictorBits - 1) >> vp8lPredictorBits
+}
+
+// vp8lPredict 计算预测残差与每个分块的预测模式
+func vp8lPredict(argb []uint32, width, height int) (residual []uint32, modes []uint32) {
+	tilesX, tilesY := vp8lTiles(width), vp8lTiles(height)
+	modes = make([]uint32, tilesX*tilesY)
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			best, bestCost := 0, -1
+			for mode := 0; mode < 14; mode++ {
+				cost := 0
+				for y := ty << vp8lPredictorBits; y < height && y < (ty+1)<<vp8lPredictorBits; y++ {
+					for x := tx << vp8lPredictorBits; x < width && x < (tx+1)<<vp8lPredictorBits; x++ {
+						cost += vp8lResidualCost(argb[y*width+x], vp8lPrediction(argb, width, x, y, mode))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+			modes[ty*tilesX+tx] = 0xff000000 | uint32(best)<<8
+		}
+	}
+
+	residual = make([]uint32, len(argb))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			mode := int(modes[(y>>vp8lPredictorBits)*tilesX+x>>vp8lPredictorBits]>>8) & 0xf
+			i := y*width + x
+			residual[i] = vp8lSub(argb[i], vp8lPrediction(argb, width, x, y, mode))
+		}
+	}
+	return residual, modes
+}
+
+// vp8lPrediction 按解码端规则计算像素预测值，首行固定用左侧、首列固定用上方像素
+func vp8lPrediction(argb []uint32, width, x, y, mode int) uint32 {
+	i := y*width + x
+	switch {
+	case x == 0 && y == 0:
+		return 0xff000000
+	case y == 0:
+		return argb[i-1]
+	case x == 0:
+		return argb[i-width]
+	}
+	// 最右列的右上像素取当前行最左侧像素，与内存中的 i-width+1 一致
+	l, t, tl, tr := argb[i-1], argb[i-width], argb[i-width-1], argb[i-width+1]
+	switch mode {
+	case 0:
+		return 0xff000000
+	case 1:
+		return l
+	case 2:
+		return t
+	case 3:
+		return tr
+	case 4:
+		return tl
+	case 5:
+		return vp8lAverage(vp8lAverage(l, tr), t)
+	case 6:
+		return vp8lAverage(l, tl)
+	case 7:
+		return vp8lAverage(l, t)
+	case 8:
+		return vp8lAverage(tl, t)
+	case 9:
+		return vp8lAverage(t, tr)
+	case 10:
+		return vp8lAverage(vp8lAverage(l, tl), vp8lAverage(t, tr))
+	case 11:
+		// Select：选择与梯度估计更接近的左侧或上方像素
+		var pl, pt int
+		for shift := 0; shift < 32; shift += 8 {
+			c := int(tl >> shift & 0xff)
+			pl += vp8lAbs(c - int(t>>shift&0xff))
+			pt += vp8lAbs(c - int(l>>shift&0xff))
+		}
+		if pl < pt {
+			return l
+		}
+		return t
+	case 12:
+		return vp8lChannels(func(shift int) int {
+			return int(l>>shift&0xff) + int(t>>shift&0xff) - int(tl>>shift&0xff)
+		})
+	default:
+		avg := vp8lAverage(l, t)
+		return vp8lChannels(func(shift int) int {
+			a := int(avg >> shift & 0xff)
+			return a + (a-int(tl>>shift&0xff))/2
+		})
+	}
+}
+
+// vp8lChannels 对四个通道分别求值并截断到 0..255
+func vp8lChannels(f func(shift int) int) uint32 {
+	var out uint32
+	for shift := 0; shift < 32; shift += 8 {
+		v := f(shift)
+		if v < 0 {
+			v = 0
+		} else if v > 255 {
+			v = 255
+		}
+		out |= uint32(v) << shift
+	}
+	return out
+}
+
+// vp8lAverage 逐通道取平均
+func vp8lAverage(a, b uint32) uint32 {
+	return (((a ^ b) & 0xfefefefe) >> 1) + (a & b)
+}
+
+// vp8lSub 逐通道相减（模 256）
+func vp8lSub(a, b uint32) uint32 {
+	ag := (a | 0x00ff00ff) - (b & 0xff00ff00)
+	rb := (a | 0xff00ff00) - (b & 0x00ff00ff)
+	return ag&0xff00ff00 | rb&0x00ff00ff
+}
+
+// vp8lResidualCost 估算残差的编码代价
+func vp8lResidualCost(pixel, prediction uint32) int {
+	d := vp8lSub(pixel, prediction)
+	cost := 0
+	for shift := 0; shift < 32; shift += 8 {
+		v := int(d >> shift & 0xff)
+		if v > 128 {
+			v = 256 - v
+		}
+		cost += v
+	}
+	return cost
+}
+
+func vp8lAbs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// vp8lPixels 将图片转换为 ARGB 像素数组（非预乘）
+func vp8lPixels(img image.Image) ([]uint32, bool) {
+	b := img.Bounds()
+	pix := make([]uint32, 0, b.Dx()*b.Dy())
+	hasAlpha := false
+	if m, ok := img.(*image.NRGBA); ok {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			row := m.Pix[m.PixOffset(b.Min.X, y):m.PixOffset(b.Max.X, y)]
+			for i := 0; i < len(row); i += 4 {
+				if row[i+3] != 0xff {
+					hasAlpha = true
+				}
+				pix = append(pix, uint32(row[i+3])<<24|uint32(row[i])<<16|uint32(row[i+1])<<8|uint32(row[i+2]))
+			}
+		}
+		return pix, hasAlpha
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A != 0xff {
+				hasAlpha = true
+			}
+			pix = append(pix, uint32(c.A)<<24|uint32(c.R)<<16|uint32(c.G)<<8|uint32(c.B))
+		}
+	}
+	return pix, hasAlpha
+}
+
+// vp8lToken 熵编码前的符号：字面像素或 LZ77 反向引用
+type vp8lToken struct {
+	argb     uint32
+	length   int // 0 表示字面像素
+	distCode int
+}
+
+// vp8lWriteImageData 写入熵编码后的像素数据（5 组前缀码 + 符号流）
+func vp8lWriteImageData(bw *vp8lBitWriter, argb []uint32, width int) {
+	tokens := vp8lBackwardRefs(argb, width)
+
+	green := make([]uint32, vp8lLiteralCodes+vp8lLengthCodes)
+	red := make([]uint32, 256)
+	blue := make([]uint32, 256)
+	alpha := make([]uint32, 256)
+	dist := make([]uint32, vp8lDistanceCodes)
+	for _, t := range tokens {
+		if t.length == 0 {
+			green[(t.argb>>8)&0xff]++
+			red[(t.argb>>16)&0xff]++
+			blue[t.argb&0xff]++
+			alpha[t.argb>>24]++
+			continue
+		}
+		lp, _, _ := vp8lPrefix(t.length)
+		green[vp8lLiteralCodes+lp]++
+		dp, _, _ := vp8lPrefix(t.distCode)
+		dist[dp]++
+	}
+
+	codes := [5]*vp8lHuffman{}
+	for i, h := range [][]uint32{green, red, blue, alpha, dist} {
+		codes[i] = newVP8LHuffman(h, vp8lMaxCodeLength)
+		codes[i].writeHeader(bw)
+	}
+
+	for _, t := range tokens {
+		if t.length == 0 {
+			codes[0].writeSymbol(bw, int((t.argb>>8)&0xff))
+			codes[1].writeSymbol(bw, int((t.argb>>16)&0xff))
+			codes[2].writeSymbol(bw, int(t.argb&0xff))
+			codes[3].writeSymbol(bw, int(t.argb>>24))
+			continue
+		}
+		lp, lbits, lextra := vp8lPrefix(t.length)
+		codes[0].writeSymbol(bw, vp8lLiteralCodes+lp)
+		bw.write(lextra, lbits)
+		dp, dbits, dextra := vp8lPrefix(t.distCode)
+		codes[4].writeSymbol(bw, dp)
+		bw.write(dextra, dbits)
+	}
+}
+
+// vp8lBackwardRefs 使用哈希链做贪心 LZ77 匹配
+func vp8lBackwardRefs(argb []uint32, width int) []vp8lToken {
+	n := len(argb)
+	distCodes := vp8lDistanceCodeTable(width)
+	head := make([]int32, 1<<vp8lHashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	prev := make([]int32, n)
+	hash := func(i int) uint32 {
+		return (argb[i]*0x1e35a7bd ^ argb[i+1]*0x9e3779b1) >> (32 - vp8lHashBits)
+	}
+	insert := func(i int) {
+		if i+1 >= n {
+			return
+		}
+		h := hash(i)
+		prev[i] = head[h]
+		head[h] = int32(i)
+	}
+
+	tokens := make([]vp8lToken, 0, n/2)
+	for i := 0; i < n; {
+		bestLen, bestDist := 0, 0
+		if i+1 < n {
+			maxLen := n - i
+			if maxLen > vp8lMaxCopyLength {
+				maxLen = vp8lMaxCopyLength
+			}
+			for j, chain := int(head[hash(i)]), 0; j >= 0 && chain < vp8lMaxChainLength && i-j <= vp8lMaxDistance; j, chain = int(prev[j]), chain+1 {
+				l := 0
+				for l < maxLen && argb[j+l] == argb[i+l] {
+					l++
+				}
+				if l > bestLen {
+					bestLen, bestDist = l, i-j
+					if l == maxLen {
+						break
+					}
+				}
+			}
+		}
+		if bestLen >= vp8lMinCopyLength {
+			code := bestDist + 120
+			if bestDist < len(distCodes) && distCodes[bestDist] != 0 {
+				code = distCodes[bestDist]
+			}
+			tokens = append(tokens, vp8lToken{length: bestLen, distCode: code})
+			for k := 0; k < bestLen; k++ {
+				insert(i + k)
+			}
+			i += bestLen
+			continue
+		}
+		tokens = append(tokens, vp8lToken{argb: argb[i]})
+		insert(i)
+		i++
+	}
+	return tokens
+}
+
+// vp8lDistanceCodeTable 计算像素距离到最短距离码的映射（仅覆盖二维邻域码 1..120）
+func vp8lDistanceCodeTable(width int) []int {
+	table := make([]int, 16*width+16)
+	for code := 120; code >= 1; code-- {
+		v := int(vp8lDistanceMap[code-1])
+		d := (v>>4)*width + 8 - v&0xf
+		if d < 1 {
+			d = 1
+		}
+		if d < len(table) {
+			table[d] = code
+		}
+	}
+	return table
+}
+
+// vp8lPrefix 将长度或距离值编码为前缀码与附加位
+func vp8lPrefix(value int) (prefix int, extraBits uint, extra uint32) {
+	if value <= 4 {
+		return value - 1, 0, 0
+	}
+	value--
+	highest := 0
+	for v := value; v > 1; v >>= 1 {
+		highest++
+	}
+	second := (value >> (highest - 1)) & 1
+	extraBits = uint(highest - 1)
+	return 2*highest + second, extraBits, uint32(value & (1<<extraBits - 1))
+}
+
+// vp8lHuffman 单个前缀码
+type vp8lHuffman struct {
+	lengths []uint8
+	codes   []uint32 // 已按位反转，可直接低位优先写入
+	symbols []int    // 出现过的符号
+}
+
+// newVP8LHuffman 根据频率构建限长的规范霍夫曼编码
+func newVP8LHuffman(freq []uint32, maxLength int) *vp8lHuffman {
+	h := &vp8lHuffman{lengths: make([]uint8, len(freq)), codes: make([]uint32, len(freq))}
+	for s, f := range freq {
+		if f > 0 {
+			h.symbols = append(h.symbols, s)
+		}
+	}
+	if len(h.symbols) < 2 {
+		// 单一符号不占用任何位
+		return h
+	}
+
+	counts := make([]uint32, len(freq))
+	copy(counts, freq)
+	for minCount := uint32(1); ; minCount *= 2 {
+		if vp8lHuffmanLengths(counts, h.lengths, maxLength) {
+			break
+		}
+		// 码长超限：抬高低频符号的计数后重试
+		for i, f := range freq {
+			if f > 0 && f < minCount {
+				counts[i] = minCount
+			}
+		}
+	}
+
+	var blCount [vp8lMaxCodeLength + 1]uint32
+	for _, l := range h.lengths {
+		blCount[l]++
+	}
+	blCount[0] = 0
+	var next [vp8lMaxCodeLength + 1]uint32
+	code := uint32(0)
+	for bits := 1; bits <= vp8lMaxCodeLength; bits++ {
+		code = (code + blCount[bits-1]) << 1
+		next[bits] = code
+	}
+	for s, l := range h.lengths {
+		if l == 0 {
+			continue
+		}
+		c := next[l]
+		next[l]++
+		var rev uint32
+		for i := uint8(0); i < l; i++ {
+			rev = rev<<1 | (c>>i)&1
+		}
+		h.codes[s] = rev
+	}
+	return h
+}
+
+// vp8lHuffmanLengths 计算霍夫曼码长，超过 maxLength 时返回 false
+func vp8lHuffmanLengths(counts []uint32, lengths []uint8, maxLength int) bool {
+	type node struct {
+		weight uint64
+		symbol int
+		left   int
+		right  int
+	}
+	var nodes []node
+	for s, c := range counts {
+		if c > 0 {
+			nodes = append(nodes, node{weight: uint64(c), symbol: s, left: -1, right: -1})
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].weight < nodes[j].weight })
+
+	// 双队列法构建霍夫曼树：叶子已排序，内部节点按生成顺序天然有序
+	leaves := len(nodes)
+	li, qi := 0, leaves
+	pick := func() int {
+		if li < leaves && (qi >= len(nodes) || nodes[li].weight <= nodes[qi].weight) {
+			li++
+			return li - 1
+		}
+		qi++
+		return qi - 1
+	}
+	for len(nodes) < 2*leaves-1 {
+		a := pick()
+		b := pick()
+		nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, symbol: -1, left: a, right: b})
+	}
+
+	depth := make([]int, len(nodes))
+	for i := len(nodes) - 1; i >= leaves; i-- {
+		depth[nodes[i].left] = depth[i] + 1
+		depth[nodes[i].right] = depth[i] + 1
+	}
+	for i := 0; i < leaves; i++ {
+		if depth[i] > maxLength {
+			return false
+		}
+	}
+	for i := range lengths {
+		lengths[i] = 0
+	}
+	for i := 0; i < leaves; i++ {
+		lengths[nodes[i].symbol] = uint8(depth[i])
+	}
+	return true
+}
+
+// writeHeader 写入前缀码定义
+func (h *vp8lHuffman) writeHeader(bw *vp8lBitWriter) {
+	// 不超过两个且小于 256 的符号使用简单码
+	if len(h.symbols) <= 2 && (len(h.symbols) == 0 || h.symbols[len(h.symbols)-1] < 256) {
+		symbols := h.symbols
+		if len(symbols) == 0 {
+			symbols = []int{0}
+		}
+		bw.write(1, 1)
+		bw.write(uint32(len(symbols)-1), 1)
+		if symbols[0] < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(symbols[0]), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(symbols[0]), 8)
+		}
+		if len(symbols) == 2 {
+			bw.write(uint32(symbols[1]), 8)
+			// 简单码中两个符号按出现顺序分配 0/1，与规范码一致
+			h.lengths[symbols[0]], h.codes[symbols[0]] = 1, 0
+			h.lengths[symbols[1]], h.codes[symbols[1]] = 1, 1
+		}
+		return
+	}
+
+	lengths := h.lengths
+	if len(h.symbols) == 1 {
+		// 单一符号的普通码：声明码长 1，解码端按零位处理
+		lengths = make([]uint8, len(h.lengths))
+		lengths[h.symbols[0]] = 1
+	}
+
+	// 码长序列使用 16/17/18 做游程压缩
+	type clToken struct {
+		symbol int
+		extra  uint32
+		bits   uint
+	}
+	var tokens []clToken
+	prev := uint8(8)
+	for i := 0; i < len(lengths); {
+		l := lengths[i]
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == l {
+			run++
+		}
+		switch {
+		case l == 0 && run >= 11:
+			if run > 138 {
+				run = 138
+			}
+			tokens = append(tokens, clToken{18, uint32(run - 11), 7})
+		case l == 0 && run >= 3:
+			if run > 10 {
+				run = 10
+			}
+			tokens = append(tokens, clToken{17, uint32(run - 3), 3})
+		case l != 0 && l == prev && run >= 3:
+			if run > 6 {
+				run = 6
+			}
+			tokens = append(tokens, clToken{16, uint32(run - 3), 2})
+		default:
+			run = 1
+			tokens = append(tokens, clToken{int(l), 0, 0})
+			if l != 0 {
+				prev = l
+			}
+		}
+		i += run
+	}
+
+	freq := make([]uint32, 19)
+	for _, t := range tokens {
+		freq[t.symbol]++
+	}
+	clCode := newVP8LHuffman(freq, 7)
+	clLengths := clCode.lengths
+	if len(clCode.symbols) == 1 {
+		clLengths = make([]uint8, 19)
+		clLengths[clCode.symbols[0]] = 1
+	}
+
+	numCodes := 4
+	for i := len(vp8lCodeLengthOrder) - 1; i >= 4; i-- {
+		if clLengths[vp8lCodeLengthOrder[i]] != 0 {
+			numCodes = i + 1
+			break
+		}
+	}
+	bw.write(0, 1)
+	bw.write(uint32(numCodes-4), 4)
+	for i := 0; i < numCodes; i++ {
+		bw.write(uint32(clLengths[vp8lCodeLengthOrder[i]]), 3)
+	}
+	bw.write(0, 1) // 码长覆盖整个字母表
+	for _, t := range tokens {
+		clCode.writeSymbol(bw, t.symbol)
+		bw.write(t.extra, t.bits)
+	}
+}
+
+// writeSymbol 写入一个符号
+func (h *vp8lHuffman) writeSymbol(bw *vp8lBitWriter, symbol int) {
+	bw.write(h.codes[symbol], uint(h.lengths[symbol]))
+}
+
+// vp8lBitWriter 低位优先的位写入器
+type vp8lBitWriter struct {
+	buf  []byte
+	acc  uint64
+	nacc uint
+}
+
+func (b *vp8lBitWriter) write(v uint32, n uint) {
+	if n == 0 {
+		return
+	}
+	b.acc |= uint64(v&(1<<n-1)) << b.nacc
+	b.nacc += n
+	for b.nacc >= 8 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc >>= 8
+		b.nacc -= 8
+	}
+}
+
+func (b *vp8lBitWriter) bytes() []byte {
+	if b.nacc > 0 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc, b.nacc = 0, 0
+	}
+	return b.buf
+}
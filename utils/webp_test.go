@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// webpPatterns 生成各种像素分布的测试图，覆盖字面像素、预测残差与 LZ77 反向引用
+var webpPatterns = map[string]func(x, y, w, h int, rng *rand.Rand) color.NRGBA{
+	"solid": func(x, y, w, h int, rng *rand.Rand) color.NRGBA {
+		return color.NRGBA{0x33, 0x99, 0xcc, 0xff}
+	},
+	"transparent": func(x, y, w, h int, rng *rand.Rand) color.NRGBA {
+		return color.NRGBA{}
+	},
+	"gradient": func(x, y, w, h int, rng *rand.Rand) color.NRGBA {
+		return color.NRGBA{uint8(x * 255 / w), uint8(y * 255 / h), uint8((x + y) * 255 / (w + h)), 0xff}
+	},
+	"alpha gradient": func(x, y, w, h int, rng *rand.Rand) color.NRGBA {
+		return color.NRGBA{0xff, uint8(x * 7), 0x10, uint8(y * 255 / h)}
+	},
+	"checker": func(x, y, w, h int, rng *rand.Rand) color.NRGBA {
+		if (x/4+y/4)%2 == 0 {
+			return color.NRGBA{0, 0, 0, 0xff}
+		}
+		return color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	},
+	"noise": func(x, y, w, h int, rng *rand.Rand) color.NRGBA {
+		return color.NRGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 0xff}
+	},
+	"alpha noise": func(x, y, w, h int, rng *rand.Rand) color.NRGBA {
+		return color.NRGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256))}
+	},
+}
+
+// webpRoundTrip 编码后用 x/image/webp 解码，逐像素与原图比较
+func webpRoundTrip(t *testing.T, name string, img image.Image) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, img); err != nil {
+		t.Fatalf("%s: encode: %v", name, err)
+	}
+	decoded, err := webp.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("%s: decode: %v", name, err)
+	}
+	b := img.Bounds()
+	if decoded.Bounds().Dx() != b.Dx() || decoded.Bounds().Dy() != b.Dy() {
+		t.Fatalf("%s: decoded size %v, want %v", name, decoded.Bounds().Size(), b.Size())
+	}
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			want := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+			if got != want {
+				t.Fatalf("%s: pixel (%d,%d) = %v, want %v", name, x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	sizes := []image.Point{{1, 1}, {2, 3}, {3, 2}, {7, 5}, {16, 16}, {17, 33}, {100, 1}, {1, 100}, {64, 64}, {257, 129}}
+	for name, pattern := range webpPatterns {
+		for _, size := range sizes {
+			rng := rand.New(rand.NewSource(int64(size.X*1000 + size.Y)))
+			img := image.NewNRGBA(image.Rect(0, 0, size.X, size.Y))
+			for y := 0; y < size.Y; y++ {
+				for x := 0; x < size.X; x++ {
+					img.SetNRGBA(x, y, pattern(x, y, size.X, size.Y, rng))
+				}
+			}
+			webpRoundTrip(t, name+" "+size.String(), img)
+		}
+	}
+}
+
+func TestEncodeWebPImageTypes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	src := image.NewNRGBA(image.Rect(0, 0, 23, 19))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(rng.Intn(256))
+	}
+
+	rgba := image.NewRGBA(src.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), src, image.Point{}, draw.Src)
+	gray := image.NewGray(src.Bounds())
+	draw.Draw(gray, gray.Bounds(), src, image.Point{}, draw.Src)
+	paletted := image.NewPaletted(src.Bounds(), color.Palette{color.Black, color.White, color.NRGBA{0xff, 0, 0, 0x80}, color.Transparent})
+	draw.Draw(paletted, paletted.Bounds(), src, image.Point{}, draw.Src)
+	ycbcr := image.NewYCbCr(src.Bounds(), image.YCbCrSubsampleRatio420)
+	for i := range ycbcr.Y {
+		ycbcr.Y[i] = uint8(rng.Intn(256))
+	}
+	for i := range ycbcr.Cb {
+		ycbcr.Cb[i], ycbcr.Cr[i] = uint8(rng.Intn(256)), uint8(rng.Intn(256))
+	}
+
+	for name, img := range map[string]image.Image{
+		"rgba":     rgba,
+		"gray":     gray,
+		"paletted": paletted,
+		"ycbcr":    ycbcr,
+		// 起点不为 0 的子图
+		"sub image": src.SubImage(image.Rect(5, 3, 20, 17)),
+	} {
+		webpRoundTrip(t, name, img)
+	}
+}
+
+func TestEncodeWebPInvalidSize(t *testing.T) {
+	for _, r := range []image.Rectangle{image.Rect(0, 0, 0, 10), image.Rect(0, 0, 10, 0), image.Rect(0, 0, vp8lMaxSize+1, 1)} {
+		if err := EncodeWebP(&bytes.Buffer{}, image.NewNRGBA(r)); err == nil {
+			t.Errorf("size %v: expected error", r.Size())
+		}
+	}
+}